@@ -38,6 +38,12 @@ type MondooAuditConfigSpec struct {
 
 type Filtering struct {
 	Namespaces FilteringSpec `json:"namespaces,omitempty"`
+
+	// NamespaceSelector limits watching/scanning to the namespaces whose labels match the selector. It is
+	// applied in addition to the Include and Exclude lists of Namespaces. Changes to the labels of a namespace
+	// are picked up automatically.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
 }
 
 type FilteringSpec struct {
@@ -91,11 +97,69 @@ type KubernetesResources struct {
 	// DEPRECATED: ContainerImageScanning determines whether container images are being scanned. The current implementation
 	// runs a separate job once every 24h that scans the container images running in the cluster.
 	ContainerImageScanning bool `json:"containerImageScanning,omitempty"`
+
+	// Schedule is a cron expression (e.g. "0 * * * *" or "@hourly") which defines when the scan runs.
+	// If not set, the scan runs hourly, starting one minute after it was enabled.
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+
+	// ScheduleJitterMinutes shifts the minute of the schedule by a stable offset in the range
+	// [0, ScheduleJitterMinutes). The offset is different for every MondooAuditConfig, which spreads
+	// the scans of multiple clusters. The hours of the schedule are not changed by the jitter. Only used
+	// if Schedule is set.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=59
+	// +optional
+	ScheduleJitterMinutes int32 `json:"scheduleJitterMinutes,omitempty"`
+
+	// MonitoredResources are additional kinds, e.g. from CRDs, whose changes are scanned right away like the
+	// changes of the built-in workload kinds. Kinds whose CRD doesn't exist yet are watched once it is created.
+	// +optional
+	MonitoredResources []MonitoredResource `json:"monitoredResources,omitempty"`
+}
+
+// MonitoredResource is a kind of resources which is watched for changes.
+type MonitoredResource struct {
+	// Group is the API group of the kind. It is empty for the core API group.
+	// +optional
+	Group string `json:"group,omitempty"`
+	// +kubebuilder:validation:MinLength=1
+	Version string `json:"version"`
+	// +kubebuilder:validation:MinLength=1
+	Kind string `json:"kind"`
 }
 
 type Nodes struct {
 	Enable    bool                        `json:"enable,omitempty"`
 	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Schedule is a cron expression (e.g. "0 * * * *" or "@hourly") which defines when the scan runs.
+	// If not set, the scan runs hourly, starting one minute after it was enabled.
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+
+	// ScheduleJitterMinutes shifts the minute of the schedule by a stable offset in the range
+	// [0, ScheduleJitterMinutes). The offset is different for every MondooAuditConfig, which spreads
+	// the scans of multiple clusters. The hours of the schedule are not changed by the jitter. Only used
+	// if Schedule is set.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=59
+	// +optional
+	ScheduleJitterMinutes int32 `json:"scheduleJitterMinutes,omitempty"`
+
+	// Style selects how the nodes are scanned. The "cronjob" style creates a CronJob and an inventory ConfigMap
+	// for every node. The "daemonset" style runs a DaemonSet with a long-lived pod on every node, which scans the
+	// node every IntervalTimer minutes.
+	// +kubebuilder:validation:Enum=cronjob;daemonset
+	// +kubebuilder:default=cronjob
+	// +optional
+	Style NodeScanStyle `json:"style,omitempty"`
+
+	// IntervalTimer is the number of minutes between two scans of a node. Only used by the "daemonset" style.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=60
+	// +optional
+	IntervalTimer int32 `json:"intervalTimer,omitempty"`
 }
 
 type Admission struct {
@@ -104,7 +168,7 @@ type Admission struct {
 	// Mode represents whether the webhook will behave in a "permissive" mode (the default) which
 	// will only scan and report on k8s resources or "enforcing" mode where depending
 	// on the scan results may reject the k8s resource creation/modification.
-	// +kubebuilder:validation:Enum=permissive;enforcing
+	// +kubebuilder:validation:Enum=permissive;enforcing;audit
 	// +kubebuilder:default=permissive
 	Mode AdmissionMode `json:"mode,omitempty"`
 	// Number of replicas for the admission webhook.
@@ -118,11 +182,120 @@ type Admission struct {
 	// during its operation.
 	// +kubebuilder:default=mondoo-operator-webhook
 	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// ScoreThreshold is the minimum score a resource needs to pass the admission check. In "enforcing"
+	// mode, resources with a lower score are rejected. The default of 100 only admits resources without
+	// any failed check.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +kubebuilder:default=100
+	// +optional
+	ScoreThreshold *int32 `json:"scoreThreshold,omitempty"`
+	// Exemptions restricts who may exempt resources from the admission checks.
+	// +optional
+	Exemptions AdmissionExemptions `json:"exemptions,omitempty"`
+	// Resources are the kinds of resources which are checked by the admission webhook. If not set, Pods,
+	// Deployments, DaemonSets, StatefulSets, Jobs and CronJobs are checked.
+	// +optional
+	Resources []AdmissionResource `json:"resources,omitempty"`
+	// DecisionLog configures where the admission decisions are recorded.
+	// +optional
+	DecisionLog AdmissionDecisionLog `json:"decisionLog,omitempty"`
+	// MaxInFlightScans is the number of concurrent scans per webhook replica. During rollouts, requests over the
+	// limit are answered right away with the last known verdict of the workload or the default response of the mode.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=20
+	// +optional
+	MaxInFlightScans *int32 `json:"maxInFlightScans,omitempty"`
+	// TimeoutSeconds is the time the API server waits for an admission decision of the webhook. The webhook
+	// answers before it passes, with the default response of the mode if the scan takes too long.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=30
+	// +kubebuilder:default=10
+	// +optional
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// AdmissionDecisionLog configures the sinks of the structured decision log. Every admission decision is
+// recorded as a JSON object with the identity of the resource, the requesting user, the mode, score,
+// decision, skip reason and latency.
+type AdmissionDecisionLog struct {
+	// Stdout writes the decisions as JSON lines to the log of the webhook.
+	// +optional
+	Stdout bool `json:"stdout,omitempty"`
+	// +optional
+	File DecisionLogFile `json:"file,omitempty"`
+	// +optional
+	HTTP DecisionLogHTTP `json:"http,omitempty"`
+}
+
+// DecisionLogFile writes the decisions as JSON lines to a rotating file in /var/log/mondoo.
+type DecisionLogFile struct {
+	Enable bool `json:"enable,omitempty"`
+	// VolumeClaimName is the name of a PersistentVolumeClaim which is mounted to store the files. If not set,
+	// the files are stored in an emptyDir volume and are lost when the webhook Pod is deleted.
+	// +optional
+	VolumeClaimName string `json:"volumeClaimName,omitempty"`
+	// MaxSizeMB is the size in megabytes after which the file is rotated.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=100
+	// +optional
+	MaxSizeMB int32 `json:"maxSizeMB,omitempty"`
+	// MaxBackups is the number of rotated files which are kept.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=5
+	// +optional
+	MaxBackups *int32 `json:"maxBackups,omitempty"`
+}
+
+// DecisionLogHTTP sends the decisions in batches as a JSON array to an HTTP endpoint. Failed requests are
+// retried with an exponential backoff.
+type DecisionLogHTTP struct {
+	// Endpoint is the URL the decisions are posted to. The HTTP sink is disabled if it is empty.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+	// BatchSize is the maximum number of decisions sent in one request.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=100
+	// +optional
+	BatchSize int32 `json:"batchSize,omitempty"`
+	// MaxRetries is the number of retries for requests which failed with a network error or a 429 or 5xx status.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=3
+	// +optional
+	MaxRetries *int32 `json:"maxRetries,omitempty"`
+}
+
+// AdmissionResource is a kind of resource in the lowercase plural form, as it is used in RBAC rules.
+// +kubebuilder:validation:Enum=pods;deployments;daemonsets;statefulsets;jobs;cronjobs;services;ingresses;networkpolicies;roles;clusterroles;rolebindings;clusterrolebindings;namespaces
+type AdmissionResource string
+
+// AdmissionExemptions lists the users and groups which may exempt a resource from the admission checks with the
+// "admission.k8s.mondoo.com/skip" annotation. Exempted resources are still scanned, but always admitted. If both
+// lists are empty, every user may exempt resources.
+type AdmissionExemptions struct {
+	// +optional
+	Users []string `json:"users,omitempty"`
+	// +optional
+	Groups []string `json:"groups,omitempty"`
 }
 
 type Containers struct {
 	Enable    bool                        `json:"enable,omitempty"`
 	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Schedule is a cron expression (e.g. "0 * * * *" or "@hourly") which defines when the scan runs.
+	// If not set, the scan runs daily, starting one minute after it was enabled.
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+
+	// ScheduleJitterMinutes shifts the minute of the schedule by a stable offset in the range
+	// [0, ScheduleJitterMinutes). The offset is different for every MondooAuditConfig, which spreads
+	// the scans of multiple clusters. The hours of the schedule are not changed by the jitter. Only used
+	// if Schedule is set.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=59
+	// +optional
+	ScheduleJitterMinutes int32 `json:"scheduleJitterMinutes,omitempty"`
 }
 
 type Image struct {
@@ -139,12 +312,27 @@ const (
 	ManualProvisioning      CertificateProvisioningMode = "manual"
 )
 
+// NodeScanStyle specifies how the nodes are scanned
+type NodeScanStyle string
+
+const (
+	NodeScanStyleCronJob   NodeScanStyle = "cronjob"
+	NodeScanStyleDaemonSet NodeScanStyle = "daemonset"
+)
+
+// MaxScheduleJitterMinutes is the largest allowed ScheduleJitterMinutes. The Maximum validation markers of the
+// ScheduleJitterMinutes fields must use the same value.
+const MaxScheduleJitterMinutes = 59
+
 // AdmissionMode specifies the allowed modes of operation for the webhook admission controller
 type AdmissionMode string
 
 const (
 	Permissive AdmissionMode = "permissive"
 	Enforcing  AdmissionMode = "enforcing"
+	// Audit evaluates resources like Enforcing, but admits them with a warning and counts the would-be denials in
+	// the status of the MondooAuditConfig.
+	Audit AdmissionMode = "audit"
 )
 
 // MondooAuditConfigStatus defines the observed state of MondooAuditConfig
@@ -159,6 +347,40 @@ type MondooAuditConfigStatus struct {
 
 	// ReconciledByOperatorVersion contains the version of the operator which reconciled this MondooAuditConfig
 	ReconciledByOperatorVersion string `json:"reconciledByOperatorVersion,omitempty"`
+
+	// AdmissionAudit counts the resources which would have been denied by the admission webhook in "audit" mode.
+	// It is updated by the webhook.
+	// +optional
+	AdmissionAudit *AdmissionAuditStatus `json:"admissionAudit,omitempty"`
+}
+
+// AdmissionAuditStatus counts the resources which the admission webhook admitted in "audit" mode, but would have
+// denied in "enforcing" mode.
+type AdmissionAuditStatus struct {
+	// WouldBeDenied is the total number of would-be denials.
+	WouldBeDenied int64 `json:"wouldBeDenied,omitempty"`
+	// Namespaces counts the would-be denials per namespace. Cluster-scoped resources are counted without a namespace.
+	// +optional
+	Namespaces []AdmissionAuditNamespace `json:"namespaces,omitempty"`
+	// Workloads counts the would-be denials per workload. Only the workloads with the most would-be denials are kept.
+	// +optional
+	Workloads []AdmissionAuditWorkload `json:"workloads,omitempty"`
+	// LastUpdateTime is the last time the counts were updated.
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+type AdmissionAuditNamespace struct {
+	Namespace     string `json:"namespace,omitempty"`
+	WouldBeDenied int64  `json:"wouldBeDenied"`
+}
+
+type AdmissionAuditWorkload struct {
+	Kind          string `json:"kind"`
+	Namespace     string `json:"namespace,omitempty"`
+	Name          string `json:"name"`
+	WouldBeDenied int64  `json:"wouldBeDenied"`
+	// LastDenialTime is the last time the workload would have been denied.
+	LastDenialTime metav1.Time `json:"lastDenialTime,omitempty"`
 }
 
 type MondooAuditConfigCondition struct {
@@ -170,6 +392,8 @@ type MondooAuditConfigCondition struct {
 	// +kubebuilder:validation:Required
 	// +required
 	Status corev1.ConditionStatus `json:"status"`
+	// ObservedGeneration is the .metadata.generation of the MondooAuditConfig the condition was set for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 	// LastUpdateTime is the last time we probed the condition
 	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
 	// LastTransitionTime is the last time the condition transitioned from one status to another.
@@ -201,6 +425,7 @@ const (
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:storageversion
 
 // MondooAuditConfig is the Schema for the mondooauditconfigs API
 type MondooAuditConfig struct {
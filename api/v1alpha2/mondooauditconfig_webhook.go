@@ -0,0 +1,58 @@
+/*
+Copyright 2022 Mondoo, Inc.
+
+This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at https://mozilla.org/MPL/2.0/.
+*/
+
+package v1alpha2
+
+import (
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+const (
+	defaultScannerServiceAccountName   = "mondoo-operator-k8s-resources-scanning"
+	defaultAdmissionServiceAccountName = "mondoo-operator-webhook"
+	defaultAdmissionScoreThreshold     = 100
+)
+
+// SetupWebhookWithManager registers the conversion and the defaulting webhooks for the MondooAuditConfig.
+func (r *MondooAuditConfig) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-k8s-mondoo-com-v1alpha2-mondooauditconfig,mutating=true,failurePolicy=ignore,sideEffects=None,groups=k8s.mondoo.com,resources=mondooauditconfigs,verbs=create;update,versions=v1alpha2,name=mmondooauditconfig.k8s.mondoo.com,admissionReviewVersions=v1
+
+var _ webhook.Defaulter = &MondooAuditConfig{}
+
+// Default implements webhook.Defaulter. It applies the same defaults as the CRD schema, such that
+// objects which were created before a default was introduced get it on their next update.
+func (r *MondooAuditConfig) Default() {
+	if r.Spec.Scanner.ServiceAccountName == "" {
+		r.Spec.Scanner.ServiceAccountName = defaultScannerServiceAccountName
+	}
+	if r.Spec.Scanner.Replicas == nil {
+		r.Spec.Scanner.Replicas = pointer.Int32(1)
+	}
+	if r.Spec.Admission.Mode == "" {
+		r.Spec.Admission.Mode = Permissive
+	}
+	if r.Spec.Admission.Replicas == nil {
+		r.Spec.Admission.Replicas = pointer.Int32(1)
+	}
+	if r.Spec.Admission.CertificateProvisioning.Mode == "" {
+		r.Spec.Admission.CertificateProvisioning.Mode = ManualProvisioning
+	}
+	if r.Spec.Admission.ServiceAccountName == "" {
+		r.Spec.Admission.ServiceAccountName = defaultAdmissionServiceAccountName
+	}
+	if r.Spec.Admission.ScoreThreshold == nil {
+		r.Spec.Admission.ScoreThreshold = pointer.Int32(defaultAdmissionScoreThreshold)
+	}
+}
@@ -56,6 +56,9 @@ type MondooOperatorConfigCondition struct {
 	Type MondooOperatorConfigConditionType `json:"type"`
 	// Status is the status of the condition.
 	Status corev1.ConditionStatus `json:"status"`
+	// ObservedGeneration is the .metadata.generation of the MondooOperatorConfig the condition was set for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 	// LastUpdateTime is the last time the condition was updated.
 	// +optional
 	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
@@ -80,6 +83,7 @@ const (
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:storageversion
 //+kubebuilder:resource:scope=Cluster
 
 // MondooOperatorConfig is the Schema for the mondoooperatorconfigs API
@@ -14,7 +14,8 @@ file, You can obtain one at https://mozilla.org/MPL/2.0/.
 package v1alpha2
 
 import (
-	"k8s.io/api/core/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -28,6 +29,28 @@ func (in *Admission) DeepCopyInto(out *Admission) {
 		**out = **in
 	}
 	out.CertificateProvisioning = in.CertificateProvisioning
+	if in.ScoreThreshold != nil {
+		in, out := &in.ScoreThreshold, &out.ScoreThreshold
+		*out = new(int32)
+		**out = **in
+	}
+	in.Exemptions.DeepCopyInto(&out.Exemptions)
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]AdmissionResource, len(*in))
+		copy(*out, *in)
+	}
+	in.DecisionLog.DeepCopyInto(&out.DecisionLog)
+	if in.MaxInFlightScans != nil {
+		in, out := &in.MaxInFlightScans, &out.MaxInFlightScans
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Admission.
@@ -40,6 +63,107 @@ func (in *Admission) DeepCopy() *Admission {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdmissionAuditNamespace) DeepCopyInto(out *AdmissionAuditNamespace) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdmissionAuditNamespace.
+func (in *AdmissionAuditNamespace) DeepCopy() *AdmissionAuditNamespace {
+	if in == nil {
+		return nil
+	}
+	out := new(AdmissionAuditNamespace)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdmissionAuditStatus) DeepCopyInto(out *AdmissionAuditStatus) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]AdmissionAuditNamespace, len(*in))
+		copy(*out, *in)
+	}
+	if in.Workloads != nil {
+		in, out := &in.Workloads, &out.Workloads
+		*out = make([]AdmissionAuditWorkload, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdmissionAuditStatus.
+func (in *AdmissionAuditStatus) DeepCopy() *AdmissionAuditStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AdmissionAuditStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdmissionAuditWorkload) DeepCopyInto(out *AdmissionAuditWorkload) {
+	*out = *in
+	in.LastDenialTime.DeepCopyInto(&out.LastDenialTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdmissionAuditWorkload.
+func (in *AdmissionAuditWorkload) DeepCopy() *AdmissionAuditWorkload {
+	if in == nil {
+		return nil
+	}
+	out := new(AdmissionAuditWorkload)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdmissionDecisionLog) DeepCopyInto(out *AdmissionDecisionLog) {
+	*out = *in
+	in.File.DeepCopyInto(&out.File)
+	in.HTTP.DeepCopyInto(&out.HTTP)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdmissionDecisionLog.
+func (in *AdmissionDecisionLog) DeepCopy() *AdmissionDecisionLog {
+	if in == nil {
+		return nil
+	}
+	out := new(AdmissionDecisionLog)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdmissionExemptions) DeepCopyInto(out *AdmissionExemptions) {
+	*out = *in
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdmissionExemptions.
+func (in *AdmissionExemptions) DeepCopy() *AdmissionExemptions {
+	if in == nil {
+		return nil
+	}
+	out := new(AdmissionExemptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CertificateProvisioning) DeepCopyInto(out *CertificateProvisioning) {
 	*out = *in
@@ -86,10 +210,55 @@ func (in *Containers) DeepCopy() *Containers {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DecisionLogFile) DeepCopyInto(out *DecisionLogFile) {
+	*out = *in
+	if in.MaxBackups != nil {
+		in, out := &in.MaxBackups, &out.MaxBackups
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DecisionLogFile.
+func (in *DecisionLogFile) DeepCopy() *DecisionLogFile {
+	if in == nil {
+		return nil
+	}
+	out := new(DecisionLogFile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DecisionLogHTTP) DeepCopyInto(out *DecisionLogHTTP) {
+	*out = *in
+	if in.MaxRetries != nil {
+		in, out := &in.MaxRetries, &out.MaxRetries
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DecisionLogHTTP.
+func (in *DecisionLogHTTP) DeepCopy() *DecisionLogHTTP {
+	if in == nil {
+		return nil
+	}
+	out := new(DecisionLogHTTP)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Filtering) DeepCopyInto(out *Filtering) {
 	*out = *in
 	in.Namespaces.DeepCopyInto(&out.Namespaces)
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Filtering.
@@ -145,6 +314,11 @@ func (in *Image) DeepCopy() *Image {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KubernetesResources) DeepCopyInto(out *KubernetesResources) {
 	*out = *in
+	if in.MonitoredResources != nil {
+		in, out := &in.MonitoredResources, &out.MonitoredResources
+		*out = make([]MonitoredResource, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubernetesResources.
@@ -261,7 +435,7 @@ func (in *MondooAuditConfigSpec) DeepCopyInto(out *MondooAuditConfigSpec) {
 	out.MondooCredsSecretRef = in.MondooCredsSecretRef
 	out.MondooTokenSecretRef = in.MondooTokenSecretRef
 	in.Scanner.DeepCopyInto(&out.Scanner)
-	out.KubernetesResources = in.KubernetesResources
+	in.KubernetesResources.DeepCopyInto(&out.KubernetesResources)
 	in.Nodes.DeepCopyInto(&out.Nodes)
 	in.Admission.DeepCopyInto(&out.Admission)
 	out.ConsoleIntegration = in.ConsoleIntegration
@@ -294,6 +468,11 @@ func (in *MondooAuditConfigStatus) DeepCopyInto(out *MondooAuditConfigStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.AdmissionAudit != nil {
+		in, out := &in.AdmissionAudit, &out.AdmissionAudit
+		*out = new(AdmissionAuditStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MondooAuditConfigStatus.
@@ -420,6 +599,21 @@ func (in *MondooOperatorConfigStatus) DeepCopy() *MondooOperatorConfigStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MonitoredResource) DeepCopyInto(out *MonitoredResource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonitoredResource.
+func (in *MonitoredResource) DeepCopy() *MonitoredResource {
+	if in == nil {
+		return nil
+	}
+	out := new(MonitoredResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Nodes) DeepCopyInto(out *Nodes) {
 	*out = *in
@@ -449,7 +643,7 @@ func (in *Scanner) DeepCopyInto(out *Scanner) {
 	out.PrivateRegistriesPullSecretRef = in.PrivateRegistriesPullSecretRef
 	if in.Env != nil {
 		in, out := &in.Env, &out.Env
-		*out = make([]v1.EnvVar, len(*in))
+		*out = make([]corev1.EnvVar, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
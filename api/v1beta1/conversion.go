@@ -0,0 +1,73 @@
+/*
+Copyright 2022 Mondoo, Inc.
+
+This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at https://mozilla.org/MPL/2.0/.
+*/
+
+package v1beta1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConversionDataAnnotation holds the v1alpha2 spec fields which have no counterpart in v1beta1. The annotation is
+// only present on objects which were converted from v1alpha2 and is removed again when converting back, which
+// makes the conversion of the spec lossless in both directions. The status is not stored in the annotation, because
+// the annotation would change with every status update. The lastUpdateTime of the v1alpha2 conditions is dropped
+// and set to the lastTransitionTime when converting back.
+const ConversionDataAnnotation = "k8s.mondoo.com/v1alpha2-conversion-data"
+
+type conversionData struct {
+	// ContainerImageScanning is the deprecated .spec.kubernetesResources.containerImageScanning.
+	ContainerImageScanning bool `json:"containerImageScanning,omitempty"`
+	// ContainersEnable is the original .spec.containers.enable. It is only stored if ContainerImageScanning
+	// is set, because then .spec.containers.enable is always true in v1beta1.
+	ContainersEnable bool `json:"containersEnable,omitempty"`
+}
+
+// popConversionData removes the conversion data annotation from the provided object meta and returns
+// the decoded data.
+func popConversionData(meta *metav1.ObjectMeta) (conversionData, error) {
+	data := conversionData{}
+	raw, ok := meta.Annotations[ConversionDataAnnotation]
+	if !ok {
+		return data, nil
+	}
+
+	delete(meta.Annotations, ConversionDataAnnotation)
+	if len(meta.Annotations) == 0 {
+		meta.Annotations = nil
+	}
+
+	if err := json.Unmarshal([]byte(raw), &data); err != nil {
+		return data, fmt.Errorf("failed to decode annotation %s: %w", ConversionDataAnnotation, err)
+	}
+	return data, nil
+}
+
+// pushConversionData stores the conversion data as an annotation on the provided object meta. Nothing is
+// stored if there is no data to preserve.
+func pushConversionData(meta *metav1.ObjectMeta, data conversionData) error {
+	delete(meta.Annotations, ConversionDataAnnotation)
+	if !data.ContainerImageScanning {
+		if len(meta.Annotations) == 0 {
+			meta.Annotations = nil
+		}
+		return nil
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode annotation %s: %w", ConversionDataAnnotation, err)
+	}
+	if meta.Annotations == nil {
+		meta.Annotations = make(map[string]string)
+	}
+	meta.Annotations[ConversionDataAnnotation] = string(raw)
+	return nil
+}
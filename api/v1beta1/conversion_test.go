@@ -0,0 +1,302 @@
+/*
+Copyright 2022 Mondoo, Inc.
+
+This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at https://mozilla.org/MPL/2.0/.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	"go.mondoo.com/mondoo-operator/api/v1alpha2"
+)
+
+func testHubAuditConfig() *v1alpha2.MondooAuditConfig {
+	return &v1alpha2.MondooAuditConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "mondoo-client",
+			Namespace:   "mondoo-operator",
+			Generation:  3,
+			Annotations: map[string]string{"team": "platform"},
+		},
+		Spec: v1alpha2.MondooAuditConfigSpec{
+			MondooCredsSecretRef: corev1.LocalObjectReference{Name: "mondoo-client"},
+			MondooTokenSecretRef: corev1.LocalObjectReference{Name: "mondoo-token"},
+			Scanner: v1alpha2.Scanner{
+				ServiceAccountName: "scanner",
+				Image:              v1alpha2.Image{Name: "mondoo", Tag: "latest"},
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+				},
+				Replicas:                       pointer.Int32(2),
+				PrivateRegistriesPullSecretRef: corev1.LocalObjectReference{Name: "pull-secret"},
+				Env:                            []corev1.EnvVar{{Name: "FOO", Value: "bar"}},
+			},
+			KubernetesResources: v1alpha2.KubernetesResources{
+				Enable:                true,
+				Schedule:              "0 * * * *",
+				ScheduleJitterMinutes: 10,
+				MonitoredResources: []v1alpha2.MonitoredResource{
+					{Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"},
+				},
+			},
+			Nodes: v1alpha2.Nodes{
+				Enable:        true,
+				Schedule:      "@daily",
+				Style:         v1alpha2.NodeScanStyleDaemonSet,
+				IntervalTimer: 30,
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+				},
+			},
+			Containers: v1alpha2.Containers{Enable: true, Schedule: "30 2 * * *"},
+			Admission: v1alpha2.Admission{
+				Enable:                  true,
+				Image:                   v1alpha2.Image{Name: "operator", Tag: "v1"},
+				Mode:                    v1alpha2.Audit,
+				Replicas:                pointer.Int32(3),
+				CertificateProvisioning: v1alpha2.CertificateProvisioning{Mode: v1alpha2.CertManagerProvisioning},
+				ServiceAccountName:      "webhook",
+				ScoreThreshold:          pointer.Int32(80),
+				Exemptions:              v1alpha2.AdmissionExemptions{Groups: []string{"sre"}},
+				Resources:               []v1alpha2.AdmissionResource{"pods", "services"},
+				DecisionLog: v1alpha2.AdmissionDecisionLog{
+					Stdout: true,
+					File:   v1alpha2.DecisionLogFile{Enable: true, VolumeClaimName: "audit", MaxSizeMB: 10, MaxBackups: pointer.Int32(2)},
+					HTTP:   v1alpha2.DecisionLogHTTP{Endpoint: "https://audit.example.com", BatchSize: 50, MaxRetries: pointer.Int32(1)},
+				},
+				MaxInFlightScans: pointer.Int32(5),
+				TimeoutSeconds:   pointer.Int32(15),
+			},
+			ConsoleIntegration: v1alpha2.ConsoleIntegration{Enable: true},
+			Filtering: v1alpha2.Filtering{
+				Namespaces: v1alpha2.FilteringSpec{Include: []string{"app"}, Exclude: []string{"kube-system"}},
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"team": "payments"},
+				},
+			},
+		},
+		Status: v1alpha2.MondooAuditConfigStatus{
+			Pods: []string{"pod-a"},
+			Conditions: []v1alpha2.MondooAuditConfigCondition{
+				{
+					Type:               v1alpha2.NodeScanningDegraded,
+					Status:             corev1.ConditionFalse,
+					ObservedGeneration: 3,
+					LastUpdateTime:     metav1.Unix(1666000000, 0),
+					LastTransitionTime: metav1.Unix(1665000000, 0),
+					Reason:             "NodeScanningAvailable",
+					Message:            "Node Scanning is available",
+				},
+			},
+			ReconciledByOperatorVersion: "v1.15.2",
+			AdmissionAudit: &v1alpha2.AdmissionAuditStatus{
+				WouldBeDenied:  3,
+				Namespaces:     []v1alpha2.AdmissionAuditNamespace{{Namespace: "app", WouldBeDenied: 3}},
+				Workloads:      []v1alpha2.AdmissionAuditWorkload{{Kind: "Deployment", Namespace: "app", Name: "web", WouldBeDenied: 3}},
+				LastUpdateTime: metav1.Unix(1666000000, 0),
+			},
+		},
+	}
+}
+
+func TestMondooAuditConfig_HubRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*v1alpha2.MondooAuditConfig)
+	}{
+		{name: "all fields set", mutate: func(*v1alpha2.MondooAuditConfig) {}},
+		{
+			name: "deprecated container image scanning",
+			mutate: func(m *v1alpha2.MondooAuditConfig) {
+				m.Spec.KubernetesResources.ContainerImageScanning = true
+				m.Spec.Containers.Enable = false
+			},
+		},
+		{
+			name: "deprecated container image scanning and containers enabled",
+			mutate: func(m *v1alpha2.MondooAuditConfig) {
+				m.Spec.KubernetesResources.ContainerImageScanning = true
+			},
+		},
+		{
+			name: "empty",
+			mutate: func(m *v1alpha2.MondooAuditConfig) {
+				*m = v1alpha2.MondooAuditConfig{}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hub := testHubAuditConfig()
+			test.mutate(hub)
+
+			spoke := &MondooAuditConfig{}
+			require.NoError(t, spoke.ConvertFrom(hub))
+
+			converted := &v1alpha2.MondooAuditConfig{}
+			require.NoError(t, spoke.ConvertTo(converted))
+
+			// The lastUpdateTime of the conditions is lost and rebuilt from the lastTransitionTime, see
+			// TestMondooAuditConfig_ConditionsLoseLastUpdateTime.
+			for i := range hub.Status.Conditions {
+				hub.Status.Conditions[i].LastUpdateTime = hub.Status.Conditions[i].LastTransitionTime
+			}
+			assert.Equal(t, hub, converted)
+		})
+	}
+}
+
+func TestMondooAuditConfig_SpokeRoundTrip(t *testing.T) {
+	hub := testHubAuditConfig()
+	hub.Spec.KubernetesResources.ContainerImageScanning = true
+
+	spoke := &MondooAuditConfig{}
+	require.NoError(t, spoke.ConvertFrom(hub))
+
+	intermediate := &v1alpha2.MondooAuditConfig{}
+	require.NoError(t, spoke.ConvertTo(intermediate))
+
+	converted := &MondooAuditConfig{}
+	require.NoError(t, converted.ConvertFrom(intermediate))
+	assert.Equal(t, spoke, converted)
+}
+
+func TestMondooAuditConfig_ConvertFrom(t *testing.T) {
+	hub := testHubAuditConfig()
+	hub.Spec.KubernetesResources.ContainerImageScanning = true
+	hub.Spec.Containers.Enable = false
+
+	spoke := &MondooAuditConfig{}
+	require.NoError(t, spoke.ConvertFrom(hub))
+
+	assert.True(t, spoke.Spec.Containers.Enable, "deprecated container image scanning must enable the containers scanning")
+	assert.Equal(t, hub.Spec.Scanner.Replicas, spoke.Spec.ScanAPI.Replicas)
+	assert.Equal(t, hub.Spec.Scanner.Resources, spoke.Spec.ScanAPI.Resources)
+	assert.Equal(t, hub.Spec.Scanner.Env, spoke.Spec.ScanAPI.Env)
+	assert.Equal(t, []metav1.Condition{
+		{
+			Type:               NodeScanningDegraded,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: 3,
+			LastTransitionTime: metav1.Unix(1665000000, 0),
+			Reason:             "NodeScanningAvailable",
+			Message:            "Node Scanning is available",
+		},
+	}, spoke.Status.Conditions)
+	assert.Contains(t, spoke.Annotations, ConversionDataAnnotation)
+	assert.Equal(t, "platform", spoke.Annotations["team"])
+}
+
+func TestMondooAuditConfig_ConditionsLoseLastUpdateTime(t *testing.T) {
+	hub := testHubAuditConfig()
+	require.NotEqual(t, hub.Status.Conditions[0].LastTransitionTime, hub.Status.Conditions[0].LastUpdateTime)
+
+	spoke := &MondooAuditConfig{}
+	require.NoError(t, spoke.ConvertFrom(hub))
+
+	converted := &v1alpha2.MondooAuditConfig{}
+	require.NoError(t, spoke.ConvertTo(converted))
+	assert.Equal(t, hub.Status.Conditions[0].LastTransitionTime, converted.Status.Conditions[0].LastUpdateTime)
+	assert.NotEqual(t, hub.Status.Conditions[0].LastUpdateTime, converted.Status.Conditions[0].LastUpdateTime)
+}
+
+func TestMondooAuditConfig_ConvertFrom_StatusNotInAnnotation(t *testing.T) {
+	hub := testHubAuditConfig()
+
+	spoke := &MondooAuditConfig{}
+	require.NoError(t, spoke.ConvertFrom(hub))
+	assert.NotContains(t, spoke.Annotations, ConversionDataAnnotation)
+
+	// A status update must not change the annotation.
+	hub.Status.Conditions[0].LastUpdateTime = metav1.Unix(1667000000, 0)
+	updated := &MondooAuditConfig{}
+	require.NoError(t, updated.ConvertFrom(hub))
+	assert.Equal(t, spoke.Annotations, updated.Annotations)
+}
+
+func TestMondooAuditConfig_ConvertTo_LegacyAnnotation(t *testing.T) {
+	// Objects converted by older operator versions have the lastUpdateTime of the conditions in the annotation.
+	spoke := &MondooAuditConfig{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+			ConversionDataAnnotation: `{"conditionsLastUpdateTime":{"NodeScanningDegraded":"2022-10-17T09:46:40Z"}}`,
+		}},
+		Status: MondooAuditConfigStatus{Conditions: []metav1.Condition{
+			{Type: NodeScanningDegraded, LastTransitionTime: metav1.Unix(1665000000, 0)},
+		}},
+	}
+
+	converted := &v1alpha2.MondooAuditConfig{}
+	require.NoError(t, spoke.ConvertTo(converted))
+	assert.Nil(t, converted.Annotations)
+	assert.Equal(t, metav1.Unix(1665000000, 0), converted.Status.Conditions[0].LastUpdateTime)
+}
+
+func TestMondooAuditConfig_ConvertTo_ContainersDisabled(t *testing.T) {
+	hub := testHubAuditConfig()
+	hub.Spec.KubernetesResources.ContainerImageScanning = true
+
+	spoke := &MondooAuditConfig{}
+	require.NoError(t, spoke.ConvertFrom(hub))
+
+	// Disabling the container image scanning in v1beta1 must not be overridden by the deprecated flag.
+	spoke.Spec.Containers.Enable = false
+
+	converted := &v1alpha2.MondooAuditConfig{}
+	require.NoError(t, spoke.ConvertTo(converted))
+	assert.False(t, converted.Spec.KubernetesResources.ContainerImageScanning)
+	assert.False(t, converted.Spec.Containers.Enable)
+	assert.NotContains(t, converted.Annotations, ConversionDataAnnotation)
+}
+
+func TestMondooAuditConfig_ConvertTo_InvalidAnnotation(t *testing.T) {
+	spoke := &MondooAuditConfig{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{ConversionDataAnnotation: "{"}},
+	}
+	assert.Error(t, spoke.ConvertTo(&v1alpha2.MondooAuditConfig{}))
+}
+
+func TestMondooOperatorConfig_RoundTrip(t *testing.T) {
+	hub := &v1alpha2.MondooOperatorConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: v1alpha2.MondooOperatorConfigName, Generation: 2},
+		Spec: v1alpha2.MondooOperatorConfigSpec{
+			Metrics:                 v1alpha2.Metrics{Enable: true, ResourceLabels: map[string]string{"release": "prometheus"}},
+			SkipContainerResolution: true,
+		},
+		Status: v1alpha2.MondooOperatorConfigStatus{
+			Conditions: []v1alpha2.MondooOperatorConfigCondition{
+				{
+					Type:               v1alpha2.PrometheusMissingCondition,
+					Status:             corev1.ConditionTrue,
+					ObservedGeneration: 2,
+					LastUpdateTime:     metav1.Unix(1666000000, 0),
+					LastTransitionTime: metav1.Unix(1665000000, 0),
+					Reason:             "PrometheusMissing",
+					Message:            "Prometheus is not installed",
+				},
+			},
+		},
+	}
+
+	spoke := &MondooOperatorConfig{}
+	require.NoError(t, spoke.ConvertFrom(hub))
+	assert.Equal(t, PrometheusMissingCondition, spoke.Status.Conditions[0].Type)
+
+	assert.NotContains(t, spoke.Annotations, ConversionDataAnnotation)
+
+	converted := &v1alpha2.MondooOperatorConfig{}
+	require.NoError(t, spoke.ConvertTo(converted))
+	hub.Status.Conditions[0].LastUpdateTime = hub.Status.Conditions[0].LastTransitionTime
+	assert.Equal(t, hub, converted)
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2022 Mondoo, Inc.
+
+This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at https://mozilla.org/MPL/2.0/.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"go.mondoo.com/mondoo-operator/api/v1alpha2"
+)
+
+// ConvertTo converts this MondooAuditConfig to the Hub version (v1alpha2).
+func (src *MondooAuditConfig) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha2.MondooAuditConfig)
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	data, err := popConversionData(&dst.ObjectMeta)
+	if err != nil {
+		return err
+	}
+
+	spec := src.Spec.DeepCopy()
+	dst.Spec = v1alpha2.MondooAuditConfigSpec{
+		MondooCredsSecretRef: spec.MondooCredsSecretRef,
+		MondooTokenSecretRef: spec.MondooTokenSecretRef,
+		Scanner: v1alpha2.Scanner{
+			ServiceAccountName:             spec.Scanner.ServiceAccountName,
+			Image:                          v1alpha2.Image(spec.Scanner.Image),
+			Resources:                      spec.ScanAPI.Resources,
+			Replicas:                       spec.ScanAPI.Replicas,
+			PrivateRegistriesPullSecretRef: spec.Scanner.PrivateRegistriesPullSecretRef,
+			Env:                            spec.ScanAPI.Env,
+		},
+		KubernetesResources: v1alpha2.KubernetesResources{
+			Enable:                spec.KubernetesResources.Enable,
+			Schedule:              spec.KubernetesResources.Schedule,
+			ScheduleJitterMinutes: spec.KubernetesResources.ScheduleJitterMinutes,
+		},
+		Nodes: v1alpha2.Nodes{
+			Enable:                spec.Nodes.Enable,
+			Resources:             spec.Nodes.Resources,
+			Schedule:              spec.Nodes.Schedule,
+			ScheduleJitterMinutes: spec.Nodes.ScheduleJitterMinutes,
+			Style:                 v1alpha2.NodeScanStyle(spec.Nodes.Style),
+			IntervalTimer:         spec.Nodes.IntervalTimer,
+		},
+		Containers: v1alpha2.Containers(spec.Containers),
+		Admission: v1alpha2.Admission{
+			Enable:   spec.Admission.Enable,
+			Image:    v1alpha2.Image(spec.Admission.Image),
+			Mode:     v1alpha2.AdmissionMode(spec.Admission.Mode),
+			Replicas: spec.Admission.Replicas,
+			CertificateProvisioning: v1alpha2.CertificateProvisioning{
+				Mode: v1alpha2.CertificateProvisioningMode(spec.Admission.CertificateProvisioning.Mode),
+			},
+			ServiceAccountName: spec.Admission.ServiceAccountName,
+			ScoreThreshold:     spec.Admission.ScoreThreshold,
+			Exemptions:         v1alpha2.AdmissionExemptions(spec.Admission.Exemptions),
+			Resources:          convertAdmissionResources[v1alpha2.AdmissionResource](spec.Admission.Resources),
+			DecisionLog: v1alpha2.AdmissionDecisionLog{
+				Stdout: spec.Admission.DecisionLog.Stdout,
+				File:   v1alpha2.DecisionLogFile(spec.Admission.DecisionLog.File),
+				HTTP:   v1alpha2.DecisionLogHTTP(spec.Admission.DecisionLog.HTTP),
+			},
+			MaxInFlightScans: spec.Admission.MaxInFlightScans,
+			TimeoutSeconds:   spec.Admission.TimeoutSeconds,
+		},
+		ConsoleIntegration: v1alpha2.ConsoleIntegration(spec.ConsoleIntegration),
+		Filtering: v1alpha2.Filtering{
+			Namespaces:        v1alpha2.FilteringSpec(spec.Filtering.Namespaces),
+			NamespaceSelector: spec.Filtering.NamespaceSelector,
+		},
+	}
+
+	// The deprecated flag is only restored as long as container image scanning is still enabled. Otherwise
+	// it was disabled in v1beta1 and the flag would enable it again.
+	if data.ContainerImageScanning && spec.Containers.Enable {
+		dst.Spec.KubernetesResources.ContainerImageScanning = true
+		dst.Spec.Containers.Enable = data.ContainersEnable
+	}
+
+	for _, r := range spec.KubernetesResources.MonitoredResources {
+		dst.Spec.KubernetesResources.MonitoredResources = append(dst.Spec.KubernetesResources.MonitoredResources, v1alpha2.MonitoredResource(r))
+	}
+
+	status := src.Status.DeepCopy()
+	dst.Status = v1alpha2.MondooAuditConfigStatus{
+		Pods:                        status.Pods,
+		ReconciledByOperatorVersion: status.ReconciledByOperatorVersion,
+	}
+	if audit := status.AdmissionAudit; audit != nil {
+		dst.Status.AdmissionAudit = &v1alpha2.AdmissionAuditStatus{
+			WouldBeDenied:  audit.WouldBeDenied,
+			LastUpdateTime: audit.LastUpdateTime,
+		}
+		for _, n := range audit.Namespaces {
+			dst.Status.AdmissionAudit.Namespaces = append(dst.Status.AdmissionAudit.Namespaces, v1alpha2.AdmissionAuditNamespace(n))
+		}
+		for _, w := range audit.Workloads {
+			dst.Status.AdmissionAudit.Workloads = append(dst.Status.AdmissionAudit.Workloads, v1alpha2.AdmissionAuditWorkload(w))
+		}
+	}
+	for _, c := range status.Conditions {
+		dst.Status.Conditions = append(dst.Status.Conditions, v1alpha2.MondooAuditConfigCondition{
+			Type:               v1alpha2.MondooAuditConfigConditionType(c.Type),
+			Status:             corev1.ConditionStatus(c.Status),
+			ObservedGeneration: c.ObservedGeneration,
+			// The lastUpdateTime is lost in v1beta1, the lastTransitionTime is the closest replacement.
+			LastUpdateTime:     c.LastTransitionTime,
+			LastTransitionTime: c.LastTransitionTime,
+			Reason:             c.Reason,
+			Message:            c.Message,
+		})
+	}
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1alpha2) to this version.
+func (dst *MondooAuditConfig) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha2.MondooAuditConfig)
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	data := conversionData{}
+
+	spec := src.Spec.DeepCopy()
+	dst.Spec = MondooAuditConfigSpec{
+		MondooCredsSecretRef: spec.MondooCredsSecretRef,
+		MondooTokenSecretRef: spec.MondooTokenSecretRef,
+		Scanner: Scanner{
+			ServiceAccountName:             spec.Scanner.ServiceAccountName,
+			Image:                          Image(spec.Scanner.Image),
+			PrivateRegistriesPullSecretRef: spec.Scanner.PrivateRegistriesPullSecretRef,
+		},
+		ScanAPI: ScanAPI{
+			Resources: spec.Scanner.Resources,
+			Replicas:  spec.Scanner.Replicas,
+			Env:       spec.Scanner.Env,
+		},
+		KubernetesResources: KubernetesResources{
+			Enable:                spec.KubernetesResources.Enable,
+			Schedule:              spec.KubernetesResources.Schedule,
+			ScheduleJitterMinutes: spec.KubernetesResources.ScheduleJitterMinutes,
+		},
+		Nodes: Nodes{
+			Enable:                spec.Nodes.Enable,
+			Resources:             spec.Nodes.Resources,
+			Schedule:              spec.Nodes.Schedule,
+			ScheduleJitterMinutes: spec.Nodes.ScheduleJitterMinutes,
+			Style:                 NodeScanStyle(spec.Nodes.Style),
+			IntervalTimer:         spec.Nodes.IntervalTimer,
+		},
+		Containers: Containers(spec.Containers),
+		Admission: Admission{
+			Enable:   spec.Admission.Enable,
+			Image:    Image(spec.Admission.Image),
+			Mode:     AdmissionMode(spec.Admission.Mode),
+			Replicas: spec.Admission.Replicas,
+			CertificateProvisioning: CertificateProvisioning{
+				Mode: CertificateProvisioningMode(spec.Admission.CertificateProvisioning.Mode),
+			},
+			ServiceAccountName: spec.Admission.ServiceAccountName,
+			ScoreThreshold:     spec.Admission.ScoreThreshold,
+			Exemptions:         AdmissionExemptions(spec.Admission.Exemptions),
+			Resources:          convertAdmissionResources[AdmissionResource](spec.Admission.Resources),
+			DecisionLog: AdmissionDecisionLog{
+				Stdout: spec.Admission.DecisionLog.Stdout,
+				File:   DecisionLogFile(spec.Admission.DecisionLog.File),
+				HTTP:   DecisionLogHTTP(spec.Admission.DecisionLog.HTTP),
+			},
+			MaxInFlightScans: spec.Admission.MaxInFlightScans,
+			TimeoutSeconds:   spec.Admission.TimeoutSeconds,
+		},
+		ConsoleIntegration: ConsoleIntegration(spec.ConsoleIntegration),
+		Filtering: Filtering{
+			Namespaces:        FilteringSpec(spec.Filtering.Namespaces),
+			NamespaceSelector: spec.Filtering.NamespaceSelector,
+		},
+	}
+
+	if spec.KubernetesResources.ContainerImageScanning {
+		data.ContainerImageScanning = true
+		data.ContainersEnable = spec.Containers.Enable
+		dst.Spec.Containers.Enable = true
+	}
+
+	for _, r := range spec.KubernetesResources.MonitoredResources {
+		dst.Spec.KubernetesResources.MonitoredResources = append(dst.Spec.KubernetesResources.MonitoredResources, MonitoredResource(r))
+	}
+
+	status := src.Status.DeepCopy()
+	dst.Status = MondooAuditConfigStatus{
+		Pods:                        status.Pods,
+		ReconciledByOperatorVersion: status.ReconciledByOperatorVersion,
+	}
+	if audit := status.AdmissionAudit; audit != nil {
+		dst.Status.AdmissionAudit = &AdmissionAuditStatus{
+			WouldBeDenied:  audit.WouldBeDenied,
+			LastUpdateTime: audit.LastUpdateTime,
+		}
+		for _, n := range audit.Namespaces {
+			dst.Status.AdmissionAudit.Namespaces = append(dst.Status.AdmissionAudit.Namespaces, AdmissionAuditNamespace(n))
+		}
+		for _, w := range audit.Workloads {
+			dst.Status.AdmissionAudit.Workloads = append(dst.Status.AdmissionAudit.Workloads, AdmissionAuditWorkload(w))
+		}
+	}
+	for _, c := range status.Conditions {
+		dst.Status.Conditions = append(dst.Status.Conditions, metav1.Condition{
+			Type:               string(c.Type),
+			Status:             metav1.ConditionStatus(c.Status),
+			ObservedGeneration: c.ObservedGeneration,
+			LastTransitionTime: c.LastTransitionTime,
+			Reason:             c.Reason,
+			Message:            c.Message,
+		})
+	}
+
+	return pushConversionData(&dst.ObjectMeta, data)
+}
+
+// convertAdmissionResources converts the admission resources between the API versions.
+func convertAdmissionResources[D, S ~string](src []S) []D {
+	if src == nil {
+		return nil
+	}
+	dst := make([]D, 0, len(src))
+	for _, r := range src {
+		dst = append(dst, D(r))
+	}
+	return dst
+}
@@ -0,0 +1,420 @@
+/*
+Copyright 2022 Mondoo, Inc.
+
+This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at https://mozilla.org/MPL/2.0/.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MondooAuditConfigSpec defines the desired state of MondooAuditConfig
+type MondooAuditConfigSpec struct {
+	// MondooCredsSecretRef is the Secret holding the Mondoo service account credentials.
+	// +kubebuilder:validation:Required
+	// +required
+	MondooCredsSecretRef corev1.LocalObjectReference `json:"mondooCredsSecretRef"`
+
+	// MondooTokenSecretRef can optionally hold a time-limited token that the mondoo-operator will use
+	// to create a Mondoo service account saved to the Secret specified in .spec.mondooCredsSecretRef
+	// if that Secret does not exist.
+	MondooTokenSecretRef corev1.LocalObjectReference `json:"mondooTokenSecretRef,omitempty"`
+
+	// Scanner holds the settings which are shared by all components running the Mondoo scanner.
+	Scanner             Scanner             `json:"scanner,omitempty"`
+	ScanAPI             ScanAPI             `json:"scanApi,omitempty"`
+	KubernetesResources KubernetesResources `json:"kubernetesResources,omitempty"`
+	Nodes               Nodes               `json:"nodes,omitempty"`
+	Containers          Containers          `json:"containers,omitempty"`
+	Admission           Admission           `json:"admission,omitempty"`
+	ConsoleIntegration  ConsoleIntegration  `json:"consoleIntegration,omitempty"`
+	Filtering           Filtering           `json:"filtering,omitempty"`
+}
+
+type Filtering struct {
+	Namespaces FilteringSpec `json:"namespaces,omitempty"`
+
+	// NamespaceSelector limits watching/scanning to the namespaces whose labels match the selector. It is
+	// applied in addition to the Include and Exclude lists of Namespaces. Changes to the labels of a namespace
+	// are picked up automatically.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+}
+
+type FilteringSpec struct {
+	// Include is the list of resources to watch/scan. Setting Include overrides anything in the
+	// Exclude list as specifying an Include list is effectively excluding everything except for what
+	// is on the Include list.
+	Include []string `json:"include,omitempty"`
+
+	// Exclude is the list of resources to ignore for any watching/scanning actions. Use this if
+	// the goal is to watch/scan all resources except for this Exclude list.
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+type ConsoleIntegration struct {
+	Enable bool `json:"enable,omitempty"`
+}
+
+// CertificateProvisioning defines the certificate provisioning configuration within the cluster.
+type CertificateProvisioning struct {
+	// +kubebuilder:validation:Enum=cert-manager;openshift;manual
+	// +kubebuilder:default=manual
+	Mode CertificateProvisioningMode `json:"mode,omitempty"`
+}
+
+// Scanner defines the Mondoo scanner image and identity. They are used by the scan API, the Kubernetes
+// resources scanning, the container image scanning and the node scanning.
+type Scanner struct {
+	// +kubebuilder:default=mondoo-operator-k8s-resources-scanning
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	Image              Image  `json:"image,omitempty"`
+
+	// PrivateRegistriesPullSecretRef defines the name of a secret that contains the credentials for the private
+	// registries we have to pull images from.
+	PrivateRegistriesPullSecretRef corev1.LocalObjectReference `json:"privateRegistriesPullSecretRef,omitempty"`
+}
+
+// ScanAPI defines the settings for the scan API Deployment which serves the Kubernetes resources scanning
+// and the admission controller.
+type ScanAPI struct {
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Number of replicas for the scan API.
+	// For enforcing mode, the minimum should be two to prevent problems during Pod failures,
+	// e.g. node failure, node scaling, etc.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Env allows setting extra environment variables for the scan API. If the operator sets already an env
+	// variable with the same name, the value specified here will override it.
+	Env []corev1.EnvVar `json:"env,omitempty"`
+}
+
+type KubernetesResources struct {
+	Enable bool `json:"enable,omitempty"`
+
+	// Schedule is a cron expression (e.g. "0 * * * *" or "@hourly") which defines when the scan runs.
+	// If not set, the scan runs hourly, starting one minute after it was enabled.
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+
+	// ScheduleJitterMinutes shifts the minute of the schedule by a stable offset in the range
+	// [0, ScheduleJitterMinutes). Only used if Schedule is set.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=59
+	// +optional
+	ScheduleJitterMinutes int32 `json:"scheduleJitterMinutes,omitempty"`
+
+	// MonitoredResources are additional kinds, e.g. from CRDs, whose changes are scanned right away like the
+	// changes of the built-in workload kinds. Kinds whose CRD doesn't exist yet are watched once it is created.
+	// +optional
+	MonitoredResources []MonitoredResource `json:"monitoredResources,omitempty"`
+}
+
+// MonitoredResource is a kind of resources which is watched for changes.
+type MonitoredResource struct {
+	// Group is the API group of the kind. It is empty for the core API group.
+	// +optional
+	Group string `json:"group,omitempty"`
+	// +kubebuilder:validation:MinLength=1
+	Version string `json:"version"`
+	// +kubebuilder:validation:MinLength=1
+	Kind string `json:"kind"`
+}
+
+type Nodes struct {
+	Enable    bool                        `json:"enable,omitempty"`
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Schedule is a cron expression (e.g. "0 * * * *" or "@hourly") which defines when the scan runs.
+	// If not set, the scan runs hourly, starting one minute after it was enabled.
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+
+	// ScheduleJitterMinutes shifts the minute of the schedule by a stable offset in the range
+	// [0, ScheduleJitterMinutes). Only used if Schedule is set.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=59
+	// +optional
+	ScheduleJitterMinutes int32 `json:"scheduleJitterMinutes,omitempty"`
+
+	// Style selects how the nodes are scanned. The "cronjob" style creates a CronJob and an inventory ConfigMap
+	// for every node. The "daemonset" style runs a DaemonSet with a long-lived pod on every node, which scans the
+	// node every IntervalTimer minutes.
+	// +kubebuilder:validation:Enum=cronjob;daemonset
+	// +kubebuilder:default=cronjob
+	// +optional
+	Style NodeScanStyle `json:"style,omitempty"`
+
+	// IntervalTimer is the number of minutes between two scans of a node. Only used by the "daemonset" style.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=60
+	// +optional
+	IntervalTimer int32 `json:"intervalTimer,omitempty"`
+}
+
+type Containers struct {
+	Enable    bool                        `json:"enable,omitempty"`
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Schedule is a cron expression (e.g. "0 * * * *" or "@hourly") which defines when the scan runs.
+	// If not set, the scan runs daily, starting one minute after it was enabled.
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+
+	// ScheduleJitterMinutes shifts the minute of the schedule by a stable offset in the range
+	// [0, ScheduleJitterMinutes). Only used if Schedule is set.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=59
+	// +optional
+	ScheduleJitterMinutes int32 `json:"scheduleJitterMinutes,omitempty"`
+}
+
+type Admission struct {
+	Enable bool  `json:"enable,omitempty"`
+	Image  Image `json:"image,omitempty"`
+	// Mode represents whether the webhook will behave in a "permissive" mode (the default) which
+	// will only scan and report on k8s resources or "enforcing" mode where depending
+	// on the scan results may reject the k8s resource creation/modification.
+	// +kubebuilder:validation:Enum=permissive;enforcing;audit
+	// +kubebuilder:default=permissive
+	Mode AdmissionMode `json:"mode,omitempty"`
+	// Number of replicas for the admission webhook.
+	// For enforcing mode, the minimum should be two to prevent problems during Pod failures,
+	// e.g. node failure, node scaling, etc.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1
+	Replicas                *int32                  `json:"replicas,omitempty"`
+	CertificateProvisioning CertificateProvisioning `json:"certificateProvisioning,omitempty"`
+	// ServiceAccountName specifies the Kubernetes ServiceAccount the webhook should use
+	// during its operation.
+	// +kubebuilder:default=mondoo-operator-webhook
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// ScoreThreshold is the minimum score a resource needs to pass the admission check. In "enforcing"
+	// mode, resources with a lower score are rejected. The default of 100 only admits resources without
+	// any failed check.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +kubebuilder:default=100
+	// +optional
+	ScoreThreshold *int32 `json:"scoreThreshold,omitempty"`
+	// Exemptions restricts who may exempt resources from the admission checks.
+	// +optional
+	Exemptions AdmissionExemptions `json:"exemptions,omitempty"`
+	// Resources are the kinds of resources which are checked by the admission webhook. If not set, Pods,
+	// Deployments, DaemonSets, StatefulSets, Jobs and CronJobs are checked.
+	// +optional
+	Resources []AdmissionResource `json:"resources,omitempty"`
+	// DecisionLog configures where the admission decisions are recorded.
+	// +optional
+	DecisionLog AdmissionDecisionLog `json:"decisionLog,omitempty"`
+	// MaxInFlightScans is the number of concurrent scans per webhook replica. During rollouts, requests over the
+	// limit are answered right away with the last known verdict of the workload or the default response of the mode.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=20
+	// +optional
+	MaxInFlightScans *int32 `json:"maxInFlightScans,omitempty"`
+	// TimeoutSeconds is the time the API server waits for an admission decision of the webhook. The webhook
+	// answers before it passes, with the default response of the mode if the scan takes too long.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=30
+	// +kubebuilder:default=10
+	// +optional
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// AdmissionDecisionLog configures the sinks of the structured decision log. Every admission decision is
+// recorded as a JSON object with the identity of the resource, the requesting user, the mode, score,
+// decision, skip reason and latency.
+type AdmissionDecisionLog struct {
+	// Stdout writes the decisions as JSON lines to the log of the webhook.
+	// +optional
+	Stdout bool `json:"stdout,omitempty"`
+	// +optional
+	File DecisionLogFile `json:"file,omitempty"`
+	// +optional
+	HTTP DecisionLogHTTP `json:"http,omitempty"`
+}
+
+// DecisionLogFile writes the decisions as JSON lines to a rotating file in /var/log/mondoo.
+type DecisionLogFile struct {
+	Enable bool `json:"enable,omitempty"`
+	// VolumeClaimName is the name of a PersistentVolumeClaim which is mounted to store the files. If not set,
+	// the files are stored in an emptyDir volume and are lost when the webhook Pod is deleted.
+	// +optional
+	VolumeClaimName string `json:"volumeClaimName,omitempty"`
+	// MaxSizeMB is the size in megabytes after which the file is rotated.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=100
+	// +optional
+	MaxSizeMB int32 `json:"maxSizeMB,omitempty"`
+	// MaxBackups is the number of rotated files which are kept.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=5
+	// +optional
+	MaxBackups *int32 `json:"maxBackups,omitempty"`
+}
+
+// DecisionLogHTTP sends the decisions in batches as a JSON array to an HTTP endpoint. Failed requests are
+// retried with an exponential backoff.
+type DecisionLogHTTP struct {
+	// Endpoint is the URL the decisions are posted to. The HTTP sink is disabled if it is empty.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+	// BatchSize is the maximum number of decisions sent in one request.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=100
+	// +optional
+	BatchSize int32 `json:"batchSize,omitempty"`
+	// MaxRetries is the number of retries for requests which failed with a network error or a 429 or 5xx status.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=3
+	// +optional
+	MaxRetries *int32 `json:"maxRetries,omitempty"`
+}
+
+// AdmissionResource is a kind of resource in the lowercase plural form, as it is used in RBAC rules.
+// +kubebuilder:validation:Enum=pods;deployments;daemonsets;statefulsets;jobs;cronjobs;services;ingresses;networkpolicies;roles;clusterroles;rolebindings;clusterrolebindings;namespaces
+type AdmissionResource string
+
+// AdmissionExemptions lists the users and groups which may exempt a resource from the admission checks with the
+// "admission.k8s.mondoo.com/skip" annotation. Exempted resources are still scanned, but always admitted. If both
+// lists are empty, every user may exempt resources.
+type AdmissionExemptions struct {
+	// +optional
+	Users []string `json:"users,omitempty"`
+	// +optional
+	Groups []string `json:"groups,omitempty"`
+}
+
+type Image struct {
+	Name string `json:"name,omitempty"`
+	Tag  string `json:"tag,omitempty"`
+}
+
+// CertificateProvisioningMode is the specified method the cluster uses for provisioning TLS certificates
+type CertificateProvisioningMode string
+
+const (
+	CertManagerProvisioning CertificateProvisioningMode = "cert-manager"
+	OpenShiftProvisioning   CertificateProvisioningMode = "openshift"
+	ManualProvisioning      CertificateProvisioningMode = "manual"
+)
+
+// NodeScanStyle specifies how the nodes are scanned
+type NodeScanStyle string
+
+const (
+	NodeScanStyleCronJob   NodeScanStyle = "cronjob"
+	NodeScanStyleDaemonSet NodeScanStyle = "daemonset"
+)
+
+// AdmissionMode specifies the allowed modes of operation for the webhook admission controller
+type AdmissionMode string
+
+const (
+	Permissive AdmissionMode = "permissive"
+	Enforcing  AdmissionMode = "enforcing"
+	// Audit evaluates resources like Enforcing, but admits them with a warning and counts the would-be denials in
+	// the status of the MondooAuditConfig.
+	Audit AdmissionMode = "audit"
+)
+
+// MondooAuditConfigStatus defines the observed state of MondooAuditConfig
+type MondooAuditConfigStatus struct {
+	// Pods store the name of the pods which are running mondoo instances
+	Pods []string `json:"pods,omitempty"`
+
+	// Conditions includes detailed status for the MondooAuditConfig. Unlike the v1alpha2 conditions, they have no
+	// lastUpdateTime. The lastUpdateTime is not preserved by the conversion: it is set to the lastTransitionTime when
+	// the conditions are converted back to v1alpha2.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ReconciledByOperatorVersion contains the version of the operator which reconciled this MondooAuditConfig
+	ReconciledByOperatorVersion string `json:"reconciledByOperatorVersion,omitempty"`
+
+	// AdmissionAudit counts the resources which would have been denied by the admission webhook in "audit" mode.
+	// It is updated by the webhook.
+	// +optional
+	AdmissionAudit *AdmissionAuditStatus `json:"admissionAudit,omitempty"`
+}
+
+// AdmissionAuditStatus counts the resources which the admission webhook admitted in "audit" mode, but would have
+// denied in "enforcing" mode.
+type AdmissionAuditStatus struct {
+	// WouldBeDenied is the total number of would-be denials.
+	WouldBeDenied int64 `json:"wouldBeDenied,omitempty"`
+	// Namespaces counts the would-be denials per namespace. Cluster-scoped resources are counted without a namespace.
+	// +optional
+	Namespaces []AdmissionAuditNamespace `json:"namespaces,omitempty"`
+	// Workloads counts the would-be denials per workload. Only the workloads with the most would-be denials are kept.
+	// +optional
+	Workloads []AdmissionAuditWorkload `json:"workloads,omitempty"`
+	// LastUpdateTime is the last time the counts were updated.
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+type AdmissionAuditNamespace struct {
+	Namespace     string `json:"namespace,omitempty"`
+	WouldBeDenied int64  `json:"wouldBeDenied"`
+}
+
+type AdmissionAuditWorkload struct {
+	Kind          string `json:"kind"`
+	Namespace     string `json:"namespace,omitempty"`
+	Name          string `json:"name"`
+	WouldBeDenied int64  `json:"wouldBeDenied"`
+	// LastDenialTime is the last time the workload would have been denied.
+	LastDenialTime metav1.Time `json:"lastDenialTime,omitempty"`
+}
+
+const (
+	// Indicates weather NodeScanning is Degraded
+	NodeScanningDegraded = "NodeScanningDegraded"
+	// Indicates weather Kubernetes resources scanning is Degraded
+	K8sResourcesScanningDegraded = "K8sResourcesScanningDegraded"
+	// Indicates weather Kubernetes container image scanning is Degraded
+	K8sContainerImageScanningDegraded = "K8sContainerImageScanningDegraded"
+	// Indicates weather Admission controller is Degraded
+	AdmissionDegraded = "AdmissionDegraded"
+	// Indicates weather Admission controller is Degraded because of the ScanAPI
+	ScanAPIDegraded = "ScanAPIDegraded"
+	// MondooIntegrationDegraded will hold the status for any issues encountered while trying to CheckIn()
+	// on behalf of the Mondoo integration MRN
+	MondooIntegrationDegraded = "IntegrationDegraded"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// MondooAuditConfig is the Schema for the mondooauditconfigs API
+type MondooAuditConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MondooAuditConfigSpec   `json:"spec,omitempty"`
+	Status MondooAuditConfigStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// MondooAuditConfigList contains a list of MondooAuditConfig
+type MondooAuditConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MondooAuditConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MondooAuditConfig{}, &MondooAuditConfigList{})
+}
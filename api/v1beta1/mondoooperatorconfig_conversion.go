@@ -0,0 +1,76 @@
+/*
+Copyright 2022 Mondoo, Inc.
+
+This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at https://mozilla.org/MPL/2.0/.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"go.mondoo.com/mondoo-operator/api/v1alpha2"
+)
+
+// ConvertTo converts this MondooOperatorConfig to the Hub version (v1alpha2).
+func (src *MondooOperatorConfig) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha2.MondooOperatorConfig)
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	if _, err := popConversionData(&dst.ObjectMeta); err != nil {
+		return err
+	}
+
+	spec := src.Spec.DeepCopy()
+	dst.Spec = v1alpha2.MondooOperatorConfigSpec{
+		Metrics:                 v1alpha2.Metrics(spec.Metrics),
+		SkipContainerResolution: spec.SkipContainerResolution,
+	}
+
+	dst.Status = v1alpha2.MondooOperatorConfigStatus{}
+	for _, c := range src.Status.Conditions {
+		dst.Status.Conditions = append(dst.Status.Conditions, v1alpha2.MondooOperatorConfigCondition{
+			Type:               v1alpha2.MondooOperatorConfigConditionType(c.Type),
+			Status:             corev1.ConditionStatus(c.Status),
+			ObservedGeneration: c.ObservedGeneration,
+			// The lastUpdateTime is lost in v1beta1, the lastTransitionTime is the closest replacement.
+			LastUpdateTime:     c.LastTransitionTime,
+			LastTransitionTime: c.LastTransitionTime,
+			Reason:             c.Reason,
+			Message:            c.Message,
+		})
+	}
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1alpha2) to this version.
+func (dst *MondooOperatorConfig) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha2.MondooOperatorConfig)
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	spec := src.Spec.DeepCopy()
+	dst.Spec = MondooOperatorConfigSpec{
+		Metrics:                 Metrics(spec.Metrics),
+		SkipContainerResolution: spec.SkipContainerResolution,
+	}
+
+	dst.Status = MondooOperatorConfigStatus{}
+	for _, c := range src.Status.Conditions {
+		dst.Status.Conditions = append(dst.Status.Conditions, metav1.Condition{
+			Type:               string(c.Type),
+			Status:             metav1.ConditionStatus(c.Status),
+			ObservedGeneration: c.ObservedGeneration,
+			LastTransitionTime: c.LastTransitionTime,
+			Reason:             c.Reason,
+			Message:            c.Message,
+		})
+	}
+
+	// All fields of the MondooOperatorConfig have a counterpart in v1beta1, so this only removes a stale annotation.
+	return pushConversionData(&dst.ObjectMeta, conversionData{})
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2022 Mondoo, Inc.
+
+This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at https://mozilla.org/MPL/2.0/.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// MondooOperatorConfigName is the one allowed name for the
+	// cluster-scoped MondooOperatorConfig resource
+	MondooOperatorConfigName = "mondoo-operator-config"
+)
+
+// MondooOperatorConfigSpec defines the desired state of MondooOperatorConfig
+type MondooOperatorConfigSpec struct {
+	// Metrics controls the enabling/disabling of metrics report of mondoo-operator
+	Metrics Metrics `json:"metrics,omitempty"`
+	// Allows skipping Image resolution from upstream repository
+	SkipContainerResolution bool `json:"skipContainerResolution,omitempty"`
+}
+
+type Metrics struct {
+	Enable bool `json:"enable,omitempty"`
+	// ResourceLabels allows providing a list of extra labels to apply to the metrics-related
+	// resources (eg. ServiceMonitor)
+	ResourceLabels map[string]string `json:"resourceLabels,omitempty"`
+}
+
+// MondooOperatorConfigStatus defines the observed state of MondooOperatorConfig
+type MondooOperatorConfigStatus struct {
+	// Conditions includes more detailed status for the mondoo config. Unlike the v1alpha2 conditions, they have no
+	// lastUpdateTime. The lastUpdateTime is not preserved by the conversion: it is set to the lastTransitionTime when
+	// the conditions are converted back to v1alpha2.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+const (
+	// PrometheusMissingCondition is used to indicate whether Prometheus was found to be installed or not.
+	PrometheusMissingCondition = "PrometheusMissing"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+
+// MondooOperatorConfig is the Schema for the mondoooperatorconfigs API
+type MondooOperatorConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MondooOperatorConfigSpec   `json:"spec,omitempty"`
+	Status MondooOperatorConfigStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// MondooOperatorConfigList contains a list of MondooOperatorConfig
+type MondooOperatorConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MondooOperatorConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MondooOperatorConfig{}, &MondooOperatorConfigList{})
+}
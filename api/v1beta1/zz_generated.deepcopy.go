@@ -0,0 +1,643 @@
+//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+/*
+Copyright 2022 Mondoo, Inc.
+
+This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at https://mozilla.org/MPL/2.0/.
+*/
+
+// Code generated by controller-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Admission) DeepCopyInto(out *Admission) {
+	*out = *in
+	out.Image = in.Image
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	out.CertificateProvisioning = in.CertificateProvisioning
+	if in.ScoreThreshold != nil {
+		in, out := &in.ScoreThreshold, &out.ScoreThreshold
+		*out = new(int32)
+		**out = **in
+	}
+	in.Exemptions.DeepCopyInto(&out.Exemptions)
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]AdmissionResource, len(*in))
+		copy(*out, *in)
+	}
+	in.DecisionLog.DeepCopyInto(&out.DecisionLog)
+	if in.MaxInFlightScans != nil {
+		in, out := &in.MaxInFlightScans, &out.MaxInFlightScans
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Admission.
+func (in *Admission) DeepCopy() *Admission {
+	if in == nil {
+		return nil
+	}
+	out := new(Admission)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdmissionAuditNamespace) DeepCopyInto(out *AdmissionAuditNamespace) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdmissionAuditNamespace.
+func (in *AdmissionAuditNamespace) DeepCopy() *AdmissionAuditNamespace {
+	if in == nil {
+		return nil
+	}
+	out := new(AdmissionAuditNamespace)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdmissionAuditStatus) DeepCopyInto(out *AdmissionAuditStatus) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]AdmissionAuditNamespace, len(*in))
+		copy(*out, *in)
+	}
+	if in.Workloads != nil {
+		in, out := &in.Workloads, &out.Workloads
+		*out = make([]AdmissionAuditWorkload, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdmissionAuditStatus.
+func (in *AdmissionAuditStatus) DeepCopy() *AdmissionAuditStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AdmissionAuditStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdmissionAuditWorkload) DeepCopyInto(out *AdmissionAuditWorkload) {
+	*out = *in
+	in.LastDenialTime.DeepCopyInto(&out.LastDenialTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdmissionAuditWorkload.
+func (in *AdmissionAuditWorkload) DeepCopy() *AdmissionAuditWorkload {
+	if in == nil {
+		return nil
+	}
+	out := new(AdmissionAuditWorkload)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdmissionDecisionLog) DeepCopyInto(out *AdmissionDecisionLog) {
+	*out = *in
+	in.File.DeepCopyInto(&out.File)
+	in.HTTP.DeepCopyInto(&out.HTTP)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdmissionDecisionLog.
+func (in *AdmissionDecisionLog) DeepCopy() *AdmissionDecisionLog {
+	if in == nil {
+		return nil
+	}
+	out := new(AdmissionDecisionLog)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdmissionExemptions) DeepCopyInto(out *AdmissionExemptions) {
+	*out = *in
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdmissionExemptions.
+func (in *AdmissionExemptions) DeepCopy() *AdmissionExemptions {
+	if in == nil {
+		return nil
+	}
+	out := new(AdmissionExemptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateProvisioning) DeepCopyInto(out *CertificateProvisioning) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateProvisioning.
+func (in *CertificateProvisioning) DeepCopy() *CertificateProvisioning {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateProvisioning)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsoleIntegration) DeepCopyInto(out *ConsoleIntegration) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsoleIntegration.
+func (in *ConsoleIntegration) DeepCopy() *ConsoleIntegration {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsoleIntegration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Containers) DeepCopyInto(out *Containers) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Containers.
+func (in *Containers) DeepCopy() *Containers {
+	if in == nil {
+		return nil
+	}
+	out := new(Containers)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DecisionLogFile) DeepCopyInto(out *DecisionLogFile) {
+	*out = *in
+	if in.MaxBackups != nil {
+		in, out := &in.MaxBackups, &out.MaxBackups
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DecisionLogFile.
+func (in *DecisionLogFile) DeepCopy() *DecisionLogFile {
+	if in == nil {
+		return nil
+	}
+	out := new(DecisionLogFile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DecisionLogHTTP) DeepCopyInto(out *DecisionLogHTTP) {
+	*out = *in
+	if in.MaxRetries != nil {
+		in, out := &in.MaxRetries, &out.MaxRetries
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DecisionLogHTTP.
+func (in *DecisionLogHTTP) DeepCopy() *DecisionLogHTTP {
+	if in == nil {
+		return nil
+	}
+	out := new(DecisionLogHTTP)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Filtering) DeepCopyInto(out *Filtering) {
+	*out = *in
+	in.Namespaces.DeepCopyInto(&out.Namespaces)
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Filtering.
+func (in *Filtering) DeepCopy() *Filtering {
+	if in == nil {
+		return nil
+	}
+	out := new(Filtering)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FilteringSpec) DeepCopyInto(out *FilteringSpec) {
+	*out = *in
+	if in.Include != nil {
+		in, out := &in.Include, &out.Include
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FilteringSpec.
+func (in *FilteringSpec) DeepCopy() *FilteringSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FilteringSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Image) DeepCopyInto(out *Image) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Image.
+func (in *Image) DeepCopy() *Image {
+	if in == nil {
+		return nil
+	}
+	out := new(Image)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubernetesResources) DeepCopyInto(out *KubernetesResources) {
+	*out = *in
+	if in.MonitoredResources != nil {
+		in, out := &in.MonitoredResources, &out.MonitoredResources
+		*out = make([]MonitoredResource, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubernetesResources.
+func (in *KubernetesResources) DeepCopy() *KubernetesResources {
+	if in == nil {
+		return nil
+	}
+	out := new(KubernetesResources)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Metrics) DeepCopyInto(out *Metrics) {
+	*out = *in
+	if in.ResourceLabels != nil {
+		in, out := &in.ResourceLabels, &out.ResourceLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Metrics.
+func (in *Metrics) DeepCopy() *Metrics {
+	if in == nil {
+		return nil
+	}
+	out := new(Metrics)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MondooAuditConfig) DeepCopyInto(out *MondooAuditConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MondooAuditConfig.
+func (in *MondooAuditConfig) DeepCopy() *MondooAuditConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(MondooAuditConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MondooAuditConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MondooAuditConfigList) DeepCopyInto(out *MondooAuditConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MondooAuditConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MondooAuditConfigList.
+func (in *MondooAuditConfigList) DeepCopy() *MondooAuditConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(MondooAuditConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MondooAuditConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MondooAuditConfigSpec) DeepCopyInto(out *MondooAuditConfigSpec) {
+	*out = *in
+	out.MondooCredsSecretRef = in.MondooCredsSecretRef
+	out.MondooTokenSecretRef = in.MondooTokenSecretRef
+	out.Scanner = in.Scanner
+	in.ScanAPI.DeepCopyInto(&out.ScanAPI)
+	in.KubernetesResources.DeepCopyInto(&out.KubernetesResources)
+	in.Nodes.DeepCopyInto(&out.Nodes)
+	in.Containers.DeepCopyInto(&out.Containers)
+	in.Admission.DeepCopyInto(&out.Admission)
+	out.ConsoleIntegration = in.ConsoleIntegration
+	in.Filtering.DeepCopyInto(&out.Filtering)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MondooAuditConfigSpec.
+func (in *MondooAuditConfigSpec) DeepCopy() *MondooAuditConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MondooAuditConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MondooAuditConfigStatus) DeepCopyInto(out *MondooAuditConfigStatus) {
+	*out = *in
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AdmissionAudit != nil {
+		in, out := &in.AdmissionAudit, &out.AdmissionAudit
+		*out = new(AdmissionAuditStatus)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MondooAuditConfigStatus.
+func (in *MondooAuditConfigStatus) DeepCopy() *MondooAuditConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MondooAuditConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MondooOperatorConfig) DeepCopyInto(out *MondooOperatorConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MondooOperatorConfig.
+func (in *MondooOperatorConfig) DeepCopy() *MondooOperatorConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(MondooOperatorConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MondooOperatorConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MondooOperatorConfigList) DeepCopyInto(out *MondooOperatorConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MondooOperatorConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MondooOperatorConfigList.
+func (in *MondooOperatorConfigList) DeepCopy() *MondooOperatorConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(MondooOperatorConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MondooOperatorConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MondooOperatorConfigSpec) DeepCopyInto(out *MondooOperatorConfigSpec) {
+	*out = *in
+	in.Metrics.DeepCopyInto(&out.Metrics)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MondooOperatorConfigSpec.
+func (in *MondooOperatorConfigSpec) DeepCopy() *MondooOperatorConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MondooOperatorConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MondooOperatorConfigStatus) DeepCopyInto(out *MondooOperatorConfigStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MondooOperatorConfigStatus.
+func (in *MondooOperatorConfigStatus) DeepCopy() *MondooOperatorConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MondooOperatorConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MonitoredResource) DeepCopyInto(out *MonitoredResource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonitoredResource.
+func (in *MonitoredResource) DeepCopy() *MonitoredResource {
+	if in == nil {
+		return nil
+	}
+	out := new(MonitoredResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Nodes) DeepCopyInto(out *Nodes) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Nodes.
+func (in *Nodes) DeepCopy() *Nodes {
+	if in == nil {
+		return nil
+	}
+	out := new(Nodes)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScanAPI) DeepCopyInto(out *ScanAPI) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]corev1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScanAPI.
+func (in *ScanAPI) DeepCopy() *ScanAPI {
+	if in == nil {
+		return nil
+	}
+	out := new(ScanAPI)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Scanner) DeepCopyInto(out *Scanner) {
+	*out = *in
+	out.Image = in.Image
+	out.PrivateRegistriesPullSecretRef = in.PrivateRegistriesPullSecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Scanner.
+func (in *Scanner) DeepCopy() *Scanner {
+	if in == nil {
+		return nil
+	}
+	out := new(Scanner)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -24,10 +24,13 @@ import (
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 
 	k8sv1alpha2 "go.mondoo.com/mondoo-operator/api/v1alpha2"
+	k8sv1beta1 "go.mondoo.com/mondoo-operator/api/v1beta1"
 	"go.mondoo.com/mondoo-operator/controllers"
 	"go.mondoo.com/mondoo-operator/controllers/integration"
 	"go.mondoo.com/mondoo-operator/controllers/metrics"
+	"go.mondoo.com/mondoo-operator/controllers/operator_webhook"
 	"go.mondoo.com/mondoo-operator/controllers/resource_monitor"
+	"go.mondoo.com/mondoo-operator/controllers/resource_monitor/debouncer"
 	"go.mondoo.com/mondoo-operator/controllers/resource_monitor/scan_api_store"
 	"go.mondoo.com/mondoo-operator/controllers/status"
 	"go.mondoo.com/mondoo-operator/pkg/utils/k8s"
@@ -47,6 +50,14 @@ func init() {
 	probeAddr := Cmd.Flags().String("health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	enableLeaderElection := Cmd.Flags().Bool("leader-elect", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
+	resourceScanBatchSize := Cmd.Flags().Int("resource-scan-batch-size", debouncer.DefaultMaxBatchSize,
+		"The maximum number of changed resources scheduled for a scan with a single request to the scan API.")
+	resourceScanFlushInterval := Cmd.Flags().Duration("resource-scan-flush-interval", debouncer.DefaultFlushInterval,
+		"The time between two scan requests for the changed resources. The changes in between are collected into one request.")
+	resourceScanCheckpoints := Cmd.Flags().Bool("resource-scan-checkpoints", true,
+		"Store the resourceVersion up to which the changed resources were scanned, so only the resources which changed while "+
+			"the operator was down are scanned after a restart. Requires a Kubernetes API server which uses increasing etcd "+
+			"revisions as resourceVersions.")
 
 	Cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		// TODO: opts.BindFlags(flag.CommandLine) is not supported with cobra. If we want to support that we should manually
@@ -58,6 +69,7 @@ func init() {
 
 		utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 		utilruntime.Must(k8sv1alpha2.AddToScheme(scheme))
+		utilruntime.Must(k8sv1beta1.AddToScheme(scheme))
 		//+kubebuilder:scaffold:scheme
 		utilruntime.Must(certmanagerv1.AddToScheme(scheme))
 		utilruntime.Must(monitoringv1.AddToScheme(scheme))
@@ -107,6 +119,16 @@ func init() {
 
 		scanApiStore := scan_api_store.NewScanApiStore(ctx)
 		go scanApiStore.Start()
+
+		resourceMonitors, err := resource_monitor.RegisterResourceMonitors(mgr, scanApiStore, debouncer.Options{
+			FlushInterval: *resourceScanFlushInterval,
+			MaxBatchSize:  *resourceScanBatchSize,
+		}, *resourceScanCheckpoints)
+		if err != nil {
+			setupLog.Error(err, "unable to register resource monitors", "controller", "resource_monitor")
+			return err
+		}
+
 		if err = (&controllers.MondooAuditConfigReconciler{
 			Client:                 mgr.GetClient(),
 			MondooClientBuilder:    controllers.MondooClientBuilder,
@@ -114,6 +136,7 @@ func init() {
 			StatusReporter:         status.NewStatusReporter(mgr.GetClient(), controllers.MondooClientBuilder, v),
 			RunningOnOpenShift:     isOpenShift,
 			ScanApiStore:           scanApiStore,
+			ResourceMonitors:       resourceMonitors,
 		}).SetupWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "MondooAuditConfig")
 			return err
@@ -126,11 +149,6 @@ func init() {
 			return err
 		}
 
-		if err = resource_monitor.RegisterResourceMonitors(mgr, scanApiStore); err != nil {
-			setupLog.Error(err, "unable to register resource monitors", "controller", "resource_monitor")
-			return err
-		}
-
 		if err = integration.Add(mgr); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Integration")
 			return err
@@ -141,6 +159,11 @@ func init() {
 			return err
 		}
 
+		if err = operator_webhook.Add(ctx, mgr); err != nil {
+			setupLog.Error(err, "unable to set up webhooks", "webhook", "Conversion")
+			return err
+		}
+
 		if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 			setupLog.Error(err, "unable to set up health check")
 			return err
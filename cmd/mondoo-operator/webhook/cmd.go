@@ -17,7 +17,12 @@ import (
 	"go.mondoo.com/mondoo-operator/api/v1alpha2"
 	"go.mondoo.com/mondoo-operator/pkg/utils/logger"
 	"go.mondoo.com/mondoo-operator/pkg/version"
+	"go.mondoo.com/mondoo-operator/pkg/webhooks/decisionlog"
 	webhookhandler "go.mondoo.com/mondoo-operator/pkg/webhooks/handler"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -32,13 +37,33 @@ var Cmd = &cobra.Command{
 }
 
 func init() {
+	metricsAddr := Cmd.Flags().String("metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	scanApiUrl := Cmd.Flags().String("scan-api-url", "", "The URL of the service to send scan requests to.")
 	tokenFilePath := Cmd.Flags().String("token-file-path", "", "Path to a file containing token to use when making scan requests.")
-	webhookMode := Cmd.Flags().String("enforcement-mode", string(v1alpha2.Permissive), "Mode 'permissive' allows resources that had a failing scan result pass, and mode 'enforcing' will deny resources with failed scanning result.")
+	webhookMode := Cmd.Flags().String("enforcement-mode", string(v1alpha2.Permissive), "Mode 'permissive' allows resources that had a failing scan result pass, mode 'enforcing' will deny resources with failed scanning result, and mode 'audit' allows them with a warning and counts them in the status of the MondooAuditConfig.")
 	integrationMRN := Cmd.Flags().String("integration-mrn", "", "The Mondoo integration MRN to label scanned items with if the MondooAuditConfig is configured with Mondoo integration.")
 	clusterID := Cmd.Flags().String("cluster-id", "", "A cluster-unique ID for associating the webhook payloads with the underlying cluster.")
 	includeNamespaces := Cmd.Flags().StringSlice("namespaces", nil, "Only process k8s resources matching the provided list of Namespaces.")
 	excludeNamespaces := Cmd.Flags().StringSlice("namespaces-exclude", nil, "Ignore k8s resources matching the provided list of Namespaces.")
+	namespaceSelector := Cmd.Flags().String("namespace-selector", "", "Only process k8s resources in Namespaces with labels matching the provided label selector.")
+	exemptionUsers := Cmd.Flags().StringSlice("exemption-users", nil, "Only allow the provided list of users to exempt k8s resources from the admission checks.")
+	exemptionGroups := Cmd.Flags().StringSlice("exemption-groups", nil, "Only allow members of the provided list of groups to exempt k8s resources from the admission checks.")
+	verdictCacheSize := Cmd.Flags().Int("verdict-cache-size", webhookhandler.DefaultVerdictCacheSize, "The number of scan results to cache. Set to 0 to disable the cache.")
+	verdictCacheTTL := Cmd.Flags().Duration("verdict-cache-ttl", webhookhandler.DefaultVerdictCacheTTL, "How long scan results are cached.")
+	lastVerdictWindow := Cmd.Flags().Duration("last-verdict-window", webhookhandler.DefaultLastVerdictWindow, "How long the last known scan result of a workload is used while the scan API is unavailable. Set to 0 to always apply the default response of the enforcement mode.")
+	resources := Cmd.Flags().StringSlice("resources", nil, "The resources the webhook is registered for. Defaults to pods, deployments, daemonsets, statefulsets, jobs and cronjobs.")
+	decisionLogStdout := Cmd.Flags().Bool("decision-log-stdout", false, "Write every admission decision as a JSON line to stdout.")
+	decisionLogFile := Cmd.Flags().String("decision-log-file", "", "Write every admission decision as a JSON line to the provided file.")
+	decisionLogFileMaxSize := Cmd.Flags().Int64("decision-log-file-max-size-mb", decisionlog.DefaultFileMaxSizeMB, "The size in megabytes after which the decision log file is rotated.")
+	decisionLogFileMaxBackups := Cmd.Flags().Int("decision-log-file-max-backups", decisionlog.DefaultFileMaxBackups, "The number of rotated decision log files to keep.")
+	decisionLogEndpoint := Cmd.Flags().String("decision-log-http-endpoint", "", "Send the admission decisions in batches to the provided URL.")
+	decisionLogBatchSize := Cmd.Flags().Int("decision-log-http-batch-size", decisionlog.DefaultHTTPBatchSize, "The maximum number of admission decisions sent in one request.")
+	decisionLogMaxRetries := Cmd.Flags().Int("decision-log-http-max-retries", decisionlog.DefaultHTTPMaxRetries, "The number of retries for a failed request to the decision log endpoint.")
+	maxInFlightScans := Cmd.Flags().Int("max-in-flight-scans", webhookhandler.DefaultMaxInFlightScans, "The number of concurrent requests to the scan API. Requests over the limit get the last known verdict or the default response of the enforcement mode. Set to 0 to disable the limit.")
+	webhookTimeout := Cmd.Flags().Duration("webhook-timeout", webhookhandler.DefaultWebhookTimeout, "The timeoutSeconds of the ValidatingWebhookConfiguration. Requests are answered before it passes.")
+	auditConfigName := Cmd.Flags().String("mondoo-audit-config-name", "", "The name of the MondooAuditConfig whose status receives the would-be denials of the audit mode.")
+	auditConfigNamespace := Cmd.Flags().String("mondoo-audit-config-namespace", "", "The namespace of the MondooAuditConfig whose status receives the would-be denials of the audit mode.")
+	scoreThreshold := Cmd.Flags().Uint32("score-threshold", webhookhandler.DefaultScoreThreshold, "The minimum score (0-100) a k8s resource needs to pass the scan.")
 
 	Cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		log.SetLogger(logger.NewLogger())
@@ -61,10 +86,46 @@ func init() {
 		}
 		token := strings.TrimSuffix(string(tokenBytes), "\n")
 
+		var sinks []decisionlog.Sink
+		if *decisionLogStdout {
+			sinks = append(sinks, decisionlog.NewWriterSink(os.Stdout))
+		}
+		if *decisionLogFile != "" {
+			sink, err := decisionlog.NewFileSink(*decisionLogFile, *decisionLogFileMaxSize*1024*1024, *decisionLogFileMaxBackups)
+			if err != nil {
+				webhookLog.Error(err, "failed to open the decision log file")
+				return err
+			}
+			sinks = append(sinks, sink)
+		}
+		if *decisionLogEndpoint != "" {
+			sink, err := decisionlog.NewHTTPSink(decisionlog.HTTPSinkOptions{
+				Endpoint:   *decisionLogEndpoint,
+				BatchSize:  *decisionLogBatchSize,
+				MaxRetries: *decisionLogMaxRetries,
+			})
+			if err != nil {
+				webhookLog.Error(err, "failed to set up the decision log endpoint")
+				return err
+			}
+			sinks = append(sinks, sink)
+		}
+		decisionLog := decisionlog.NewLogger(sinks...)
+		defer func() {
+			if err := decisionLog.Close(); err != nil {
+				webhookLog.Error(err, "failed to close the decision log")
+			}
+		}()
+
 		// Setup a Manager
 		webhookLog.Info("setting up manager")
+		scheme := runtime.NewScheme()
+		utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+		utilruntime.Must(v1alpha2.AddToScheme(scheme))
 		mgr, err := manager.New(config.GetConfigOrDie(), manager.Options{
+			Scheme:                 scheme,
 			HealthProbeBindAddress: ":8081",
+			MetricsBindAddress:     *metricsAddr,
 		})
 		if err != nil {
 			webhookLog.Error(err, "unable to set up overall controller manager")
@@ -77,6 +138,17 @@ func init() {
 
 		webhookLog.Info("registering webhooks to the webhook server")
 
+		auditStatus := webhookhandler.NewAuditStatusRecorder(mgr.GetClient(), mgr.GetAPIReader(), types.NamespacedName{
+			Name:      *auditConfigName,
+			Namespace: *auditConfigNamespace,
+		}, webhookhandler.DefaultAuditStatusInterval)
+		if auditStatus != nil {
+			if err := mgr.Add(auditStatus); err != nil {
+				webhookLog.Error(err, "unable to set up the audit status recorder")
+				return err
+			}
+		}
+
 		webhookOpts := &webhookhandler.NewWebhookValidatorOpts{
 			Client:            mgr.GetClient(),
 			Mode:              *webhookMode,
@@ -86,6 +158,19 @@ func init() {
 			ClusterId:         *clusterID,
 			IncludeNamespaces: *includeNamespaces,
 			ExcludeNamespaces: *excludeNamespaces,
+			NamespaceSelector: *namespaceSelector,
+			ScoreThreshold:    *scoreThreshold,
+			ExemptionUsers:    *exemptionUsers,
+			ExemptionGroups:   *exemptionGroups,
+			Recorder:          mgr.GetEventRecorderFor("mondoo-webhook"),
+			VerdictCacheSize:  *verdictCacheSize,
+			VerdictCacheTTL:   *verdictCacheTTL,
+			LastVerdictWindow: *lastVerdictWindow,
+			Resources:         *resources,
+			DecisionLog:       decisionLog,
+			MaxInFlightScans:  *maxInFlightScans,
+			WebhookTimeout:    *webhookTimeout,
+			AuditStatus:       auditStatus,
 		}
 		webhookValidator, err := webhookhandler.NewWebhookValidator(webhookOpts)
 		if err != nil {
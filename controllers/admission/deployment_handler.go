@@ -19,6 +19,7 @@ import (
 	webhooksv1 "k8s.io/api/admissionregistration/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
@@ -30,6 +31,7 @@ import (
 	mondoov1alpha2 "go.mondoo.com/mondoo-operator/api/v1alpha2"
 	"go.mondoo.com/mondoo-operator/pkg/utils/k8s"
 	"go.mondoo.com/mondoo-operator/pkg/utils/mondoo"
+	wutils "go.mondoo.com/mondoo-operator/pkg/webhooks/utils"
 )
 
 var (
@@ -139,6 +141,16 @@ func deepEqualsValidatingWebhookConfiguration(existing, desired *webhooksv1.Vali
 			return false
 		}
 
+		// The API server defaults the timeout to 10 seconds.
+		if pointer.Int32Deref(existing.Webhooks[i].TimeoutSeconds, 10) != pointer.Int32Deref(desired.Webhooks[i].TimeoutSeconds, 10) {
+			return false
+		}
+
+		if !equalLabelSelectors(existing.Webhooks[i].NamespaceSelector, desired.Webhooks[i].NamespaceSelector) ||
+			!equalLabelSelectors(existing.Webhooks[i].ObjectSelector, desired.Webhooks[i].ObjectSelector) {
+			return false
+		}
+
 		if len(existing.Webhooks[i].Rules) != len(desired.Webhooks[i].Rules) {
 			return false
 		}
@@ -158,6 +170,18 @@ func deepEqualsValidatingWebhookConfiguration(existing, desired *webhooksv1.Vali
 	return true
 }
 
+// equalLabelSelectors treats a nil selector as equal to an empty one, because the API server defaults the selectors
+// of a webhook to an empty selector.
+func equalLabelSelectors(existing, desired *metav1.LabelSelector) bool {
+	if existing == nil {
+		existing = &metav1.LabelSelector{}
+	}
+	if desired == nil {
+		desired = &metav1.LabelSelector{}
+	}
+	return equality.Semantic.DeepEqual(existing, desired)
+}
+
 func (n *DeploymentHandler) syncWebhookService(ctx context.Context) error {
 	desiredService := WebhookService(n.TargetNamespace, *n.Mondoo)
 
@@ -201,6 +225,46 @@ func (n *DeploymentHandler) syncWebhookService(ctx context.Context) error {
 	return nil
 }
 
+// syncWebhookMetricsService creates or updates the Service for the webhook metrics if metrics are enabled in the
+// MondooOperatorConfig. Otherwise the Service is deleted.
+func (n *DeploymentHandler) syncWebhookMetricsService(ctx context.Context) error {
+	desiredService := WebhookMetricsService(n.TargetNamespace, *n.Mondoo)
+	if !n.MondooOperatorConfig.Spec.Metrics.Enable {
+		if err := k8s.DeleteIfExists(ctx, n.KubeClient, desiredService); err != nil {
+			webhookLog.Error(err, "failed to clean up webhook metrics Service")
+			return err
+		}
+		return nil
+	}
+
+	if err := n.setControllerRef(desiredService); err != nil {
+		return err
+	}
+
+	service := &corev1.Service{}
+	created, err := k8s.CreateIfNotExist(ctx, n.KubeClient, service, desiredService)
+	if err != nil {
+		webhookLog.Error(err, "failed to create metrics Service for webhook")
+		return err
+	}
+
+	if created {
+		webhookLog.Info("Created webhook metrics service")
+		return nil
+	}
+
+	if !k8s.AreServicesEqual(*desiredService, *service) || !reflect.DeepEqual(desiredService.Labels, service.Labels) {
+		k8s.UpdateService(service, *desiredService)
+		service.Labels = desiredService.Labels
+		if err := n.KubeClient.Update(ctx, service); err != nil {
+			webhookLog.Error(err, "failed to update existing webhook metrics Service")
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (n *DeploymentHandler) syncWebhookDeployment(ctx context.Context) error {
 	if n.Mondoo.Spec.Admission.CertificateProvisioning.Mode == mondoov1alpha2.CertManagerProvisioning {
 		cm := &CertManagerHandler{
@@ -221,6 +285,11 @@ func (n *DeploymentHandler) syncWebhookDeployment(ctx context.Context) error {
 		return err
 	}
 
+	if _, err := k8s.NamespaceSelector(n.Mondoo.Spec.Filtering); err != nil {
+		webhookLog.Error(err, "invalid namespace selector", "namespace", n.Mondoo.Namespace, "name", n.Mondoo.Name)
+		return err
+	}
+
 	integrationMRN, err := k8s.TryGetIntegrationMrnForAuditConfig(ctx, n.KubeClient, *n.Mondoo)
 	if err != nil {
 		webhookLog.Error(err,
@@ -322,13 +391,18 @@ func (n *DeploymentHandler) prepareValidatingWebhook(ctx context.Context, vwc *w
 		annotationValue = "manual"
 	}
 
-	for i := range vwc.Webhooks {
-		if n.Mondoo.Spec.Admission.Mode == mondoov1alpha2.Enforcing {
-			*vwc.Webhooks[i].FailurePolicy = webhooksv1.Fail
-		} else {
-			*vwc.Webhooks[i].FailurePolicy = webhooksv1.Ignore
-		}
+	// Keep requests for filtered namespaces in the API server. The webhook still checks the filtering for
+	// anything which cannot be expressed as a selector.
+	namespaceSelector := webhookNamespaceSelector(*n.Mondoo)
+	resources, err := wutils.AdmissionResources(admissionResourceNames(*n.Mondoo))
+	if err != nil {
+		return err
+	}
+	var webhooks []webhooksv1.ValidatingWebhook
+	for _, w := range vwc.Webhooks {
+		webhooks = append(webhooks, namespaceModeWebhooks(w, n.Mondoo.Spec.Admission.Mode, namespaceSelector, resources, webhookTimeoutSeconds(*n.Mondoo))...)
 	}
+	vwc.Webhooks = webhooks
 
 	// For AKS the ValidatingWebhookConfiguration would normally not be notified about resources
 	// in Namespaces with the label key 'control-plane'. Adding the label "admissions.enforcer/disabled": "true"
@@ -346,6 +420,10 @@ func (n *DeploymentHandler) applyWebhooks(ctx context.Context) (ctrl.Result, err
 		return ctrl.Result{}, err
 	}
 
+	if err := n.syncWebhookMetricsService(ctx); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	if err := n.syncWebhookDeployment(ctx); err != nil {
 		return ctrl.Result{}, err
 	}
@@ -460,6 +538,17 @@ func (n *DeploymentHandler) down(ctx context.Context) (ctrl.Result, error) {
 		return ctrl.Result{}, err
 	}
 
+	metricsService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      webhookMetricsServiceName(n.Mondoo.Name),
+			Namespace: n.TargetNamespace,
+		},
+	}
+	if err := k8s.DeleteIfExists(ctx, n.KubeClient, metricsService); err != nil {
+		webhookLog.Error(err, "failed to clean up webhook metrics Service resource")
+		return ctrl.Result{}, err
+	}
+
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      webhookDeploymentName(n.Mondoo.Name),
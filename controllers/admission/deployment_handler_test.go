@@ -290,6 +290,117 @@ func TestReconcile(t *testing.T) {
 				assert.NoError(t, err, "expected cert-manager-generated Secret to still exist")
 			},
 		},
+		{
+			name: "namespace filtering translated into webhook namespace selector",
+			mondooAuditConfigSpec: func() mondoov1alpha2.MondooAuditConfigSpec {
+				mac := testMondooAuditConfigSpec(true, false)
+				mac.Filtering.Namespaces.Exclude = []string{"kube-system", "openshift-*"}
+				mac.Filtering.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}}
+				return mac
+			}(),
+			validate: func(t *testing.T, kubeClient client.Client) {
+				vwc := &webhooksv1.ValidatingWebhookConfiguration{}
+				vwc.Name = fmt.Sprintf("%s-%s-mondoo", testNamespace, testMondooAuditConfigName)
+				require.NoError(t, kubeClient.Get(context.TODO(), client.ObjectKeyFromObject(vwc), vwc))
+
+				for _, webhook := range vwc.Webhooks {
+					assert.Equal(t, map[string]string{"team": "payments"}, webhook.NamespaceSelector.MatchLabels)
+					assert.Contains(t, webhook.NamespaceSelector.MatchExpressions, metav1.LabelSelectorRequirement{
+						Key:      corev1.LabelMetadataName,
+						Operator: metav1.LabelSelectorOpNotIn,
+						Values:   []string{"kube-system"},
+					})
+				}
+			},
+		},
+		{
+			name:                  "one webhook per namespace admission mode",
+			mondooAuditConfigSpec: testMondooAuditConfigSpec(true, false),
+			validate: func(t *testing.T, kubeClient client.Client) {
+				vwc := &webhooksv1.ValidatingWebhookConfiguration{}
+				vwc.Name = fmt.Sprintf("%s-%s-mondoo", testNamespace, testMondooAuditConfigName)
+				require.NoError(t, kubeClient.Get(context.TODO(), client.ObjectKeyFromObject(vwc), vwc))
+
+				require.Len(t, vwc.Webhooks, 4)
+				expected := map[string]webhooksv1.FailurePolicyType{
+					"policy.k8s.mondoo.com":            webhooksv1.Ignore,
+					"enforcing.policy.k8s.mondoo.com":  webhooksv1.Fail,
+					"permissive.policy.k8s.mondoo.com": webhooksv1.Ignore,
+					"audit.policy.k8s.mondoo.com":      webhooksv1.Ignore,
+				}
+				for _, webhook := range vwc.Webhooks {
+					assert.Equal(t, expected[webhook.Name], *webhook.FailurePolicy, webhook.Name)
+				}
+			},
+		},
+		{
+			name: "pass score threshold down to Deployment",
+			mondooAuditConfigSpec: func() mondoov1alpha2.MondooAuditConfigSpec {
+				mac := testMondooAuditConfigSpec(true, false)
+				mac.Admission.ScoreThreshold = pointer.Int32(80)
+				return mac
+			}(),
+			validate: func(t *testing.T, kubeClient client.Client) {
+				deployment := &appsv1.Deployment{}
+				deploymentKey := types.NamespacedName{Name: webhookDeploymentName(testMondooAuditConfigName), Namespace: testNamespace}
+				err := kubeClient.Get(context.TODO(), deploymentKey, deployment)
+				require.NoError(t, err, "expected Admission Deployment to exist")
+
+				args := deployment.Spec.Template.Spec.Containers[0].Args
+				assert.Contains(t, args, "--score-threshold")
+				assert.Contains(t, args, "80")
+			},
+		},
+		{
+			name: "pass exemptions down to Deployment",
+			mondooAuditConfigSpec: func() mondoov1alpha2.MondooAuditConfigSpec {
+				mac := testMondooAuditConfigSpec(true, false)
+				mac.Admission.Exemptions = mondoov1alpha2.AdmissionExemptions{Groups: []string{"sre", "platform"}}
+				return mac
+			}(),
+			validate: func(t *testing.T, kubeClient client.Client) {
+				deployment := &appsv1.Deployment{}
+				deploymentKey := types.NamespacedName{Name: webhookDeploymentName(testMondooAuditConfigName), Namespace: testNamespace}
+				err := kubeClient.Get(context.TODO(), deploymentKey, deployment)
+				require.NoError(t, err, "expected Admission Deployment to exist")
+
+				args := deployment.Spec.Template.Spec.Containers[0].Args
+				assert.Contains(t, args, "--exemption-groups")
+				assert.Contains(t, args, "sre,platform")
+			},
+		},
+		{
+			name: "pass resources down to Deployment and webhook rules",
+			mondooAuditConfigSpec: func() mondoov1alpha2.MondooAuditConfigSpec {
+				mac := testMondooAuditConfigSpec(true, false)
+				mac.Admission.Resources = []mondoov1alpha2.AdmissionResource{"deployments", "services", "clusterrolebindings"}
+				return mac
+			}(),
+			validate: func(t *testing.T, kubeClient client.Client) {
+				deployment := &appsv1.Deployment{}
+				deploymentKey := types.NamespacedName{Name: webhookDeploymentName(testMondooAuditConfigName), Namespace: testNamespace}
+				err := kubeClient.Get(context.TODO(), deploymentKey, deployment)
+				require.NoError(t, err, "expected Admission Deployment to exist")
+
+				args := deployment.Spec.Template.Spec.Containers[0].Args
+				assert.Contains(t, args, "--resources")
+				assert.Contains(t, args, "deployments,services,clusterrolebindings")
+
+				vwcName, err := validatingWebhookName(&mondoov1alpha2.MondooAuditConfig{
+					ObjectMeta: metav1.ObjectMeta{Name: testMondooAuditConfigName, Namespace: testNamespace},
+				})
+				require.NoError(t, err)
+				vwc := &webhooksv1.ValidatingWebhookConfiguration{}
+				require.NoError(t, kubeClient.Get(context.TODO(), types.NamespacedName{Name: vwcName}, vwc))
+				require.Len(t, vwc.Webhooks, 4)
+
+				var resources []string
+				for _, rule := range vwc.Webhooks[0].Rules {
+					resources = append(resources, rule.Resources...)
+				}
+				assert.ElementsMatch(t, []string{"deployments", "services", "clusterrolebindings"}, resources)
+			},
+		},
 		{
 			name: "pass admission mode down to Deployment",
 			mondooAuditConfigSpec: func() mondoov1alpha2.MondooAuditConfigSpec {
@@ -505,6 +616,54 @@ func TestReconcile(t *testing.T) {
 	}
 }
 
+func TestReconcile_WebhookMetricsService(t *testing.T) {
+	auditConfig := &mondoov1alpha2.MondooAuditConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testMondooAuditConfigName,
+			Namespace: testNamespace,
+		},
+		Spec: testMondooAuditConfigSpec(true, false),
+	}
+	kubeSystemNamespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "kube-system",
+			UID:  types.UID(testClusterID),
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithObjects(auditConfig, kubeSystemNamespace).Build()
+	operatorConfig := &mondoov1alpha2.MondooOperatorConfig{
+		Spec: mondoov1alpha2.MondooOperatorConfigSpec{Metrics: mondoov1alpha2.Metrics{Enable: true}},
+	}
+	webhooks := &DeploymentHandler{
+		Mondoo:                 auditConfig,
+		KubeClient:             fakeClient,
+		TargetNamespace:        testNamespace,
+		MondooOperatorConfig:   operatorConfig,
+		ContainerImageResolver: fakeMondoo.NewNoOpContainerImageResolver(),
+	}
+
+	_, err := webhooks.Reconcile(context.TODO())
+	require.NoError(t, err)
+
+	service := &corev1.Service{}
+	serviceKey := types.NamespacedName{Name: webhookMetricsServiceName(testMondooAuditConfigName), Namespace: testNamespace}
+	require.NoError(t, fakeClient.Get(context.TODO(), serviceKey, service), "expected webhook metrics Service to exist")
+	assert.Equal(t, WebhookDeploymentLabels(), service.Labels)
+	assert.Equal(t, WebhookDeploymentLabels(), service.Spec.Selector)
+
+	deployment := &appsv1.Deployment{}
+	deploymentKey := types.NamespacedName{Name: webhookDeploymentName(testMondooAuditConfigName), Namespace: testNamespace}
+	require.NoError(t, fakeClient.Get(context.TODO(), deploymentKey, deployment))
+	assert.Contains(t, deployment.Spec.Template.Spec.Containers[0].Ports,
+		corev1.ContainerPort{Name: "metrics", ContainerPort: webhookMetricsPort, Protocol: corev1.ProtocolTCP})
+
+	// Disabling metrics removes the Service again.
+	operatorConfig.Spec.Metrics.Enable = false
+	_, err = webhooks.Reconcile(context.TODO())
+	require.NoError(t, err)
+	assert.True(t, errors.IsNotFound(fakeClient.Get(context.TODO(), serviceKey, &corev1.Service{})))
+}
+
 func defaultResourcesWhenEnabled() []client.Object {
 	objects := []client.Object{}
 
@@ -10,25 +10,41 @@ package admission
 
 import (
 	"fmt"
+	"path"
 	"strings"
 
+	webhooksv1 "k8s.io/api/admissionregistration/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	mondoov1alpha2 "go.mondoo.com/mondoo-operator/api/v1alpha2"
 	"go.mondoo.com/mondoo-operator/controllers/scanapi"
+	"go.mondoo.com/mondoo-operator/pkg/constants"
 	"go.mondoo.com/mondoo-operator/pkg/feature_flags"
+	"go.mondoo.com/mondoo-operator/pkg/utils/k8s"
+	wutils "go.mondoo.com/mondoo-operator/pkg/webhooks/utils"
 )
 
 const (
 	webhookDeploymentLabelKey   = "app.kubernetes.io/name"
 	webhookDeploymentLabelValue = "mondoo-operator-webhook"
 
+	// webhookMetricsPort is the port the webhook serves its Prometheus metrics on.
+	webhookMetricsPort = 8080
+
+	// defaultWebhookTimeoutSeconds is the timeout of the API server for the webhook calls if the MondooAuditConfig
+	// doesn't configure one. The webhook derives the deadline for its scans from it.
+	defaultWebhookTimeoutSeconds = 10
+
+	// decisionLogDir is where the volume for the decision log file is mounted.
+	decisionLogDir = "/var/log/mondoo"
+
 	// openShiftServiceAnnotationKey is how we annotate a Service so that OpenShift
 	// will create TLS certificates for the webhook Service.
 	openShiftServiceAnnotationKey = "service.beta.openshift.io/serving-cert-secret-name"
@@ -74,6 +90,39 @@ func WebhookDeployment(ns, image string, m mondoov1alpha2.MondooAuditConfig, int
 		strings.Join(m.Spec.Filtering.Namespaces.Include, ","),
 		"--namespaces-exclude",
 		strings.Join(m.Spec.Filtering.Namespaces.Exclude, ","),
+		"--metrics-bind-address",
+		fmt.Sprintf(":%d", webhookMetricsPort),
+		"--mondoo-audit-config-name",
+		m.Name,
+		"--mondoo-audit-config-namespace",
+		m.Namespace,
+	}
+
+	// The selector is validated by the DeploymentHandler before the Deployment is created.
+	if selector, err := k8s.NamespaceSelector(m.Spec.Filtering); err == nil && selector != nil {
+		containerArgs = append(containerArgs, []string{"--namespace-selector", selector.String()}...)
+	}
+
+	if m.Spec.Admission.ScoreThreshold != nil {
+		containerArgs = append(containerArgs, []string{"--score-threshold", fmt.Sprint(*m.Spec.Admission.ScoreThreshold)}...)
+	}
+
+	if exemptions := m.Spec.Admission.Exemptions; len(exemptions.Users) > 0 || len(exemptions.Groups) > 0 {
+		containerArgs = append(containerArgs, []string{
+			"--exemption-users", strings.Join(exemptions.Users, ","),
+			"--exemption-groups", strings.Join(exemptions.Groups, ","),
+		}...)
+	}
+
+	if resources := admissionResourceNames(m); len(resources) > 0 {
+		containerArgs = append(containerArgs, []string{"--resources", strings.Join(resources, ",")}...)
+	}
+
+	containerArgs = append(containerArgs, decisionLogArgs(m.Spec.Admission.DecisionLog)...)
+
+	containerArgs = append(containerArgs, []string{"--webhook-timeout", fmt.Sprintf("%ds", webhookTimeoutSeconds(m))}...)
+	if m.Spec.Admission.MaxInFlightScans != nil {
+		containerArgs = append(containerArgs, []string{"--max-in-flight-scans", fmt.Sprint(*m.Spec.Admission.MaxInFlightScans)}...)
 	}
 
 	if integrationMRN != "" {
@@ -116,6 +165,13 @@ func WebhookDeployment(ns, image string, m mondoov1alpha2.MondooAuditConfig, int
 							},
 							Env:  feature_flags.AllFeatureFlagsAsEnv(),
 							Name: "webhook",
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "metrics",
+									ContainerPort: webhookMetricsPort,
+									Protocol:      corev1.ProtocolTCP,
+								},
+							},
 							ReadinessProbe: &corev1.Probe{
 								ProbeHandler: corev1.ProbeHandler{
 									HTTPGet: &corev1.HTTPGetAction{
@@ -210,9 +266,57 @@ func WebhookDeployment(ns, image string, m mondoov1alpha2.MondooAuditConfig, int
 		},
 	}
 
+	if m.Spec.Admission.DecisionLog.File.Enable {
+		addDecisionLogVolume(deployment, m.Spec.Admission.DecisionLog.File)
+	}
+
 	return deployment
 }
 
+// decisionLogArgs returns the webhook arguments for the configured decision log sinks.
+func decisionLogArgs(decisionLog mondoov1alpha2.AdmissionDecisionLog) []string {
+	var args []string
+	if decisionLog.Stdout {
+		args = append(args, "--decision-log-stdout")
+	}
+	if file := decisionLog.File; file.Enable {
+		args = append(args, "--decision-log-file", path.Join(decisionLogDir, "decisions.jsonl"))
+		if file.MaxSizeMB > 0 {
+			args = append(args, "--decision-log-file-max-size-mb", fmt.Sprint(file.MaxSizeMB))
+		}
+		if file.MaxBackups != nil {
+			args = append(args, "--decision-log-file-max-backups", fmt.Sprint(*file.MaxBackups))
+		}
+	}
+	if httpSink := decisionLog.HTTP; httpSink.Endpoint != "" {
+		args = append(args, "--decision-log-http-endpoint", httpSink.Endpoint)
+		if httpSink.BatchSize > 0 {
+			args = append(args, "--decision-log-http-batch-size", fmt.Sprint(httpSink.BatchSize))
+		}
+		if httpSink.MaxRetries != nil {
+			args = append(args, "--decision-log-http-max-retries", fmt.Sprint(*httpSink.MaxRetries))
+		}
+	}
+	return args
+}
+
+// addDecisionLogVolume mounts the volume for the decision log file. The root filesystem of the webhook is read-only,
+// so the files are written to the PersistentVolumeClaim or to an emptyDir if no claim is configured.
+func addDecisionLogVolume(deployment *appsv1.Deployment, file mondoov1alpha2.DecisionLogFile) {
+	volume := corev1.Volume{Name: "decision-log"}
+	if file.VolumeClaimName != "" {
+		volume.VolumeSource.PersistentVolumeClaim = &corev1.PersistentVolumeClaimVolumeSource{ClaimName: file.VolumeClaimName}
+	} else {
+		volume.VolumeSource.EmptyDir = &corev1.EmptyDirVolumeSource{}
+	}
+	podSpec := &deployment.Spec.Template.Spec
+	podSpec.Volumes = append(podSpec.Volumes, volume)
+	podSpec.Containers[0].VolumeMounts = append(podSpec.Containers[0].VolumeMounts, corev1.VolumeMount{
+		Name:      volume.Name,
+		MountPath: decisionLogDir,
+	})
+}
+
 func WebhookDeploymentLabels() map[string]string {
 	return map[string]string{
 		webhookDeploymentLabelKey: webhookDeploymentLabelValue,
@@ -239,6 +343,34 @@ func WebhookService(ns string, m mondoov1alpha2.MondooAuditConfig) *corev1.Servi
 	}
 }
 
+// WebhookMetricsService returns the Service exposing the metrics of the webhook. The ServiceMonitor for the webhook
+// selects it by the WebhookDeploymentLabels.
+func WebhookMetricsService(ns string, m mondoov1alpha2.MondooAuditConfig) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      webhookMetricsServiceName(m.Name),
+			Namespace: ns,
+			Labels:    WebhookDeploymentLabels(),
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "metrics",
+					Port:       int32(webhookMetricsPort),
+					Protocol:   corev1.ProtocolTCP,
+					TargetPort: intstr.FromInt(webhookMetricsPort),
+				},
+			},
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: WebhookDeploymentLabels(),
+		},
+	}
+}
+
+func webhookMetricsServiceName(prefix string) string {
+	return prefix + "-webhook-metrics"
+}
+
 func webhookServiceName(prefix string) string {
 	return prefix + "-webhook-service"
 }
@@ -253,3 +385,168 @@ func validatingWebhookName(mondooAuditConfig *mondoov1alpha2.MondooAuditConfig)
 	}
 	return fmt.Sprintf("%s-%s-mondoo", mondooAuditConfig.Namespace, mondooAuditConfig.Name), nil
 }
+
+// webhookNamespaceSelector translates the namespace filtering of the MondooAuditConfig into a namespace selector
+// for the ValidatingWebhookConfiguration, so requests for filtered namespaces are not sent to the webhook at all.
+// Glob patterns cannot be expressed as a label selector. They are left out and only filtered by the webhook
+// itself. A nil selector is returned if nothing can be translated.
+func webhookNamespaceSelector(m mondoov1alpha2.MondooAuditConfig) *metav1.LabelSelector {
+	selector := &metav1.LabelSelector{}
+	if s := m.Spec.Filtering.NamespaceSelector; s != nil {
+		if len(s.MatchLabels) > 0 {
+			selector.MatchLabels = make(map[string]string, len(s.MatchLabels))
+			for k, v := range s.MatchLabels {
+				selector.MatchLabels[k] = v
+			}
+		}
+		for _, r := range s.MatchExpressions {
+			selector.MatchExpressions = append(selector.MatchExpressions, *r.DeepCopy())
+		}
+	}
+
+	// Exclude patterns are ignored if include patterns are set, so only one of both is translated.
+	namespaces := m.Spec.Filtering.Namespaces
+	if len(namespaces.Include) > 0 {
+		if names, ok := literalNamespaces(namespaces.Include); ok {
+			selector.MatchExpressions = append(selector.MatchExpressions, metav1.LabelSelectorRequirement{
+				Key:      corev1.LabelMetadataName,
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   names,
+			})
+		}
+	} else if names, _ := literalNamespaces(namespaces.Exclude); len(names) > 0 {
+		selector.MatchExpressions = append(selector.MatchExpressions, metav1.LabelSelectorRequirement{
+			Key:      corev1.LabelMetadataName,
+			Operator: metav1.LabelSelectorOpNotIn,
+			Values:   names,
+		})
+	}
+
+	if len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0 {
+		return nil
+	}
+	return selector
+}
+
+// literalNamespaces returns the patterns which are plain namespace names. The boolean is false if at least one
+// of the patterns is a glob.
+func literalNamespaces(patterns []string) ([]string, bool) {
+	var names []string
+	for _, p := range patterns {
+		if strings.ContainsAny(p, `*?[]{}\`) {
+			continue
+		}
+		names = append(names, p)
+	}
+	return names, len(names) == len(patterns)
+}
+
+// webhookTimeoutSeconds returns the timeout of the API server for the webhook calls. The webhook gets the same
+// timeout to answer before it passes.
+func webhookTimeoutSeconds(m mondoov1alpha2.MondooAuditConfig) int32 {
+	return pointer.Int32Deref(m.Spec.Admission.TimeoutSeconds, defaultWebhookTimeoutSeconds)
+}
+
+// namespaceModeWebhooks splits the webhook into one webhook per admission mode, so the failure policy matches
+// the mode of the namespace. Namespaces can override the mode of the MondooAuditConfig with the
+// AdmissionModeNamespaceLabel. Namespaces with disabled admission are not sent to any of the webhooks.
+// The rules of the webhooks match the resources.
+func namespaceModeWebhooks(
+	webhook webhooksv1.ValidatingWebhook,
+	mode mondoov1alpha2.AdmissionMode,
+	namespaceSelector *metav1.LabelSelector,
+	resources []wutils.AdmissionResource,
+	timeoutSeconds int32,
+) []webhooksv1.ValidatingWebhook {
+	var operations []webhooksv1.OperationType
+	if len(webhook.Rules) > 0 {
+		operations = webhook.Rules[0].Operations
+	}
+
+	overrides := []string{
+		string(mondoov1alpha2.Enforcing), string(mondoov1alpha2.Permissive), string(mondoov1alpha2.Audit), constants.AdmissionModeDisabled,
+	}
+	defaultWebhook := modeWebhook(webhook, "", failurePolicy(mode), namespaceSelector, timeoutSeconds, metav1.LabelSelectorRequirement{
+		Key:      constants.AdmissionModeNamespaceLabel,
+		Operator: metav1.LabelSelectorOpNotIn,
+		Values:   overrides,
+	})
+	defaultWebhook.Rules = admissionRules(resources, operations, true)
+	webhooks := []webhooksv1.ValidatingWebhook{defaultWebhook}
+
+	for _, m := range []mondoov1alpha2.AdmissionMode{mondoov1alpha2.Enforcing, mondoov1alpha2.Permissive, mondoov1alpha2.Audit} {
+		w := modeWebhook(webhook, string(m)+".", failurePolicy(m), namespaceSelector, timeoutSeconds, metav1.LabelSelectorRequirement{
+			Key:      constants.AdmissionModeNamespaceLabel,
+			Operator: metav1.LabelSelectorOpIn,
+			Values:   []string{string(m)},
+		})
+		w.Rules = admissionRules(resources, operations, false)
+		webhooks = append(webhooks, w)
+	}
+	return webhooks
+}
+
+// admissionRules returns one rule per API group and version of the resources. The namespace selector of a
+// webhook never skips cluster-scoped resources other than Namespaces, so they are only included if
+// clusterScoped is true. Otherwise they would be sent to every mode webhook.
+func admissionRules(
+	resources []wutils.AdmissionResource, operations []webhooksv1.OperationType, clusterScoped bool,
+) []webhooksv1.RuleWithOperations {
+	var rules []webhooksv1.RuleWithOperations
+	ruleIndex := make(map[schema.GroupVersion]int)
+	for _, r := range resources {
+		if r.ClusterScoped && r.Resource != "namespaces" && !clusterScoped {
+			continue
+		}
+		i, ok := ruleIndex[r.GroupVersion]
+		if !ok {
+			i = len(rules)
+			ruleIndex[r.GroupVersion] = i
+			rules = append(rules, webhooksv1.RuleWithOperations{
+				Operations: operations,
+				Rule: webhooksv1.Rule{
+					APIGroups:   []string{r.Group},
+					APIVersions: []string{r.Version},
+				},
+			})
+		}
+		rules[i].Resources = append(rules[i].Resources, r.Resource)
+	}
+	return rules
+}
+
+// admissionResourceNames returns the names of the resources configured in the MondooAuditConfig.
+func admissionResourceNames(m mondoov1alpha2.MondooAuditConfig) []string {
+	var names []string
+	for _, r := range m.Spec.Admission.Resources {
+		names = append(names, string(r))
+	}
+	return names
+}
+
+func modeWebhook(
+	webhook webhooksv1.ValidatingWebhook,
+	namePrefix string,
+	policy webhooksv1.FailurePolicyType,
+	namespaceSelector *metav1.LabelSelector,
+	timeoutSeconds int32,
+	modeRequirement metav1.LabelSelectorRequirement,
+) webhooksv1.ValidatingWebhook {
+	w := *webhook.DeepCopy()
+	w.Name = namePrefix + w.Name
+	w.FailurePolicy = &policy
+	w.TimeoutSeconds = pointer.Int32(timeoutSeconds)
+	w.NamespaceSelector = &metav1.LabelSelector{}
+	if namespaceSelector != nil {
+		w.NamespaceSelector = namespaceSelector.DeepCopy()
+	}
+	w.NamespaceSelector.MatchExpressions = append(w.NamespaceSelector.MatchExpressions, modeRequirement)
+	return w
+}
+
+func failurePolicy(mode mondoov1alpha2.AdmissionMode) webhooksv1.FailurePolicyType {
+	if mode == mondoov1alpha2.Enforcing {
+		return webhooksv1.Fail
+	}
+	return webhooksv1.Ignore
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2022 Mondoo, Inc.
+
+This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at https://mozilla.org/MPL/2.0/.
+*/
+
+package admission
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	webhooksv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	mondoov1alpha2 "go.mondoo.com/mondoo-operator/api/v1alpha2"
+	"go.mondoo.com/mondoo-operator/pkg/constants"
+	wutils "go.mondoo.com/mondoo-operator/pkg/webhooks/utils"
+)
+
+func TestWebhookNamespaceSelector(t *testing.T) {
+	tests := []struct {
+		name      string
+		filtering mondoov1alpha2.Filtering
+		expected  *metav1.LabelSelector
+	}{
+		{
+			name: "no filtering",
+		},
+		{
+			name: "include",
+			filtering: mondoov1alpha2.Filtering{
+				Namespaces: mondoov1alpha2.FilteringSpec{Include: []string{"app1", "app2"}, Exclude: []string{"kube-system"}},
+			},
+			expected: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: corev1.LabelMetadataName, Operator: metav1.LabelSelectorOpIn, Values: []string{"app1", "app2"}},
+			}},
+		},
+		{
+			name: "include with glob",
+			filtering: mondoov1alpha2.Filtering{
+				Namespaces: mondoov1alpha2.FilteringSpec{Include: []string{"app1", "app-*"}},
+			},
+		},
+		{
+			name: "exclude with glob",
+			filtering: mondoov1alpha2.Filtering{
+				Namespaces: mondoov1alpha2.FilteringSpec{Exclude: []string{"kube-*", "monitoring"}},
+			},
+			expected: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: corev1.LabelMetadataName, Operator: metav1.LabelSelectorOpNotIn, Values: []string{"monitoring"}},
+			}},
+		},
+		{
+			name: "namespace selector",
+			filtering: mondoov1alpha2.Filtering{
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "env", Operator: metav1.LabelSelectorOpExists},
+					},
+				},
+				Namespaces: mondoov1alpha2.FilteringSpec{Include: []string{"app1"}},
+			},
+			expected: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "env", Operator: metav1.LabelSelectorOpExists},
+				{Key: corev1.LabelMetadataName, Operator: metav1.LabelSelectorOpIn, Values: []string{"app1"}},
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := mondoov1alpha2.MondooAuditConfig{Spec: mondoov1alpha2.MondooAuditConfigSpec{Filtering: test.filtering}}
+			assert.Equal(t, test.expected, webhookNamespaceSelector(m))
+		})
+	}
+}
+
+func TestNamespaceModeWebhooks(t *testing.T) {
+	webhook := webhooksv1.ValidatingWebhook{Name: "policy.k8s.mondoo.com"}
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}}
+
+	webhooks := namespaceModeWebhooks(webhook, mondoov1alpha2.Enforcing, selector, nil, 15)
+	require.Len(t, webhooks, 4)
+
+	assert.Equal(t, "policy.k8s.mondoo.com", webhooks[0].Name)
+	assert.Equal(t, webhooksv1.Fail, *webhooks[0].FailurePolicy)
+	assert.Equal(t, &metav1.LabelSelector{
+		MatchLabels: map[string]string{"team": "payments"},
+		MatchExpressions: []metav1.LabelSelectorRequirement{{
+			Key:      constants.AdmissionModeNamespaceLabel,
+			Operator: metav1.LabelSelectorOpNotIn,
+			Values:   []string{"enforcing", "permissive", "audit", "disabled"},
+		}},
+	}, webhooks[0].NamespaceSelector)
+
+	assert.Equal(t, "enforcing.policy.k8s.mondoo.com", webhooks[1].Name)
+	assert.Equal(t, webhooksv1.Fail, *webhooks[1].FailurePolicy)
+	assert.Equal(t, &metav1.LabelSelector{
+		MatchLabels: map[string]string{"team": "payments"},
+		MatchExpressions: []metav1.LabelSelectorRequirement{{
+			Key:      constants.AdmissionModeNamespaceLabel,
+			Operator: metav1.LabelSelectorOpIn,
+			Values:   []string{"enforcing"},
+		}},
+	}, webhooks[1].NamespaceSelector)
+
+	assert.Equal(t, "permissive.policy.k8s.mondoo.com", webhooks[2].Name)
+	assert.Equal(t, webhooksv1.Ignore, *webhooks[2].FailurePolicy)
+
+	// Audit mode never denies resources, so the API server shouldn't either if the webhook is unavailable.
+	assert.Equal(t, "audit.policy.k8s.mondoo.com", webhooks[3].Name)
+	assert.Equal(t, webhooksv1.Ignore, *webhooks[3].FailurePolicy)
+
+	for _, w := range webhooks {
+		assert.Equal(t, int32(15), *w.TimeoutSeconds)
+	}
+
+	// The selector of the MondooAuditConfig is not modified.
+	assert.Empty(t, selector.MatchExpressions)
+}
+
+func TestAdmissionRules(t *testing.T) {
+	resources, err := wutils.AdmissionResources([]string{"pods", "deployments", "clusterroles", "namespaces", "statefulsets"})
+	require.NoError(t, err)
+	operations := []webhooksv1.OperationType{webhooksv1.Create, webhooksv1.Update}
+
+	assert.Equal(t, []webhooksv1.RuleWithOperations{
+		{
+			Operations: operations,
+			Rule:       webhooksv1.Rule{APIGroups: []string{""}, APIVersions: []string{"v1"}, Resources: []string{"pods", "namespaces"}},
+		},
+		{
+			Operations: operations,
+			Rule:       webhooksv1.Rule{APIGroups: []string{"apps"}, APIVersions: []string{"v1"}, Resources: []string{"deployments", "statefulsets"}},
+		},
+		{
+			Operations: operations,
+			Rule:       webhooksv1.Rule{APIGroups: []string{"rbac.authorization.k8s.io"}, APIVersions: []string{"v1"}, Resources: []string{"clusterroles"}},
+		},
+	}, admissionRules(resources, operations, true))
+
+	// Cluster-scoped resources would be sent to every mode webhook, only Namespaces are matched by their labels.
+	rules := admissionRules(resources, operations, false)
+	require.Len(t, rules, 2)
+	assert.Equal(t, []string{"pods", "namespaces"}, rules[0].Resources)
+
+	_, err = wutils.AdmissionResources([]string{"secrets"})
+	assert.Error(t, err)
+}
+
+func TestWebhookDeployment_DecisionLog(t *testing.T) {
+	m := mondoov1alpha2.MondooAuditConfig{ObjectMeta: metav1.ObjectMeta{Name: "mondoo-client"}}
+	deployment := WebhookDeployment("mondoo-operator", "webhook:latest", m, "", "cluster")
+	assert.NotContains(t, deployment.Spec.Template.Spec.Containers[0].Args, "--decision-log-stdout")
+	assert.Subset(t, deployment.Spec.Template.Spec.Containers[0].Args, []string{"--webhook-timeout", "10s"})
+	assert.NotContains(t, deployment.Spec.Template.Spec.Containers[0].Args, "--max-in-flight-scans")
+	assert.Len(t, deployment.Spec.Template.Spec.Volumes, 2)
+
+	m.Spec.Admission.DecisionLog = mondoov1alpha2.AdmissionDecisionLog{
+		Stdout: true,
+		File:   mondoov1alpha2.DecisionLogFile{Enable: true, VolumeClaimName: "audit", MaxSizeMB: 10, MaxBackups: pointer.Int32(0)},
+		HTTP:   mondoov1alpha2.DecisionLogHTTP{Endpoint: "https://audit.example.com", BatchSize: 50},
+	}
+	deployment = WebhookDeployment("mondoo-operator", "webhook:latest", m, "", "cluster")
+	container := deployment.Spec.Template.Spec.Containers[0]
+	assert.Subset(t, container.Args, []string{
+		"--decision-log-stdout",
+		"--decision-log-file", "/var/log/mondoo/decisions.jsonl",
+		"--decision-log-file-max-size-mb", "10",
+		"--decision-log-file-max-backups", "0",
+		"--decision-log-http-endpoint", "https://audit.example.com",
+		"--decision-log-http-batch-size", "50",
+	})
+	assert.NotContains(t, container.Args, "--decision-log-http-max-retries")
+
+	assert.Contains(t, container.VolumeMounts, corev1.VolumeMount{Name: "decision-log", MountPath: "/var/log/mondoo"})
+	assert.Contains(t, deployment.Spec.Template.Spec.Volumes, corev1.Volume{
+		Name:         "decision-log",
+		VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "audit"}},
+	})
+
+	m.Spec.Admission.DecisionLog.File.VolumeClaimName = ""
+	deployment = WebhookDeployment("mondoo-operator", "webhook:latest", m, "", "cluster")
+	assert.Contains(t, deployment.Spec.Template.Spec.Volumes, corev1.Volume{
+		Name:         "decision-log",
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	})
+}
+
+func TestWebhookDeployment_Timeout(t *testing.T) {
+	m := mondoov1alpha2.MondooAuditConfig{ObjectMeta: metav1.ObjectMeta{Name: "mondoo-client"}}
+	m.Spec.Admission.TimeoutSeconds = pointer.Int32(25)
+	deployment := WebhookDeployment("mondoo-operator", "webhook:latest", m, "", "cluster")
+	assert.Subset(t, deployment.Spec.Template.Spec.Containers[0].Args, []string{"--webhook-timeout", "25s"})
+	assert.Equal(t, int32(25), webhookTimeoutSeconds(m))
+}
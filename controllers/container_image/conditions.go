@@ -9,6 +9,8 @@ file, You can obtain one at https://mozilla.org/MPL/2.0/.
 package container_image
 
 import (
+	"fmt"
+
 	"go.mondoo.com/mondoo-operator/api/v1alpha2"
 	"go.mondoo.com/mondoo-operator/pkg/utils/mondoo"
 	corev1 "k8s.io/api/core/v1"
@@ -32,3 +34,10 @@ func updateImageScanningConditions(config *v1alpha2.MondooAuditConfig, degradedS
 	config.Status.Conditions = mondoo.SetMondooAuditCondition(
 		config.Status.Conditions, v1alpha2.K8sContainerImageScanningDegraded, status, reason, msg, updateCheck)
 }
+
+func updateImageScanningScheduleConditions(config *v1alpha2.MondooAuditConfig, err error) {
+	msg := fmt.Sprintf("Kubernetes Container Image Scanning schedule is invalid: %s", err)
+	config.Status.Conditions = mondoo.SetMondooAuditCondition(
+		config.Status.Conditions, v1alpha2.K8sContainerImageScanningDegraded, corev1.ConditionTrue, "KubernetesContainerImageScanningInvalidSchedule", msg,
+		mondoo.UpdateConditionIfReasonOrMessageChange)
+}
@@ -49,6 +49,13 @@ func (n *DeploymentHandler) Reconcile(ctx context.Context) (ctrl.Result, error)
 		return ctrl.Result{}, n.down(ctx)
 	}
 
+	if _, err := CronJobSchedule(*n.Mondoo); err != nil {
+		logger.Error(err, "Invalid container image scanning schedule", "namespace", n.Mondoo.Namespace, "name", n.Mondoo.Name)
+		updateImageScanningScheduleConditions(n.Mondoo, err)
+		// Retrying does not help here. The MondooAuditConfig is reconciled again once the schedule is changed.
+		return ctrl.Result{}, nil
+	}
+
 	if err := n.syncCronJob(ctx); err != nil {
 		return ctrl.Result{}, err
 	}
@@ -76,7 +83,17 @@ func (n *DeploymentHandler) syncCronJob(ctx context.Context) error {
 		return err
 	}
 
-	updated, err := n.syncConfigMap(ctx, clusterUid)
+	namespaces, err := k8s.ResolveNamespaceFiltering(ctx, n.KubeClient, n.Mondoo.Spec.Filtering)
+	if err != nil {
+		logger.Error(err, "Failed to resolve the namespaces to scan", "namespace", n.Mondoo.Namespace, "name", n.Mondoo.Name)
+		return err
+	}
+	if namespaces.NoMatch {
+		logger.Info("No namespace matches the namespace selector, suspending the container image scan",
+			"namespace", n.Mondoo.Namespace, "name", n.Mondoo.Name)
+	}
+
+	updated, err := n.syncConfigMap(ctx, clusterUid, namespaces)
 	if err != nil {
 		return err
 	}
@@ -114,7 +131,7 @@ func (n *DeploymentHandler) syncCronJob(ctx context.Context) error {
 	}
 
 	existing := &batchv1.CronJob{}
-	desired := CronJob(mondooClientImage, integrationMrn, clusterUid, privateRegistriesSecretName, *n.Mondoo)
+	desired := CronJob(mondooClientImage, integrationMrn, clusterUid, privateRegistriesSecretName, namespaces, *n.Mondoo)
 	if err := ctrl.SetControllerReference(n.Mondoo, desired, n.KubeClient.Scheme()); err != nil {
 		logger.Error(err, "Failed to set ControllerReference", "namespace", desired.Namespace, "name", desired.Name)
 		return err
@@ -126,9 +143,17 @@ func (n *DeploymentHandler) syncCronJob(ctx context.Context) error {
 		return err
 	}
 
+	// Without a configured schedule we keep the default one that was picked when the CronJob was created. A
+	// schedule that was configured before is replaced by a new default one.
+	if !created && n.Mondoo.Spec.Containers.Schedule == "" && !k8s.HasCustomSchedule(*existing) {
+		desired.Spec.Schedule = existing.Spec.Schedule
+	}
+
 	if created {
 		logger.Info("Created CronJob", "namespace", desired.Namespace, "name", desired.Name)
 	} else if !k8s.AreCronJobsEqual(*existing, *desired) {
+		k8s.UpdateSchedule(existing, *desired)
+		existing.Spec.Suspend = desired.Spec.Suspend
 		existing.Spec.JobTemplate = desired.Spec.JobTemplate
 		existing.SetOwnerReferences(desired.GetOwnerReferences())
 
@@ -150,7 +175,7 @@ func (n *DeploymentHandler) syncCronJob(ctx context.Context) error {
 // syncConfigMap syncs the inventory ConfigMap. Returns a boolean indicating whether the ConfigMap has been updated. It
 // can only be "true", if the ConfigMap existed before this reconcile cycle and the inventory was different from the
 // desired state.
-func (n *DeploymentHandler) syncConfigMap(ctx context.Context, clusterUid string) (bool, error) {
+func (n *DeploymentHandler) syncConfigMap(ctx context.Context, clusterUid string, namespaces k8s.NamespaceFiltering) (bool, error) {
 	existing := &corev1.ConfigMap{}
 
 	integrationMrn, err := k8s.TryGetIntegrationMrnForAuditConfig(ctx, n.KubeClient, *n.Mondoo)
@@ -159,7 +184,7 @@ func (n *DeploymentHandler) syncConfigMap(ctx context.Context, clusterUid string
 		return false, err
 	}
 
-	desired, err := ConfigMap(integrationMrn, clusterUid, *n.Mondoo)
+	desired, err := ConfigMap(integrationMrn, clusterUid, namespaces, *n.Mondoo)
 	if err != nil {
 		logger.Error(err, "failed to generate desired ConfigMap with inventory")
 		return false, err
@@ -35,7 +35,9 @@ const (
 	InventoryConfigMapBase = "-containers-inventory"
 )
 
-func CronJob(image, integrationMrn, clusterUid, privateImageScanningSecretName string, m v1alpha2.MondooAuditConfig) *batchv1.CronJob {
+func CronJob(
+	image, integrationMrn, clusterUid, privateImageScanningSecretName string, namespaces k8s.NamespaceFiltering, m v1alpha2.MondooAuditConfig,
+) *batchv1.CronJob {
 	ls := CronJobLabels(m)
 
 	// We want to start the cron job one minute after it was enabled.
@@ -51,6 +53,8 @@ func CronJob(image, integrationMrn, clusterUid, privateImageScanningSecretName s
 		Spec: batchv1.CronJobSpec{
 			Schedule:          cronTab,
 			ConcurrencyPolicy: batchv1.ForbidConcurrent,
+			// Without any matching namespace there is nothing to scan.
+			Suspend: pointer.Bool(namespaces.NoMatch),
 			JobTemplate: batchv1.JobTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{Labels: ls},
 				Spec: batchv1.JobSpec{
@@ -146,6 +150,11 @@ func CronJob(image, integrationMrn, clusterUid, privateImageScanningSecretName s
 		},
 	}
 
+	// The schedule is validated by the DeploymentHandler before the CronJob is created.
+	if schedule, err := CronJobSchedule(m); err == nil && schedule != "" {
+		k8s.SetCustomSchedule(cronjob, schedule)
+	}
+
 	if privateImageScanningSecretName != "" {
 		// mount secret needed to pull images from private registries
 		cronjob.Spec.JobTemplate.Spec.Template.Spec.Volumes = append(cronjob.Spec.JobTemplate.Spec.Template.Spec.Volumes, corev1.Volume{
@@ -187,6 +196,15 @@ func CronJob(image, integrationMrn, clusterUid, privateImageScanningSecretName s
 	return cronjob
 }
 
+// CronJobSchedule returns the container image scanning schedule configured in the MondooAuditConfig with the
+// jitter applied. An empty string is returned if no schedule is configured.
+func CronJobSchedule(m v1alpha2.MondooAuditConfig) (string, error) {
+	if m.Spec.Containers.Schedule == "" {
+		return "", nil
+	}
+	return k8s.CronScheduleWithJitter(m.Spec.Containers.Schedule, m.Spec.Containers.ScheduleJitterMinutes, string(m.UID))
+}
+
 func CronJobLabels(m v1alpha2.MondooAuditConfig) map[string]string {
 	return map[string]string{
 		"app":       "mondoo-container-scan",
@@ -204,8 +222,8 @@ func CronJobName(prefix string) string {
 	return fmt.Sprintf("%s%s", prefix, CronJobNameSuffix)
 }
 
-func ConfigMap(integrationMRN, clusterUID string, m v1alpha2.MondooAuditConfig) (*corev1.ConfigMap, error) {
-	inv, err := Inventory(integrationMRN, clusterUID, m)
+func ConfigMap(integrationMRN, clusterUID string, namespaces k8s.NamespaceFiltering, m v1alpha2.MondooAuditConfig) (*corev1.ConfigMap, error) {
+	inv, err := Inventory(integrationMRN, clusterUID, namespaces, m)
 	if err != nil {
 		return nil, err
 	}
@@ -223,7 +241,7 @@ func ConfigMapName(prefix string) string {
 	return fmt.Sprintf("%s%s", prefix, InventoryConfigMapBase)
 }
 
-func Inventory(integrationMRN, clusterUID string, m v1alpha2.MondooAuditConfig) (string, error) {
+func Inventory(integrationMRN, clusterUID string, namespaces k8s.NamespaceFiltering, m v1alpha2.MondooAuditConfig) (string, error) {
 	inv := &v1.Inventory{
 		Metadata: &v1.ObjectMeta{
 			Name: "mondoo-k8s-containers-inventory",
@@ -235,8 +253,8 @@ func Inventory(integrationMRN, clusterUID string, m v1alpha2.MondooAuditConfig)
 						{
 							Backend: providers.ProviderType_K8S,
 							Options: map[string]string{
-								"namespaces":         strings.Join(m.Spec.Filtering.Namespaces.Include, ","),
-								"namespaces-exclude": strings.Join(m.Spec.Filtering.Namespaces.Exclude, ","),
+								"namespaces":         strings.Join(namespaces.Include, ","),
+								"namespaces-exclude": strings.Join(namespaces.Exclude, ","),
 							},
 							Discover: &providers.Discovery{
 								Targets: []string{"container-images"},
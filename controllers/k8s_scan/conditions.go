@@ -9,6 +9,8 @@ file, You can obtain one at https://mozilla.org/MPL/2.0/.
 package k8s_scan
 
 import (
+	"fmt"
+
 	"go.mondoo.com/mondoo-operator/api/v1alpha2"
 	"go.mondoo.com/mondoo-operator/pkg/utils/mondoo"
 	corev1 "k8s.io/api/core/v1"
@@ -32,3 +34,10 @@ func updateWorkloadsConditions(config *v1alpha2.MondooAuditConfig, degradedStatu
 	config.Status.Conditions = mondoo.SetMondooAuditCondition(
 		config.Status.Conditions, v1alpha2.K8sResourcesScanningDegraded, status, reason, msg, updateCheck)
 }
+
+func updateWorkloadsScheduleConditions(config *v1alpha2.MondooAuditConfig, err error) {
+	msg := fmt.Sprintf("Kubernetes Resources Scanning schedule is invalid: %s", err)
+	config.Status.Conditions = mondoo.SetMondooAuditCondition(
+		config.Status.Conditions, v1alpha2.K8sResourcesScanningDegraded, corev1.ConditionTrue, "KubernetesResourcesScanningInvalidSchedule", msg,
+		mondoo.UpdateConditionIfReasonOrMessageChange)
+}
@@ -21,6 +21,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"go.mondoo.com/mondoo-operator/api/v1alpha2"
+	"go.mondoo.com/mondoo-operator/controllers/resource_monitor"
 	"go.mondoo.com/mondoo-operator/controllers/resource_monitor/scan_api_store"
 	"go.mondoo.com/mondoo-operator/controllers/scanapi"
 	"go.mondoo.com/mondoo-operator/pkg/utils/k8s"
@@ -40,11 +41,13 @@ type DeploymentHandler struct {
 	ContainerImageResolver mondoo.ContainerImageResolver
 	MondooOperatorConfig   *v1alpha2.MondooOperatorConfig
 	ScanApiStore           scan_api_store.ScanApiStore
+	ResourceMonitors       *resource_monitor.DynamicResourceMonitors
 }
 
 func (n *DeploymentHandler) Reconcile(ctx context.Context) (ctrl.Result, error) {
 	if !n.Mondoo.Spec.KubernetesResources.Enable {
 		n.ScanApiStore.Delete(scanapi.ScanApiServiceUrl(*n.Mondoo))
+		n.ResourceMonitors.SetResources(client.ObjectKeyFromObject(n.Mondoo), nil)
 		return ctrl.Result{}, n.down(ctx)
 	}
 
@@ -55,6 +58,14 @@ func (n *DeploymentHandler) Reconcile(ctx context.Context) (ctrl.Result, error)
 			"name", n.Mondoo.Name)
 		return ctrl.Result{}, err
 	}
+	n.ResourceMonitors.SetResources(client.ObjectKeyFromObject(n.Mondoo), n.Mondoo.Spec.KubernetesResources.MonitoredResources)
+
+	if _, err := CronJobSchedule(*n.Mondoo); err != nil {
+		logger.Error(err, "Invalid Kubernetes resources scanning schedule", "namespace", n.Mondoo.Namespace, "name", n.Mondoo.Name)
+		updateWorkloadsScheduleConditions(n.Mondoo, err)
+		// Retrying does not help here. The MondooAuditConfig is reconciled again once the schedule is changed.
+		return ctrl.Result{}, nil
+	}
 
 	return ctrl.Result{}, n.syncCronJob(ctx)
 }
@@ -81,8 +92,18 @@ func (n *DeploymentHandler) syncCronJob(ctx context.Context) error {
 		return err
 	}
 
+	namespaces, err := k8s.ResolveNamespaceFiltering(ctx, n.KubeClient, n.Mondoo.Spec.Filtering)
+	if err != nil {
+		logger.Error(err, "Failed to resolve the namespaces to scan", "namespace", n.Mondoo.Namespace, "name", n.Mondoo.Name)
+		return err
+	}
+	if namespaces.NoMatch {
+		logger.Info("No namespace matches the namespace selector, suspending the Kubernetes resources scan",
+			"namespace", n.Mondoo.Namespace, "name", n.Mondoo.Name)
+	}
+
 	existing := &batchv1.CronJob{}
-	desired := CronJob(mondooOperatorImage, integrationMrn, clusterUid, *n.Mondoo)
+	desired := CronJob(mondooOperatorImage, integrationMrn, clusterUid, namespaces, *n.Mondoo)
 	if err := ctrl.SetControllerReference(n.Mondoo, desired, n.KubeClient.Scheme()); err != nil {
 		logger.Error(err, "Failed to set ControllerReference", "namespace", desired.Namespace, "name", desired.Name)
 		return err
@@ -94,9 +115,17 @@ func (n *DeploymentHandler) syncCronJob(ctx context.Context) error {
 		return err
 	}
 
+	// Without a configured schedule we keep the default one that was picked when the CronJob was created. A
+	// schedule that was configured before is replaced by a new default one.
+	if !created && n.Mondoo.Spec.KubernetesResources.Schedule == "" && !k8s.HasCustomSchedule(*existing) {
+		desired.Spec.Schedule = existing.Spec.Schedule
+	}
+
 	if created {
 		logger.Info("Created CronJob", "namespace", desired.Namespace, "name", desired.Name)
 	} else if !k8s.AreCronJobsEqual(*existing, *desired) {
+		k8s.UpdateSchedule(existing, *desired)
+		existing.Spec.Suspend = desired.Spec.Suspend
 		existing.Spec.JobTemplate = desired.Spec.JobTemplate
 		existing.SetOwnerReferences(desired.GetOwnerReferences())
 
@@ -34,6 +34,7 @@ import (
 	"go.mondoo.com/mondoo-operator/controllers/scanapi"
 	"go.mondoo.com/mondoo-operator/pkg/constants"
 	"go.mondoo.com/mondoo-operator/pkg/mondooclient"
+	"go.mondoo.com/mondoo-operator/pkg/utils/k8s"
 	"go.mondoo.com/mondoo-operator/pkg/utils/mondoo"
 	fakeMondoo "go.mondoo.com/mondoo-operator/pkg/utils/mondoo/fake"
 	"go.mondoo.com/mondoo-operator/pkg/utils/test"
@@ -81,8 +82,9 @@ func (s *DeploymentHandlerSuite) TestReconcile_Create() {
 
 	scanApiUrl := scanapi.ScanApiServiceUrl(*d.Mondoo)
 	s.scanApiStoreMock.EXPECT().Add(&scan_api_store.ScanApiStoreAddOpts{
-		Url:   scanApiUrl,
-		Token: "token",
+		Url:         scanApiUrl,
+		AuditConfig: client.ObjectKeyFromObject(&s.auditConfig),
+		Token:       "token",
 	}).Times(1)
 
 	result, err := d.Reconcile(s.ctx)
@@ -95,7 +97,7 @@ func (s *DeploymentHandlerSuite) TestReconcile_Create() {
 	image, err := s.containerImageResolver.MondooOperatorImage("", "", false)
 	s.NoError(err)
 
-	expected := CronJob(image, "", test.KubeSystemNamespaceUid, s.auditConfig)
+	expected := CronJob(image, "", test.KubeSystemNamespaceUid, k8s.NamespaceFiltering{}, s.auditConfig)
 	s.NoError(ctrl.SetControllerReference(&s.auditConfig, expected, d.KubeClient.Scheme()))
 
 	// Set some fields that the kube client sets
@@ -120,6 +122,7 @@ func (s *DeploymentHandlerSuite) TestReconcile_Create_ConsoleIntegration() {
 	scanApiUrl := scanapi.ScanApiServiceUrl(*d.Mondoo)
 	s.scanApiStoreMock.EXPECT().Add(&scan_api_store.ScanApiStoreAddOpts{
 		Url:            scanApiUrl,
+		AuditConfig:    client.ObjectKeyFromObject(&s.auditConfig),
 		Token:          "token",
 		IntegrationMrn: integrationMrn,
 	}).Times(1)
@@ -149,7 +152,7 @@ func (s *DeploymentHandlerSuite) TestReconcile_Create_ConsoleIntegration() {
 	image, err := s.containerImageResolver.MondooOperatorImage("", "", false)
 	s.NoError(err)
 
-	expected := CronJob(image, integrationMrn, test.KubeSystemNamespaceUid, s.auditConfig)
+	expected := CronJob(image, integrationMrn, test.KubeSystemNamespaceUid, k8s.NamespaceFiltering{}, s.auditConfig)
 	s.NoError(ctrl.SetControllerReference(&s.auditConfig, expected, d.KubeClient.Scheme()))
 
 	// Set some fields that the kube client sets
@@ -171,15 +174,16 @@ func (s *DeploymentHandlerSuite) TestReconcile_Update() {
 
 	scanApiUrl := scanapi.ScanApiServiceUrl(*d.Mondoo)
 	s.scanApiStoreMock.EXPECT().Add(&scan_api_store.ScanApiStoreAddOpts{
-		Url:   scanApiUrl,
-		Token: "token",
+		Url:         scanApiUrl,
+		AuditConfig: client.ObjectKeyFromObject(&s.auditConfig),
+		Token:       "token",
 	}).Times(1)
 
 	image, err := s.containerImageResolver.MondooOperatorImage("", "", false)
 	s.NoError(err)
 
 	// Make sure a cron job exists with different container command
-	cronJob := CronJob(image, "", "", s.auditConfig)
+	cronJob := CronJob(image, "", "", k8s.NamespaceFiltering{}, s.auditConfig)
 	cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Command = []string{"test-command"}
 	s.NoError(d.KubeClient.Create(s.ctx, cronJob))
 
@@ -187,7 +191,7 @@ func (s *DeploymentHandlerSuite) TestReconcile_Update() {
 	s.NoError(err)
 	s.True(result.IsZero())
 
-	expected := CronJob(image, "", test.KubeSystemNamespaceUid, s.auditConfig)
+	expected := CronJob(image, "", test.KubeSystemNamespaceUid, k8s.NamespaceFiltering{}, s.auditConfig)
 	s.NoError(ctrl.SetControllerReference(&s.auditConfig, expected, d.KubeClient.Scheme()))
 
 	// Set some fields that the kube client sets
@@ -206,13 +210,126 @@ func (s *DeploymentHandlerSuite) TestReconcile_Update() {
 	s.Equal(expected, created)
 }
 
+func (s *DeploymentHandlerSuite) TestReconcile_UpdateSchedule() {
+	d := s.createDeploymentHandler()
+
+	scanApiUrl := scanapi.ScanApiServiceUrl(*d.Mondoo)
+	s.scanApiStoreMock.EXPECT().Add(&scan_api_store.ScanApiStoreAddOpts{
+		Url:         scanApiUrl,
+		AuditConfig: client.ObjectKeyFromObject(&s.auditConfig),
+		Token:       "token",
+	}).Times(5)
+
+	result, err := d.Reconcile(s.ctx)
+	s.NoError(err)
+	s.True(result.IsZero())
+
+	created := &batchv1.CronJob{}
+	created.Name = CronJobName(s.auditConfig.Name)
+	created.Namespace = s.auditConfig.Namespace
+	s.NoError(d.KubeClient.Get(s.ctx, client.ObjectKeyFromObject(created), created))
+	defaultSchedule := created.Spec.Schedule
+
+	// Reconciling without a configured schedule keeps the default one
+	result, err = d.Reconcile(s.ctx)
+	s.NoError(err)
+	s.True(result.IsZero())
+
+	s.NoError(d.KubeClient.Get(s.ctx, client.ObjectKeyFromObject(created), created))
+	s.Equal(defaultSchedule, created.Spec.Schedule)
+
+	d.Mondoo.Spec.KubernetesResources.Schedule = "15 2 * * *"
+	result, err = d.Reconcile(s.ctx)
+	s.NoError(err)
+	s.True(result.IsZero())
+
+	s.NoError(d.KubeClient.Get(s.ctx, client.ObjectKeyFromObject(created), created))
+	s.Equal("15 2 * * *", created.Spec.Schedule)
+	s.True(k8s.HasCustomSchedule(*created))
+
+	// Removing the configured schedule switches back to an hourly default schedule
+	d.Mondoo.Spec.KubernetesResources.Schedule = ""
+	result, err = d.Reconcile(s.ctx)
+	s.NoError(err)
+	s.True(result.IsZero())
+
+	s.NoError(d.KubeClient.Get(s.ctx, client.ObjectKeyFromObject(created), created))
+	s.Regexp(`^\d+ \* \* \* \*$`, created.Spec.Schedule)
+	s.False(k8s.HasCustomSchedule(*created))
+	defaultSchedule = created.Spec.Schedule
+
+	result, err = d.Reconcile(s.ctx)
+	s.NoError(err)
+	s.True(result.IsZero())
+
+	s.NoError(d.KubeClient.Get(s.ctx, client.ObjectKeyFromObject(created), created))
+	s.Equal(defaultSchedule, created.Spec.Schedule)
+}
+
+func (s *DeploymentHandlerSuite) TestReconcile_NamespaceSelector() {
+	d := s.createDeploymentHandler()
+	d.Mondoo.Spec.Filtering.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}}
+	s.scanApiStoreMock.EXPECT().Add(gomock.Any()).Times(2)
+
+	// No namespace matches the selector, so the CronJob is suspended.
+	result, err := d.Reconcile(s.ctx)
+	s.NoError(err)
+	s.True(result.IsZero())
+
+	created := &batchv1.CronJob{}
+	created.Name = CronJobName(s.auditConfig.Name)
+	created.Namespace = s.auditConfig.Namespace
+	s.NoError(d.KubeClient.Get(s.ctx, client.ObjectKeyFromObject(created), created))
+	s.True(*created.Spec.Suspend)
+
+	for _, name := range []string{"payments-b", "payments-a"} {
+		s.NoError(d.KubeClient.Create(s.ctx, &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"team": "payments"}},
+		}))
+	}
+
+	result, err = d.Reconcile(s.ctx)
+	s.NoError(err)
+	s.True(result.IsZero())
+
+	s.NoError(d.KubeClient.Get(s.ctx, client.ObjectKeyFromObject(created), created))
+	s.False(*created.Spec.Suspend)
+	s.Contains(created.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Args, "payments-a,payments-b")
+}
+
+func (s *DeploymentHandlerSuite) TestReconcile_InvalidSchedule() {
+	d := s.createDeploymentHandler()
+	d.Mondoo.Spec.KubernetesResources.Schedule = "61 * * * *"
+
+	scanApiUrl := scanapi.ScanApiServiceUrl(*d.Mondoo)
+	s.scanApiStoreMock.EXPECT().Add(&scan_api_store.ScanApiStoreAddOpts{
+		Url:         scanApiUrl,
+		AuditConfig: client.ObjectKeyFromObject(&s.auditConfig),
+		Token:       "token",
+	}).Times(1)
+
+	result, err := d.Reconcile(s.ctx)
+	s.NoError(err)
+	s.True(result.IsZero())
+
+	cronJobs := &batchv1.CronJobList{}
+	s.NoError(d.KubeClient.List(s.ctx, cronJobs))
+	s.Equal(0, len(cronJobs.Items))
+
+	s.Equal(1, len(d.Mondoo.Status.Conditions))
+	condition := d.Mondoo.Status.Conditions[0]
+	s.Equal("KubernetesResourcesScanningInvalidSchedule", condition.Reason)
+	s.Equal(corev1.ConditionTrue, condition.Status)
+}
+
 func (s *DeploymentHandlerSuite) TestReconcile_K8sResourceScanningStatus() {
 	d := s.createDeploymentHandler()
 
 	scanApiUrl := scanapi.ScanApiServiceUrl(*d.Mondoo)
 	s.scanApiStoreMock.EXPECT().Add(&scan_api_store.ScanApiStoreAddOpts{
-		Url:   scanApiUrl,
-		Token: "token",
+		Url:         scanApiUrl,
+		AuditConfig: client.ObjectKeyFromObject(&s.auditConfig),
+		Token:       "token",
 	}).Times(4)
 
 	// Reconcile to create all resources
@@ -303,8 +420,9 @@ func (s *DeploymentHandlerSuite) TestReconcile_Disable() {
 
 	scanApiUrl := scanapi.ScanApiServiceUrl(*d.Mondoo)
 	s.scanApiStoreMock.EXPECT().Add(&scan_api_store.ScanApiStoreAddOpts{
-		Url:   scanApiUrl,
-		Token: "token",
+		Url:         scanApiUrl,
+		AuditConfig: client.ObjectKeyFromObject(&s.auditConfig),
+		Token:       "token",
 	}).Times(1)
 
 	// Reconcile to create all resources
@@ -16,6 +16,7 @@ import (
 	"go.mondoo.com/mondoo-operator/api/v1alpha2"
 	"go.mondoo.com/mondoo-operator/controllers/scanapi"
 	"go.mondoo.com/mondoo-operator/pkg/feature_flags"
+	"go.mondoo.com/mondoo-operator/pkg/utils/k8s"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -25,7 +26,7 @@ import (
 
 const CronJobNameSuffix = "-k8s-scan"
 
-func CronJob(image, integrationMrn, clusterUid string, m v1alpha2.MondooAuditConfig) *batchv1.CronJob {
+func CronJob(image, integrationMrn, clusterUid string, namespaces k8s.NamespaceFiltering, m v1alpha2.MondooAuditConfig) *batchv1.CronJob {
 	ls := CronJobLabels(m)
 
 	cronTab := fmt.Sprintf("%d * * * *", time.Now().Add(1*time.Minute).Minute())
@@ -40,8 +41,8 @@ func CronJob(image, integrationMrn, clusterUid string, m v1alpha2.MondooAuditCon
 		"--timeout", "55",
 		// Cleanup any resources more than 2 hours old
 		"--cleanup-assets-older-than", "2h",
-		"--namespaces", strings.Join(m.Spec.Filtering.Namespaces.Include, ","),
-		"--namespaces-exclude", strings.Join(m.Spec.Filtering.Namespaces.Exclude, ","),
+		"--namespaces", strings.Join(namespaces.Include, ","),
+		"--namespaces-exclude", strings.Join(namespaces.Exclude, ","),
 	}
 
 	if integrationMrn != "" {
@@ -57,7 +58,7 @@ func CronJob(image, integrationMrn, clusterUid string, m v1alpha2.MondooAuditCon
 		containerArgs = append(containerArgs, []string{"--set-managed-by", scannedAssetsManagedBy}...)
 	}
 
-	return &batchv1.CronJob{
+	cronJob := &batchv1.CronJob{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      CronJobName(m.Name),
 			Namespace: m.Namespace,
@@ -66,6 +67,8 @@ func CronJob(image, integrationMrn, clusterUid string, m v1alpha2.MondooAuditCon
 		Spec: batchv1.CronJobSpec{
 			Schedule:          cronTab,
 			ConcurrencyPolicy: batchv1.ForbidConcurrent,
+			// Without any matching namespace there is nothing to scan.
+			Suspend: pointer.Bool(namespaces.NoMatch),
 			JobTemplate: batchv1.JobTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{Labels: ls},
 				Spec: batchv1.JobSpec{
@@ -133,6 +136,22 @@ func CronJob(image, integrationMrn, clusterUid string, m v1alpha2.MondooAuditCon
 			FailedJobsHistoryLimit:     pointer.Int32(1),
 		},
 	}
+
+	// The schedule is validated by the DeploymentHandler before the CronJob is created.
+	if schedule, err := CronJobSchedule(m); err == nil && schedule != "" {
+		k8s.SetCustomSchedule(cronJob, schedule)
+	}
+	return cronJob
+}
+
+// CronJobSchedule returns the Kubernetes resources scanning schedule configured in the MondooAuditConfig with
+// the jitter applied. An empty string is returned if no schedule is configured.
+func CronJobSchedule(m v1alpha2.MondooAuditConfig) (string, error) {
+	if m.Spec.KubernetesResources.Schedule == "" {
+		return "", nil
+	}
+	return k8s.CronScheduleWithJitter(
+		m.Spec.KubernetesResources.Schedule, m.Spec.KubernetesResources.ScheduleJitterMinutes, string(m.UID))
 }
 
 func CronJobLabels(m v1alpha2.MondooAuditConfig) map[string]string {
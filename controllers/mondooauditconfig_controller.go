@@ -27,6 +27,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
@@ -35,6 +36,7 @@ import (
 	"go.mondoo.com/mondoo-operator/controllers/container_image"
 	"go.mondoo.com/mondoo-operator/controllers/k8s_scan"
 	"go.mondoo.com/mondoo-operator/controllers/nodes"
+	"go.mondoo.com/mondoo-operator/controllers/resource_monitor"
 	"go.mondoo.com/mondoo-operator/controllers/resource_monitor/scan_api_store"
 	"go.mondoo.com/mondoo-operator/controllers/scanapi"
 	"go.mondoo.com/mondoo-operator/controllers/status"
@@ -55,6 +57,7 @@ type MondooAuditConfigReconciler struct {
 	StatusReporter         *status.StatusReporter
 	RunningOnOpenShift     bool
 	ScanApiStore           scan_api_store.ScanApiStore
+	ResourceMonitors       *resource_monitor.DynamicResourceMonitors
 }
 
 // so we can mock out the mondoo client for testing
@@ -66,7 +69,7 @@ var MondooClientBuilder = mondooclient.NewClient
 //+kubebuilder:rbac:groups=k8s.mondoo.com,resources=mondooauditconfigs/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=k8s.mondoo.com,resources=mondooauditconfigs/finalizers,verbs=update
 //+kubebuilder:rbac:groups=k8s.mondoo.com,resources=mondoooperatorconfigs,verbs=get;watch;list
-//+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apps,resources=deployments;daemonsets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=apps,resources=deployments;replicasets;daemonsets;statefulsets,verbs=get;list;watch
 //+kubebuilder:rbac:groups=batch,resources=cronjobs,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=batch,resources=cronjobs;jobs,verbs=get;list;watch
@@ -156,6 +159,8 @@ func (r *MondooAuditConfigReconciler) Reconcile(ctx context.Context, req ctrl.Re
 			return result, reconcileError
 		}
 
+		r.ResourceMonitors.SetResources(req.NamespacedName, nil)
+
 		controllerutil.RemoveFinalizer(mondooAuditConfig, finalizerString)
 		if reconcileError = r.Update(ctx, mondooAuditConfig); reconcileError != nil {
 			log.Error(reconcileError, "failed to remove finalizer")
@@ -222,6 +227,9 @@ func (r *MondooAuditConfigReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		ContainerImageResolver: r.ContainerImageResolver,
 		DeployOnOpenShift:      r.RunningOnOpenShift,
 	}
+	if r.ScanApiStore != nil {
+		scanapi.HealthError = r.ScanApiStore.HealthError
+	}
 	result, reconcileError := scanapi.Reconcile(ctx)
 	if reconcileError != nil {
 		log.Error(reconcileError, "Failed to set up scan API")
@@ -245,6 +253,8 @@ func (r *MondooAuditConfigReconciler) Reconcile(ctx context.Context, req ctrl.Re
 	if reconcileError != nil || result.Requeue {
 		return result, reconcileError
 	}
+	// The node scanning DaemonSet is checked again until it is rolled out. The other handlers are not blocked by that.
+	nodesRequeueAfter := result.RequeueAfter
 
 	containers := container_image.DeploymentHandler{
 		Mondoo:                 mondooAuditConfig,
@@ -267,6 +277,7 @@ func (r *MondooAuditConfigReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		MondooOperatorConfig:   config,
 		ContainerImageResolver: r.ContainerImageResolver,
 		ScanApiStore:           r.ScanApiStore,
+		ResourceMonitors:       r.ResourceMonitors,
 	}
 
 	result, reconcileError = workloads.Reconcile(ctx)
@@ -297,6 +308,9 @@ func (r *MondooAuditConfigReconciler) Reconcile(ctx context.Context, req ctrl.Re
 	// This should only happen, after all objects have been reconciled
 	mondooAuditConfig.Status.ReconciledByOperatorVersion = version.Version
 
+	if nodesRequeueAfter > 0 {
+		return ctrl.Result{RequeueAfter: nodesRequeueAfter}, nil
+	}
 	return ctrl.Result{Requeue: true, RequeueAfter: time.Hour * 24 * 7}, nil
 }
 
@@ -321,6 +335,27 @@ func (r *MondooAuditConfigReconciler) nodeEventsRequestMapper(o client.Object) [
 	return requests
 }
 
+// namespaceEventsRequestMapper Maps namespace events to enqueue all MondooAuditConfigs that filter namespaces with a
+// namespace selector for reconciliation. The namespaces matching the selector are passed to the scan CronJobs, so
+// they have to be updated whenever a namespace is added, removed or relabeled.
+func (r *MondooAuditConfigReconciler) namespaceEventsRequestMapper(o client.Object) []reconcile.Request {
+	ctx := context.Background()
+	var requests []reconcile.Request
+	auditConfigs := &v1alpha2.MondooAuditConfigList{}
+	if err := r.Client.List(ctx, auditConfigs); err != nil {
+		logger := ctrllog.Log.WithName("namespace-watcher")
+		logger.Error(err, "Failed to list MondooAuditConfigs")
+		return requests
+	}
+
+	for _, a := range auditConfigs.Items {
+		if a.Spec.Filtering.NamespaceSelector != nil {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&a)})
+		}
+	}
+	return requests
+}
+
 func (r *MondooAuditConfigReconciler) exchangeTokenForServiceAccount(ctx context.Context, auditConfig *v1alpha2.MondooAuditConfig, log logr.Logger) error {
 	if auditConfig.Spec.MondooCredsSecretRef.Name == "" {
 		log.Info("MondooAuditConfig without .spec.mondooCredsSecretRef defined")
@@ -369,15 +404,24 @@ func (r *MondooAuditConfigReconciler) exchangeTokenForServiceAccount(ctx context
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *MondooAuditConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha2.MondooAuditConfig{}).
 		Owns(&batchv1.CronJob{}).
 		Owns(&appsv1.Deployment{}).
+		Owns(&appsv1.DaemonSet{}).
 		Watches(
 			&source.Kind{Type: &corev1.Node{}},
 			handler.EnqueueRequestsFromMapFunc(r.nodeEventsRequestMapper),
 			builder.WithPredicates(k8s.IgnoreGenericEventsPredicate{})).
-		Complete(r)
+		Watches(
+			&source.Kind{Type: &corev1.Namespace{}},
+			handler.EnqueueRequestsFromMapFunc(r.namespaceEventsRequestMapper),
+			builder.WithPredicates(k8s.IgnoreGenericEventsPredicate{}, predicate.LabelChangedPredicate{}))
+	if r.ScanApiStore != nil {
+		// Update the ScanAPIDegraded condition when the scan API becomes reachable or unreachable.
+		b = b.Watches(&source.Channel{Source: r.ScanApiStore.HealthEvents()}, &handler.EnqueueRequestForObject{})
+	}
+	return b.Complete(r)
 }
 
 // labelsForMondoo returns the labels for selecting the resources
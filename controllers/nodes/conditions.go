@@ -9,6 +9,8 @@ file, You can obtain one at https://mozilla.org/MPL/2.0/.
 package nodes
 
 import (
+	"fmt"
+
 	"go.mondoo.com/mondoo-operator/api/v1alpha2"
 	"go.mondoo.com/mondoo-operator/pkg/utils/mondoo"
 
@@ -33,3 +35,17 @@ func updateNodeConditions(config *v1alpha2.MondooAuditConfig, degradedStatus boo
 	config.Status.Conditions = mondoo.SetMondooAuditCondition(
 		config.Status.Conditions, v1alpha2.NodeScanningDegraded, status, reason, msg, updateCheck)
 }
+
+// updateNodeRolloutConditions sets the NodeScanningDegraded condition to unknown while the DaemonSet is rolled out.
+func updateNodeRolloutConditions(config *v1alpha2.MondooAuditConfig) {
+	config.Status.Conditions = mondoo.SetMondooAuditCondition(
+		config.Status.Conditions, v1alpha2.NodeScanningDegraded, corev1.ConditionUnknown, "NodeScanningRollingOut",
+		"Node Scanning is being rolled out", mondoo.UpdateConditionIfReasonOrMessageChange)
+}
+
+func updateNodeScheduleConditions(config *v1alpha2.MondooAuditConfig, err error) {
+	msg := fmt.Sprintf("Node Scanning schedule is invalid: %s", err)
+	config.Status.Conditions = mondoo.SetMondooAuditCondition(
+		config.Status.Conditions, v1alpha2.NodeScanningDegraded, corev1.ConditionTrue, "NodeScanningInvalidSchedule", msg,
+		mondoo.UpdateConditionIfReasonOrMessageChange)
+}
@@ -11,10 +11,12 @@ package nodes
 import (
 	"context"
 	"reflect"
+	"time"
 
 	"go.mondoo.com/mondoo-operator/api/v1alpha2"
 	"go.mondoo.com/mondoo-operator/pkg/utils/k8s"
 	"go.mondoo.com/mondoo-operator/pkg/utils/mondoo"
+	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -25,6 +27,9 @@ import (
 
 var logger = ctrl.Log.WithName("node-scanning")
 
+// daemonSetRolloutRequeueAfter is the time after which the DaemonSet is checked again while it is rolled out.
+const daemonSetRolloutRequeueAfter = 30 * time.Second
+
 type DeploymentHandler struct {
 	KubeClient             client.Client
 	Mondoo                 *v1alpha2.MondooAuditConfig
@@ -38,6 +43,26 @@ func (n *DeploymentHandler) Reconcile(ctx context.Context) (ctrl.Result, error)
 		return ctrl.Result{}, n.down(ctx)
 	}
 
+	if n.Mondoo.Spec.Nodes.Style == v1alpha2.NodeScanStyleDaemonSet {
+		rolledOut, err := n.syncDaemonSet(ctx)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !rolledOut {
+			// The DaemonSet status changes trigger a reconcile as well. Requeuing makes sure the condition does
+			// not stay unknown if one of them is missed.
+			return ctrl.Result{RequeueAfter: daemonSetRolloutRequeueAfter}, nil
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if _, err := CronJobSchedule(*n.Mondoo); err != nil {
+		logger.Error(err, "Invalid node scanning schedule", "namespace", n.Mondoo.Namespace, "name", n.Mondoo.Name)
+		updateNodeScheduleConditions(n.Mondoo, err)
+		// Retrying does not help here. The MondooAuditConfig is reconciled again once the schedule is changed.
+		return ctrl.Result{}, nil
+	}
+
 	if err := n.syncCronJob(ctx); err != nil {
 		return ctrl.Result{}, err
 	}
@@ -71,6 +96,11 @@ func (n *DeploymentHandler) syncCronJob(ctx context.Context) error {
 		return err
 	}
 
+	// The DaemonSet is left over when switching from the daemonset style.
+	if err := n.cleanupDaemonSet(ctx); err != nil {
+		return err
+	}
+
 	// Create/update CronJobs for nodes
 	for _, node := range nodes.Items {
 		updated, err := n.syncConfigMap(ctx, node, clusterUid)
@@ -106,7 +136,14 @@ func (n *DeploymentHandler) syncCronJob(ctx context.Context) error {
 			continue
 		}
 
+		// Without a configured schedule we keep the default one that was picked when the CronJob was created. A
+		// schedule that was configured before is replaced by a new default one.
+		if n.Mondoo.Spec.Nodes.Schedule == "" && !k8s.HasCustomSchedule(*existing) {
+			desired.Spec.Schedule = existing.Spec.Schedule
+		}
+
 		if !k8s.AreCronJobsEqual(*existing, *desired) {
+			k8s.UpdateSchedule(existing, *desired)
 			existing.Spec.JobTemplate = desired.Spec.JobTemplate
 			existing.SetOwnerReferences(desired.GetOwnerReferences())
 
@@ -136,6 +173,125 @@ func (n *DeploymentHandler) syncCronJob(ctx context.Context) error {
 	return nil
 }
 
+// syncDaemonSet syncs the DaemonSet of the "daemonset" node scanning style. The CronJobs and inventory ConfigMaps
+// of the "cronjob" style are deleted. It returns false until the DaemonSet controller has observed the latest
+// version of the DaemonSet.
+func (n *DeploymentHandler) syncDaemonSet(ctx context.Context) (bool, error) {
+	mondooClientImage, err := n.ContainerImageResolver.CnspecImage(
+		n.Mondoo.Spec.Scanner.Image.Name, n.Mondoo.Spec.Scanner.Image.Tag, n.MondooOperatorConfig.Spec.SkipContainerResolution)
+	if err != nil {
+		logger.Error(err, "Failed to resolve mondoo-client container image")
+		return false, err
+	}
+
+	mondooOperatorImage, err := n.ContainerImageResolver.MondooOperatorImage(
+		"", "", n.MondooOperatorConfig.Spec.SkipContainerResolution)
+	if err != nil {
+		logger.Error(err, "Failed to resolve mondoo-operator container image")
+		return false, err
+	}
+
+	clusterUid, err := k8s.GetClusterUID(ctx, n.KubeClient, logger)
+	if err != nil {
+		logger.Error(err, "Failed to get cluster's UID")
+		return false, err
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := n.KubeClient.List(ctx, nodes); err != nil {
+		logger.Error(err, "Failed to list cluster nodes")
+		return false, err
+	}
+
+	if err := n.cleanupCronJobs(ctx, *nodes); err != nil {
+		return false, err
+	}
+
+	if err := n.syncInventoryTemplate(ctx, nodes.Items, clusterUid); err != nil {
+		return false, err
+	}
+
+	existing := &appsv1.DaemonSet{}
+	desired := DaemonSet(mondooClientImage, *n.Mondoo, n.IsOpenshift)
+	if err := ctrl.SetControllerReference(n.Mondoo, desired, n.KubeClient.Scheme()); err != nil {
+		logger.Error(err, "Failed to set ControllerReference", "namespace", desired.Namespace, "name", desired.Name)
+		return false, err
+	}
+
+	created, err := k8s.CreateIfNotExist(ctx, n.KubeClient, existing, desired)
+	if err != nil {
+		logger.Error(err, "Failed to create DaemonSet", "namespace", desired.Namespace, "name", desired.Name)
+		return false, err
+	}
+
+	if created {
+		logger.Info("Created DaemonSet", "namespace", desired.Namespace, "name", desired.Name)
+	} else if !k8s.AreDaemonSetsEqual(*existing, *desired) {
+		k8s.UpdateDaemonSet(existing, *desired)
+		if err := n.KubeClient.Update(ctx, existing); err != nil {
+			logger.Error(err, "Failed to update DaemonSet", "namespace", existing.Namespace, "name", existing.Name)
+			return false, err
+		}
+	}
+
+	if err := n.syncGCCronjob(ctx, mondooOperatorImage, clusterUid); err != nil {
+		return false, err
+	}
+
+	// The status of a DaemonSet that was just created or updated does not tell anything about its pods yet.
+	if created || existing.Status.ObservedGeneration < existing.Generation {
+		updateNodeRolloutConditions(n.Mondoo)
+		return false, nil
+	}
+
+	updateNodeConditions(n.Mondoo, existing.Status.NumberReady < existing.Status.DesiredNumberScheduled)
+	return true, nil
+}
+
+// syncInventoryTemplate syncs the inventory ConfigMap which is shared by the pods of the DaemonSet.
+func (n *DeploymentHandler) syncInventoryTemplate(ctx context.Context, nodes []corev1.Node, clusterUid string) error {
+	integrationMrn, err := k8s.TryGetIntegrationMrnForAuditConfig(ctx, n.KubeClient, *n.Mondoo)
+	if err != nil {
+		logger.Error(err, "failed to retrieve IntegrationMRN")
+		return err
+	}
+
+	desired, err := InventoryTemplateConfigMap(nodes, integrationMrn, clusterUid, *n.Mondoo)
+	if err != nil {
+		logger.Error(err, "failed to generate desired ConfigMap with inventory template")
+		return err
+	}
+
+	if err := ctrl.SetControllerReference(n.Mondoo, desired, n.KubeClient.Scheme()); err != nil {
+		logger.Error(err, "Failed to set ControllerReference", "namespace", desired.Namespace, "name", desired.Name)
+		return err
+	}
+
+	existing := &corev1.ConfigMap{}
+	created, err := k8s.CreateIfNotExist(ctx, n.KubeClient, existing, desired)
+	if err != nil {
+		logger.Error(err, "Failed to create inventory template ConfigMap", "namespace", desired.Namespace, "name", desired.Name)
+		return err
+	}
+
+	if created {
+		logger.Info("Created inventory template ConfigMap", "namespace", desired.Namespace, "name", desired.Name)
+		return nil
+	}
+
+	if !reflect.DeepEqual(existing.Data, desired.Data) ||
+		!reflect.DeepEqual(existing.GetOwnerReferences(), desired.GetOwnerReferences()) {
+		existing.Data = desired.Data
+		existing.SetOwnerReferences(desired.GetOwnerReferences())
+
+		if err := n.KubeClient.Update(ctx, existing); err != nil {
+			logger.Error(err, "Failed to update inventory template ConfigMap", "namespace", existing.Namespace, "name", existing.Name)
+			return err
+		}
+	}
+	return nil
+}
+
 // syncConfigMap syncs the inventory ConfigMap. Returns a boolean indicating whether the ConfigMap has been updated. It
 // can only be "true", if the ConfigMap existed before this reconcile cycle and the inventory was different from the
 // desired state.
@@ -245,6 +401,9 @@ func (n *DeploymentHandler) syncGCCronjob(ctx context.Context, mondooOperatorIma
 		return nil
 	}
 
+	// The garbage collection schedule is picked when the CronJob is created.
+	desired.Spec.Schedule = existing.Spec.Schedule
+
 	if !k8s.AreCronJobsEqual(*existing, *desired) {
 		existing.Spec.JobTemplate = desired.Spec.JobTemplate
 		existing.SetOwnerReferences(desired.GetOwnerReferences())
@@ -277,6 +436,30 @@ func (n *DeploymentHandler) down(ctx context.Context) error {
 		return err
 	}
 
+	if err := n.cleanupCronJobs(ctx, *nodes); err != nil {
+		return err
+	}
+
+	if err := n.cleanupDaemonSet(ctx); err != nil {
+		return err
+	}
+
+	gcCronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Name: GarbageCollectCronJobName(n.Mondoo.Name), Namespace: n.Mondoo.Namespace},
+	}
+	if err := k8s.DeleteIfExists(ctx, n.KubeClient, gcCronJob); err != nil {
+		logger.Error(err, "Failed to clean up node garbage collect CronJob", "namespace", gcCronJob.Namespace, "name", gcCronJob.Name)
+		return err
+	}
+
+	// Update any remnant conditions
+	updateNodeConditions(n.Mondoo, false)
+
+	return nil
+}
+
+// cleanupCronJobs deletes the CronJobs and inventory ConfigMaps of the "cronjob" node scanning style.
+func (n *DeploymentHandler) cleanupCronJobs(ctx context.Context, nodes corev1.NodeList) error {
 	for _, node := range nodes.Items {
 		cronJob := &batchv1.CronJob{
 			ObjectMeta: metav1.ObjectMeta{Name: CronJobName(n.Mondoo.Name, node.Name), Namespace: n.Mondoo.Namespace},
@@ -294,17 +477,25 @@ func (n *DeploymentHandler) down(ctx context.Context) error {
 			return err
 		}
 	}
+	return nil
+}
 
-	gcCronJob := &batchv1.CronJob{
-		ObjectMeta: metav1.ObjectMeta{Name: GarbageCollectCronJobName(n.Mondoo.Name), Namespace: n.Mondoo.Namespace},
+// cleanupDaemonSet deletes the DaemonSet and the inventory template ConfigMap of the "daemonset" node scanning style.
+func (n *DeploymentHandler) cleanupDaemonSet(ctx context.Context) error {
+	daemonSet := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: DaemonSetName(n.Mondoo.Name), Namespace: n.Mondoo.Namespace},
 	}
-	if err := k8s.DeleteIfExists(ctx, n.KubeClient, gcCronJob); err != nil {
-		logger.Error(err, "Failed to clean up node garbage collect CronJob", "namespace", gcCronJob.Namespace, "name", gcCronJob.Name)
+	if err := k8s.DeleteIfExists(ctx, n.KubeClient, daemonSet); err != nil {
+		logger.Error(err, "Failed to clean up node scanning DaemonSet", "namespace", daemonSet.Namespace, "name", daemonSet.Name)
 		return err
 	}
 
-	// Update any remnant conditions
-	updateNodeConditions(n.Mondoo, false)
-
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: InventoryTemplateName(n.Mondoo.Name), Namespace: n.Mondoo.Namespace},
+	}
+	if err := k8s.DeleteIfExists(ctx, n.KubeClient, configMap); err != nil {
+		logger.Error(err, "Failed to clean up inventory template ConfigMap", "namespace", configMap.Namespace, "name", configMap.Name)
+		return err
+	}
 	return nil
 }
@@ -19,9 +19,11 @@ import (
 	mondoov1alpha2 "go.mondoo.com/mondoo-operator/api/v1alpha2"
 	"go.mondoo.com/mondoo-operator/pkg/constants"
 	"go.mondoo.com/mondoo-operator/pkg/mondooclient"
+	"go.mondoo.com/mondoo-operator/pkg/utils/k8s"
 	"go.mondoo.com/mondoo-operator/pkg/utils/mondoo"
 	fakeMondoo "go.mondoo.com/mondoo-operator/pkg/utils/mondoo/fake"
 	"go.mondoo.com/mondoo-operator/tests/framework/utils"
+	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -316,6 +318,43 @@ func (s *DeploymentHandlerSuite) TestReconcile_UpdateCronJobs() {
 	}
 }
 
+func (s *DeploymentHandlerSuite) TestReconcile_RemoveCustomSchedule() {
+	s.seedNodes()
+	s.auditConfig.Spec.Nodes.Schedule = "15 2 * * *"
+	d := s.createDeploymentHandler()
+
+	result, err := d.Reconcile(s.ctx)
+	s.NoError(err)
+	s.True(result.IsZero())
+
+	nodes := &corev1.NodeList{}
+	s.NoError(d.KubeClient.List(s.ctx, nodes))
+
+	for _, n := range nodes.Items {
+		created := &batchv1.CronJob{}
+		created.Name = CronJobName(s.auditConfig.Name, n.Name)
+		created.Namespace = s.auditConfig.Namespace
+		s.NoError(d.KubeClient.Get(s.ctx, client.ObjectKeyFromObject(created), created))
+		s.Equal("15 2 * * *", created.Spec.Schedule)
+		s.True(k8s.HasCustomSchedule(*created))
+	}
+
+	// Removing the configured schedule switches back to an hourly default schedule
+	d.Mondoo.Spec.Nodes.Schedule = ""
+	result, err = d.Reconcile(s.ctx)
+	s.NoError(err)
+	s.True(result.IsZero())
+
+	for _, n := range nodes.Items {
+		created := &batchv1.CronJob{}
+		created.Name = CronJobName(s.auditConfig.Name, n.Name)
+		created.Namespace = s.auditConfig.Namespace
+		s.NoError(d.KubeClient.Get(s.ctx, client.ObjectKeyFromObject(created), created))
+		s.Regexp(`^\d+ \* \* \* \*$`, created.Spec.Schedule)
+		s.False(k8s.HasCustomSchedule(*created))
+	}
+}
+
 func (s *DeploymentHandlerSuite) TestReconcile_CleanCronJobsForDeletedNodes() {
 	s.seedNodes()
 	d := s.createDeploymentHandler()
@@ -462,6 +501,207 @@ func (s *DeploymentHandlerSuite) TestReconcile_DisableNodeScanning() {
 	s.Equal(0, len(cronJobs.Items))
 }
 
+func (s *DeploymentHandlerSuite) TestReconcile_CreateDaemonSet() {
+	s.seedNodes()
+	s.auditConfig.Spec.Nodes.Style = mondoov1alpha2.NodeScanStyleDaemonSet
+	d := s.createDeploymentHandler()
+
+	result, err := d.Reconcile(s.ctx)
+	s.NoError(err)
+	s.Equal(ctrl.Result{RequeueAfter: daemonSetRolloutRequeueAfter}, result)
+
+	nodes := &corev1.NodeList{}
+	s.NoError(d.KubeClient.List(s.ctx, nodes))
+
+	image, err := s.containerImageResolver.CnspecImage(
+		s.auditConfig.Spec.Scanner.Image.Name, s.auditConfig.Spec.Scanner.Image.Tag, false)
+	s.NoError(err)
+
+	expected := DaemonSet(image, s.auditConfig, false)
+	s.NoError(ctrl.SetControllerReference(&s.auditConfig, expected, d.KubeClient.Scheme()))
+
+	// Set some fields that the kube client sets
+	gvk, err := apiutil.GVKForObject(expected, d.KubeClient.Scheme())
+	s.NoError(err)
+	expected.SetGroupVersionKind(gvk)
+	expected.ResourceVersion = "1"
+
+	created := &appsv1.DaemonSet{}
+	s.NoError(d.KubeClient.Get(s.ctx, client.ObjectKeyFromObject(expected), created))
+	s.Equal(expected, created)
+
+	// Verify the pods tolerate the taints of all nodes.
+	s.Equal([]corev1.Toleration{{Operator: corev1.TolerationOpExists}}, created.Spec.Template.Spec.Tolerations)
+
+	// Verify a single inventory is created instead of one per node.
+	configMaps := &corev1.ConfigMapList{}
+	s.NoError(d.KubeClient.List(s.ctx, configMaps))
+	s.Equal(1, len(configMaps.Items))
+	s.Equal(InventoryTemplateName(s.auditConfig.Name), configMaps.Items[0].Name)
+	s.Contains(configMaps.Items[0].Data["inventory"], "name: "+nodeNamePlaceholder)
+
+	cronJobs := &batchv1.CronJobList{}
+	s.NoError(d.KubeClient.List(s.ctx, cronJobs))
+	s.Equal(1, len(cronJobs.Items))
+	s.Equal(GarbageCollectCronJobName(s.auditConfig.Name), cronJobs.Items[0].Name)
+}
+
+func (s *DeploymentHandlerSuite) TestReconcile_DaemonSetConditions() {
+	s.seedNodes()
+	s.auditConfig.Spec.Nodes.Style = mondoov1alpha2.NodeScanStyleDaemonSet
+	d := s.createDeploymentHandler()
+
+	// The status of a new DaemonSet does not tell anything about its pods yet.
+	result, err := d.Reconcile(s.ctx)
+	s.NoError(err)
+	s.Equal(ctrl.Result{RequeueAfter: daemonSetRolloutRequeueAfter}, result)
+
+	condition := mondoo.FindMondooAuditConditions(d.Mondoo.Status.Conditions, mondoov1alpha2.NodeScanningDegraded)
+	s.Equal(corev1.ConditionUnknown, condition.Status)
+	s.Equal("NodeScanningRollingOut", condition.Reason)
+
+	ds := &appsv1.DaemonSet{}
+	s.NoError(d.KubeClient.Get(s.ctx, client.ObjectKey{Name: DaemonSetName(s.auditConfig.Name), Namespace: s.auditConfig.Namespace}, ds))
+
+	// The DaemonSet controller has not observed the latest version yet.
+	ds.Generation = 2
+	ds.Status = appsv1.DaemonSetStatus{ObservedGeneration: 1, DesiredNumberScheduled: 2, NumberReady: 2}
+	s.NoError(d.KubeClient.Update(s.ctx, ds))
+
+	result, err = d.Reconcile(s.ctx)
+	s.NoError(err)
+	s.Equal(ctrl.Result{RequeueAfter: daemonSetRolloutRequeueAfter}, result)
+
+	condition = mondoo.FindMondooAuditConditions(d.Mondoo.Status.Conditions, mondoov1alpha2.NodeScanningDegraded)
+	s.Equal(corev1.ConditionUnknown, condition.Status)
+
+	// Not all pods are ready.
+	ds.Status = appsv1.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 2, NumberReady: 1}
+	s.NoError(d.KubeClient.Update(s.ctx, ds))
+
+	result, err = d.Reconcile(s.ctx)
+	s.NoError(err)
+	s.True(result.IsZero())
+
+	condition = mondoo.FindMondooAuditConditions(d.Mondoo.Status.Conditions, mondoov1alpha2.NodeScanningDegraded)
+	s.Equal(corev1.ConditionTrue, condition.Status)
+
+	ds.Status.NumberReady = 2
+	s.NoError(d.KubeClient.Update(s.ctx, ds))
+
+	result, err = d.Reconcile(s.ctx)
+	s.NoError(err)
+	s.True(result.IsZero())
+
+	condition = mondoo.FindMondooAuditConditions(d.Mondoo.Status.Conditions, mondoov1alpha2.NodeScanningDegraded)
+	s.Equal(corev1.ConditionFalse, condition.Status)
+}
+
+func (s *DeploymentHandlerSuite) TestReconcile_KeepDaemonSetOnNodeChange() {
+	s.seedNodes()
+	s.auditConfig.Spec.Nodes.Style = mondoov1alpha2.NodeScanStyleDaemonSet
+	d := s.createDeploymentHandler()
+
+	_, err := d.Reconcile(s.ctx)
+	s.NoError(err)
+
+	ds := &appsv1.DaemonSet{}
+	s.NoError(d.KubeClient.Get(s.ctx, client.ObjectKey{Name: DaemonSetName(s.auditConfig.Name), Namespace: s.auditConfig.Namespace}, ds))
+	resourceVersion := ds.ResourceVersion
+
+	// Taint the worker node with a new taint and a lifecycle taint of the node lifecycle controller.
+	node := &corev1.Node{}
+	s.NoError(d.KubeClient.Get(s.ctx, client.ObjectKey{Name: "node02"}, node))
+	node.Spec.Taints = []corev1.Taint{
+		{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "node.kubernetes.io/unreachable", Effect: corev1.TaintEffectNoExecute},
+	}
+	s.NoError(d.KubeClient.Update(s.ctx, node))
+
+	// The node event triggers a reconcile of the MondooAuditConfig.
+	_, err = d.Reconcile(s.ctx)
+	s.NoError(err)
+
+	s.NoError(d.KubeClient.Get(s.ctx, client.ObjectKey{Name: DaemonSetName(s.auditConfig.Name), Namespace: s.auditConfig.Namespace}, ds))
+	s.Equal(resourceVersion, ds.ResourceVersion)
+	s.Equal([]corev1.Toleration{{Operator: corev1.TolerationOpExists}}, ds.Spec.Template.Spec.Tolerations)
+}
+
+func (s *DeploymentHandlerSuite) TestReconcile_SwitchStyles() {
+	s.seedNodes()
+	d := s.createDeploymentHandler()
+
+	// Reconcile to create the CronJobs
+	result, err := d.Reconcile(s.ctx)
+	s.NoError(err)
+	s.True(result.IsZero())
+
+	// Verify switching to the daemonset style deletes the CronJobs and inventory ConfigMaps of the nodes.
+	d.Mondoo.Spec.Nodes.Style = mondoov1alpha2.NodeScanStyleDaemonSet
+	result, err = d.Reconcile(s.ctx)
+	s.NoError(err)
+	s.Equal(ctrl.Result{RequeueAfter: daemonSetRolloutRequeueAfter}, result)
+
+	cronJobs := &batchv1.CronJobList{}
+	s.NoError(d.KubeClient.List(s.ctx, cronJobs, client.MatchingLabels(CronJobLabels(s.auditConfig))))
+	s.Equal(0, len(cronJobs.Items))
+
+	configMaps := &corev1.ConfigMapList{}
+	s.NoError(d.KubeClient.List(s.ctx, configMaps))
+	s.Equal(1, len(configMaps.Items))
+
+	daemonSets := &appsv1.DaemonSetList{}
+	s.NoError(d.KubeClient.List(s.ctx, daemonSets))
+	s.Equal(1, len(daemonSets.Items))
+
+	// Verify switching back to the cronjob style deletes the DaemonSet and the inventory template ConfigMap.
+	d.Mondoo.Spec.Nodes.Style = mondoov1alpha2.NodeScanStyleCronJob
+	result, err = d.Reconcile(s.ctx)
+	s.NoError(err)
+	s.True(result.IsZero())
+
+	s.NoError(d.KubeClient.List(s.ctx, daemonSets))
+	s.Equal(0, len(daemonSets.Items))
+
+	s.NoError(d.KubeClient.List(s.ctx, configMaps))
+	s.Equal(2, len(configMaps.Items))
+	for _, cm := range configMaps.Items {
+		s.NotEqual(InventoryTemplateName(s.auditConfig.Name), cm.Name)
+	}
+
+	s.NoError(d.KubeClient.List(s.ctx, cronJobs, client.MatchingLabels(CronJobLabels(s.auditConfig))))
+	s.Equal(2, len(cronJobs.Items))
+}
+
+func (s *DeploymentHandlerSuite) TestReconcile_DisableNodeScanningDaemonSet() {
+	s.seedNodes()
+	s.auditConfig.Spec.Nodes.Style = mondoov1alpha2.NodeScanStyleDaemonSet
+	d := s.createDeploymentHandler()
+
+	// Reconcile to create all resources
+	result, err := d.Reconcile(s.ctx)
+	s.NoError(err)
+	s.Equal(ctrl.Result{RequeueAfter: daemonSetRolloutRequeueAfter}, result)
+
+	// Reconcile again to delete the resources
+	d.Mondoo.Spec.Nodes.Enable = false
+	result, err = d.Reconcile(s.ctx)
+	s.NoError(err)
+	s.True(result.IsZero())
+
+	configMaps := &corev1.ConfigMapList{}
+	s.NoError(d.KubeClient.List(s.ctx, configMaps))
+	s.Equal(0, len(configMaps.Items))
+
+	daemonSets := &appsv1.DaemonSetList{}
+	s.NoError(d.KubeClient.List(s.ctx, daemonSets))
+	s.Equal(0, len(daemonSets.Items))
+
+	cronJobs := &batchv1.CronJobList{}
+	s.NoError(d.KubeClient.List(s.ctx, cronJobs))
+	s.Equal(0, len(cronJobs.Items))
+}
+
 func (s *DeploymentHandlerSuite) createDeploymentHandler() DeploymentHandler {
 	return DeploymentHandler{
 		KubeClient:             s.fakeClientBuilder.Build(),
@@ -12,8 +12,11 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"math"
+	"sort"
+	"strings"
 	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -35,10 +38,21 @@ const (
 	CronJobNameBase               = "-node-"
 	GarbageCollectCronJobNameBase = "-node-gc"
 	InventoryConfigMapBase        = "-node-inventory-"
+	DaemonSetNameBase             = "-node"
+	InventoryTemplateNameBase     = "-node-inventory-template"
+
+	// nodeNamePlaceholder and nodeUIDPlaceholder are replaced with the name and the UID of the node in the inventory
+	// template by the pods of the DaemonSet. The pods don't have access to the Kubernetes API, so the name is taken
+	// from the downward API and the UID is looked up in the node list of the inventory template ConfigMap.
+	nodeNamePlaceholder = "__NODE_NAME__"
+	nodeUIDPlaceholder  = "__NODE_UID__"
 
 	ignoreQueryAnnotationPrefix = "policies.k8s.mondoo.com/"
 
 	ignoreAnnotationValue = "ignore"
+
+	// DefaultIntervalTimer is the default number of minutes between two scans of a node by the DaemonSet.
+	DefaultIntervalTimer = 60
 )
 
 func CronJob(image string, node corev1.Node, m v1alpha2.MondooAuditConfig, isOpenshift bool) *batchv1.CronJob {
@@ -55,7 +69,7 @@ func CronJob(image string, node corev1.Node, m v1alpha2.MondooAuditConfig, isOpe
 		"--score-threshold", "0",
 	}
 
-	return &batchv1.CronJob{
+	cronJob := &batchv1.CronJob{
 		ObjectMeta: metav1.ObjectMeta{
 			Annotations: map[string]string{
 				ignoreQueryAnnotationPrefix + "mondoo-kubernetes-security-cronjob-runasnonroot": ignoreAnnotationValue,
@@ -91,24 +105,11 @@ func CronJob(image string, node corev1.Node, m v1alpha2.MondooAuditConfig, isOpe
 							AutomountServiceAccountToken: pointer.Bool(false),
 							Containers: []corev1.Container{
 								{
-									Image:     image,
-									Name:      name,
-									Command:   cmd,
-									Resources: k8s.ResourcesRequirementsWithDefaults(m.Spec.Nodes.Resources, k8s.DefaultNodeScanningResources),
-									SecurityContext: &corev1.SecurityContext{
-										AllowPrivilegeEscalation: pointer.Bool(isOpenshift),
-										ReadOnlyRootFilesystem:   pointer.Bool(true),
-										RunAsNonRoot:             pointer.Bool(false),
-										RunAsUser:                pointer.Int64(0),
-										Capabilities: &corev1.Capabilities{
-											Drop: []corev1.Capability{
-												"ALL",
-											},
-										},
-										// RHCOS requires to run as privileged to properly do node scanning. If the container
-										// is not privileged, then we have no access to /proc.
-										Privileged: pointer.Bool(isOpenshift),
-									},
+									Image:           image,
+									Name:            name,
+									Command:         cmd,
+									Resources:       k8s.ResourcesRequirementsWithDefaults(m.Spec.Nodes.Resources, k8s.DefaultNodeScanningResources),
+									SecurityContext: securityContext(isOpenshift),
 									VolumeMounts: []corev1.VolumeMount{
 										{
 											Name:      "root",
@@ -187,6 +188,184 @@ func CronJob(image string, node corev1.Node, m v1alpha2.MondooAuditConfig, isOpe
 			FailedJobsHistoryLimit:     pointer.Int32(1),
 		},
 	}
+
+	// The schedule is validated by the DeploymentHandler before the CronJob is created.
+	if schedule, err := CronJobSchedule(m); err == nil && schedule != "" {
+		k8s.SetCustomSchedule(cronJob, schedule)
+	}
+	return cronJob
+}
+
+// DaemonSet returns the DaemonSet of the "daemonset" node scanning style. Its pods run on every node and scan the
+// node every IntervalTimer minutes. Like the Jobs of the CronJobs, the pods tolerate every taint. The tolerations do
+// not depend on the taints of the nodes, such that the DaemonSet is not rolled out again whenever the taints change.
+func DaemonSet(image string, m v1alpha2.MondooAuditConfig, isOpenshift bool) *appsv1.DaemonSet {
+	ls := DaemonSetLabels(m)
+	unsetHostPath := corev1.HostPathUnset
+
+	interval := m.Spec.Nodes.IntervalTimer
+	if interval <= 0 {
+		interval = DefaultIntervalTimer
+	}
+	// The inventory is rendered for the node before every scan. cnspec keeps running, even if a scan fails. A new
+	// node might not be in the node list yet, so the pod waits until the operator added it.
+	script := fmt.Sprintf(`while true; do
+  NODE_UID=""
+  while read -r name uid; do
+    if [ "$name" = "$NODE_NAME" ]; then NODE_UID="$uid"; fi
+  done < /etc/opt/mondoo/nodes
+  if [ -z "$NODE_UID" ]; then
+    echo "Waiting for node ${NODE_NAME} to be added to the node list"
+    sleep 10
+    continue
+  fi
+  sed -e "s/%s/${NODE_NAME}/g" -e "s/%s/${NODE_UID}/g" /etc/opt/mondoo/inventory_template.yml > /tmp/inventory.yml
+  cnspec scan local --config /etc/opt/mondoo/mondoo.yml --inventory-file /tmp/inventory.yml --score-threshold 0
+  sleep %d
+done`, nodeNamePlaceholder, nodeUIDPlaceholder, interval*60)
+
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				ignoreQueryAnnotationPrefix + "mondoo-kubernetes-security-daemonset-runasnonroot": ignoreAnnotationValue,
+			},
+			Name:      DaemonSetName(m.Name),
+			Namespace: m.Namespace,
+			Labels:    ls,
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: ls},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						ignoreQueryAnnotationPrefix + "mondoo-kubernetes-security-pod-runasnonroot": ignoreAnnotationValue,
+					},
+					Labels: ls,
+				},
+				Spec: corev1.PodSpec{
+					Tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+					// The node scanning does not use the Kubernetes API at all, therefore the service account token
+					// should not be mounted at all.
+					AutomountServiceAccountToken: pointer.Bool(false),
+					Containers: []corev1.Container{
+						{
+							Image:           image,
+							Name:            "cnspec",
+							Command:         []string{"/bin/sh", "-c", script},
+							Resources:       k8s.ResourcesRequirementsWithDefaults(m.Spec.Nodes.Resources, k8s.DefaultNodeScanningResources),
+							SecurityContext: securityContext(isOpenshift),
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "root",
+									ReadOnly:  true,
+									MountPath: "/mnt/host/",
+								},
+								{
+									Name:      "config",
+									ReadOnly:  true,
+									MountPath: "/etc/opt/",
+								},
+								{
+									Name:      "temp",
+									MountPath: "/tmp",
+								},
+							},
+							Env: []corev1.EnvVar{
+								{
+									Name:  "DEBUG",
+									Value: "false",
+								},
+								{
+									Name:  "MONDOO_PROCFS",
+									Value: "on",
+								},
+								{
+									Name: "NODE_NAME",
+									ValueFrom: &corev1.EnvVarSource{
+										FieldRef: &corev1.ObjectFieldSelector{FieldPath: "spec.nodeName"},
+									},
+								},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "root",
+							VolumeSource: corev1.VolumeSource{
+								HostPath: &corev1.HostPathVolumeSource{Path: "/", Type: &unsetHostPath},
+							},
+						},
+						{
+							Name: "config",
+							VolumeSource: corev1.VolumeSource{
+								Projected: &corev1.ProjectedVolumeSource{
+									DefaultMode: pointer.Int32(corev1.ProjectedVolumeSourceDefaultMode),
+									Sources: []corev1.VolumeProjection{
+										{
+											ConfigMap: &corev1.ConfigMapProjection{
+												LocalObjectReference: corev1.LocalObjectReference{Name: InventoryTemplateName(m.Name)},
+												Items: []corev1.KeyToPath{
+													{
+														Key:  "inventory",
+														Path: "mondoo/inventory_template.yml",
+													},
+													{
+														Key:  "nodes",
+														Path: "mondoo/nodes",
+													},
+												},
+											},
+										},
+										{
+											Secret: &corev1.SecretProjection{
+												LocalObjectReference: m.Spec.MondooCredsSecretRef,
+												Items: []corev1.KeyToPath{{
+													Key:  "config",
+													Path: "mondoo/mondoo.yml",
+												}},
+											},
+										},
+									},
+								},
+							},
+						},
+						{
+							Name: "temp",
+							VolumeSource: corev1.VolumeSource{
+								EmptyDir: &corev1.EmptyDirVolumeSource{},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func securityContext(isOpenshift bool) *corev1.SecurityContext {
+	return &corev1.SecurityContext{
+		AllowPrivilegeEscalation: pointer.Bool(isOpenshift),
+		ReadOnlyRootFilesystem:   pointer.Bool(true),
+		RunAsNonRoot:             pointer.Bool(false),
+		RunAsUser:                pointer.Int64(0),
+		Capabilities: &corev1.Capabilities{
+			Drop: []corev1.Capability{
+				"ALL",
+			},
+		},
+		// RHCOS requires to run as privileged to properly do node scanning. If the container
+		// is not privileged, then we have no access to /proc.
+		Privileged: pointer.Bool(isOpenshift),
+	}
+}
+
+// CronJobSchedule returns the node scanning schedule configured in the MondooAuditConfig with the jitter
+// applied. An empty string is returned if no schedule is configured.
+func CronJobSchedule(m v1alpha2.MondooAuditConfig) (string, error) {
+	if m.Spec.Nodes.Schedule == "" {
+		return "", nil
+	}
+	return k8s.CronScheduleWithJitter(m.Spec.Nodes.Schedule, m.Spec.Nodes.ScheduleJitterMinutes, string(m.UID))
 }
 
 func GarbageCollectCronJob(image, clusterUid string, m v1alpha2.MondooAuditConfig) *batchv1.CronJob {
@@ -308,6 +487,35 @@ func ConfigMap(node corev1.Node, integrationMRN, clusterUID string, m v1alpha2.M
 	}, nil
 }
 
+// InventoryTemplateConfigMap returns the ConfigMap with the inventory of the "daemonset" node scanning style. The
+// inventory is shared by all nodes, so it contains placeholders for the name and the UID of the node. The UIDs are
+// listed in the "nodes" key, one "<name> <uid>" line per node, so both styles report the same platform ID for a node.
+func InventoryTemplateConfigMap(
+	nodes []corev1.Node, integrationMRN, clusterUID string, m v1alpha2.MondooAuditConfig,
+) (*corev1.ConfigMap, error) {
+	inv, err := inventory(nodeNamePlaceholder, nodePlatformId(clusterUID, nodeUIDPlaceholder), integrationMRN, clusterUID)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeList := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		nodeList = append(nodeList, fmt.Sprintf("%s %s\n", node.Name, node.UID))
+	}
+	sort.Strings(nodeList)
+
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: m.Namespace,
+			Name:      InventoryTemplateName(m.Name),
+		},
+		Data: map[string]string{
+			"inventory": inv,
+			"nodes":     strings.Join(nodeList, ""),
+		},
+	}, nil
+}
+
 func CronJobName(prefix, suffix string) string {
 	// If the name becomes longer than 52 chars, then we hash the suffix and trim
 	// it such that the full name fits within 52 chars. This is needed because in
@@ -325,7 +533,23 @@ func ConfigMapName(prefix, nodeName string) string {
 	return fmt.Sprintf("%s%s", base, NodeNameOrHash(k8s.ResourceNameMaxLength-len(base), nodeName))
 }
 
+func DaemonSetName(prefix string) string {
+	return fmt.Sprintf("%s%s", prefix, DaemonSetNameBase)
+}
+
+func InventoryTemplateName(prefix string) string {
+	return fmt.Sprintf("%s%s", prefix, InventoryTemplateNameBase)
+}
+
 func Inventory(node corev1.Node, integrationMRN, clusterUID string, m v1alpha2.MondooAuditConfig) (string, error) {
+	return inventory(node.Name, nodePlatformId(clusterUID, string(node.UID)), integrationMRN, clusterUID)
+}
+
+func nodePlatformId(clusterUID, nodeUID string) string {
+	return fmt.Sprintf("//platformid.api.mondoo.app/runtime/k8s/uid/%s/node/%s", clusterUID, nodeUID)
+}
+
+func inventory(nodeName, platformId, integrationMRN, clusterUID string) (string, error) {
 	inv := &v1.Inventory{
 		Metadata: &v1.ObjectMeta{
 			Name: "mondoo-node-inventory",
@@ -337,12 +561,12 @@ func Inventory(node corev1.Node, integrationMRN, clusterUID string, m v1alpha2.M
 			Assets: []*asset.Asset{
 				{
 					Id:   "host",
-					Name: node.Name,
+					Name: nodeName,
 					Connections: []*providers.Config{
 						{
 							Host:       "/mnt/host",
 							Backend:    providers.ProviderType_FS,
-							PlatformId: fmt.Sprintf("//platformid.api.mondoo.app/runtime/k8s/uid/%s/node/%s", clusterUID, node.UID),
+							PlatformId: platformId,
 						},
 					},
 					Labels: map[string]string{
@@ -376,6 +600,14 @@ func CronJobLabels(m v1alpha2.MondooAuditConfig) map[string]string {
 	}
 }
 
+func DaemonSetLabels(m v1alpha2.MondooAuditConfig) map[string]string {
+	return map[string]string{
+		"app":       "mondoo",
+		"scan":      "nodes-daemonset",
+		"mondoo_cr": m.Name,
+	}
+}
+
 func GarbageCollectCronJobLabels(m v1alpha2.MondooAuditConfig) map[string]string {
 	return map[string]string{
 		"app":       "mondoo",
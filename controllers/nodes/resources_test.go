@@ -12,6 +12,7 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"math/rand"
+	"strings"
 	"testing"
 	"time"
 
@@ -193,6 +194,58 @@ func TestCronJob_Privileged(t *testing.T) {
 	assert.False(t, *cronJobSepc.Spec.JobTemplate.Spec.Template.Spec.Containers[0].SecurityContext.AllowPrivilegeEscalation)
 }
 
+func TestDaemonSet_Tolerations(t *testing.T) {
+	ds := DaemonSet("test123", *testMondooAuditConfig(), false)
+	assert.Equal(t, []corev1.Toleration{{Operator: corev1.TolerationOpExists}}, ds.Spec.Template.Spec.Tolerations)
+}
+
+func TestDaemonSet_IntervalTimer(t *testing.T) {
+	auditConfig := testMondooAuditConfig()
+	ds := DaemonSet("test123", *auditConfig, false)
+	assert.Contains(t, ds.Spec.Template.Spec.Containers[0].Command[2], fmt.Sprintf("sleep %d", DefaultIntervalTimer*60))
+
+	auditConfig.Spec.Nodes.IntervalTimer = 10
+	ds = DaemonSet("test123", *auditConfig, false)
+	assert.Contains(t, ds.Spec.Template.Spec.Containers[0].Command[2], "sleep 600")
+}
+
+func TestInventoryTemplateConfigMap(t *testing.T) {
+	auditConfig := testMondooAuditConfig()
+	nodes := []corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node02", UID: "uid-2"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node01", UID: "uid-1"}},
+	}
+	cm, err := InventoryTemplateConfigMap(nodes, "", testClusterUID, *auditConfig)
+	assert.NoError(t, err, "unexpected error generating inventory template")
+	assert.Equal(t, InventoryTemplateName(auditConfig.Name), cm.Name)
+	assert.Contains(t, cm.Data["inventory"], "name: "+nodeNamePlaceholder)
+	assert.Contains(t, cm.Data["inventory"], fmt.Sprintf("/k8s/uid/%s/node/%s", testClusterUID, nodeUIDPlaceholder))
+	assert.Equal(t, "node01 uid-1\nnode02 uid-2\n", cm.Data["nodes"])
+}
+
+func TestInventoryTemplateConfigMap_SamePlatformIdAsCronJob(t *testing.T) {
+	const integrationMRN = "//test-MRN"
+	auditConfig := testMondooAuditConfig()
+	node := corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node01", UID: "3e7f5c2a-uid"}}
+
+	cm, err := InventoryTemplateConfigMap([]corev1.Node{node}, integrationMRN, testClusterUID, *auditConfig)
+	assert.NoError(t, err, "unexpected error generating inventory template")
+
+	// Render the template the way the pods of the DaemonSet do.
+	var nodeUID string
+	for _, line := range strings.Split(cm.Data["nodes"], "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 && fields[0] == node.Name {
+			nodeUID = fields[1]
+		}
+	}
+	rendered := strings.NewReplacer(nodeNamePlaceholder, node.Name, nodeUIDPlaceholder, nodeUID).Replace(cm.Data["inventory"])
+
+	inventory, err := Inventory(node, integrationMRN, testClusterUID, *auditConfig)
+	assert.NoError(t, err, "unexpected error generating inventory")
+	assert.Equal(t, inventory, rendered)
+	assert.Contains(t, rendered, fmt.Sprintf("/k8s/uid/%s/node/%s", testClusterUID, node.UID))
+}
+
 func TestInventory(t *testing.T) {
 	randName := utils.RandString(10)
 	auditConfig := v1alpha2.MondooAuditConfig{ObjectMeta: metav1.ObjectMeta{Name: "mondoo-client"}}
@@ -0,0 +1,175 @@
+/*
+Copyright 2022 Mondoo, Inc.
+
+This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at https://mozilla.org/MPL/2.0/.
+*/
+
+package operator_webhook
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	caCertKey = "ca.crt"
+	caKeyKey  = "ca.key"
+
+	caValidity      = 10 * 365 * 24 * time.Hour
+	servingValidity = 365 * 24 * time.Hour
+	// renewBefore is the time before the expiry at which a certificate is replaced.
+	renewBefore = 30 * 24 * time.Hour
+)
+
+// renewCertificates makes sure the Secret holds a valid CA and a valid serving certificate for the provided DNS
+// names. The serving certificate is reissued with the existing CA whenever possible, so the CA bundle configured
+// for the API server stays the same. Returns true if the Secret data was changed.
+func renewCertificates(secret *corev1.Secret, dnsNames []string, now time.Time) (bool, error) {
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+
+	changed := false
+	if !isCertificateValid(secret.Data[caCertKey], nil, now) || len(secret.Data[caKeyKey]) == 0 {
+		caCert, caKey, err := newCA(now)
+		if err != nil {
+			return false, err
+		}
+		secret.Data[caCertKey] = caCert
+		secret.Data[caKeyKey] = caKey
+		changed = true
+	}
+
+	if changed || !isCertificateValid(secret.Data[corev1.TLSCertKey], dnsNames, now) {
+		cert, key, err := newServingCertificate(secret.Data[caCertKey], secret.Data[caKeyKey], dnsNames, now)
+		if err != nil {
+			return false, err
+		}
+		secret.Data[corev1.TLSCertKey] = cert
+		secret.Data[corev1.TLSPrivateKeyKey] = key
+		changed = true
+	}
+	return changed, nil
+}
+
+// isCertificateValid checks whether the PEM encoded certificate is valid for all provided DNS names and
+// does not have to be renewed yet.
+func isCertificateValid(certPEM []byte, dnsNames []string, now time.Time) bool {
+	cert, err := parseCertificate(certPEM)
+	if err != nil {
+		return false
+	}
+	if now.Before(cert.NotBefore) || now.Add(renewBefore).After(cert.NotAfter) {
+		return false
+	}
+	for _, name := range dnsNames {
+		if cert.VerifyHostname(name) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func newCA(now time.Time) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate private key for CA: %w", err)
+	}
+
+	template, err := certificateTemplate(now, caValidity)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.Subject = pkix.Name{CommonName: "mondoo-operator-webhook-ca", Organization: []string{"mondoo.com"}}
+	template.IsCA = true
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign
+	template.BasicConstraintsValid = true
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create self-signed certificate for CA: %w", err)
+	}
+	return encodeCertificate(der, key)
+}
+
+func newServingCertificate(caCertPEM, caKeyPEM []byte, dnsNames []string, now time.Time) ([]byte, []byte, error) {
+	caCert, err := parseCertificate(caCertPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	caKey, err := parsePrivateKey(caKeyPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate private key for the serving certificate: %w", err)
+	}
+
+	template, err := certificateTemplate(now, servingValidity)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.Subject = pkix.Name{CommonName: dnsNames[0], Organization: []string{"mondoo.com"}}
+	template.DNSNames = dnsNames
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to sign the serving certificate: %w", err)
+	}
+	return encodeCertificate(der, key)
+}
+
+func certificateTemplate(now time.Time, validity time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate certificate serial number: %w", err)
+	}
+	return &x509.Certificate{
+		SerialNumber: serial,
+		// Allow for some clock skew between the operator and the API server.
+		NotBefore: now.Add(-time.Hour),
+		NotAfter:  now.Add(validity),
+	}, nil
+}
+
+func encodeCertificate(der []byte, key *ecdsa.PrivateKey) ([]byte, []byte, error) {
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode private key: %w", err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	return certPEM, keyPEM, nil
+}
+
+func parseCertificate(certPEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("failed to decode PEM certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func parsePrivateKey(keyPEM []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil || block.Type != "EC PRIVATE KEY" {
+		return nil, errors.New("failed to decode PEM private key")
+	}
+	return x509.ParseECPrivateKey(block.Bytes)
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2022 Mondoo, Inc.
+
+This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at https://mozilla.org/MPL/2.0/.
+*/
+
+package operator_webhook
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var testDnsNames = []string{"mondoo-operator-webhook-service.mondoo-operator.svc"}
+
+func TestRenewCertificates_NewSecret(t *testing.T) {
+	now := time.Now()
+	secret := &corev1.Secret{}
+
+	changed, err := renewCertificates(secret, testDnsNames, now)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	for _, key := range []string{caCertKey, caKeyKey, corev1.TLSCertKey, corev1.TLSPrivateKeyKey} {
+		assert.NotEmpty(t, secret.Data[key], key)
+	}
+	assert.True(t, isCertificateValid(secret.Data[corev1.TLSCertKey], testDnsNames, now))
+
+	// The serving certificate must be trusted by the CA which is injected into the CRDs.
+	ca, err := parseCertificate(secret.Data[caCertKey])
+	require.NoError(t, err)
+	cert, err := parseCertificate(secret.Data[corev1.TLSCertKey])
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	_, err = cert.Verify(x509.VerifyOptions{
+		DNSName:     testDnsNames[0],
+		Roots:       roots,
+		CurrentTime: now,
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	assert.NoError(t, err)
+}
+
+func TestRenewCertificates_ValidSecret(t *testing.T) {
+	now := time.Now()
+	secret := &corev1.Secret{}
+	_, err := renewCertificates(secret, testDnsNames, now)
+	require.NoError(t, err)
+	expected := secret.DeepCopy()
+
+	changed, err := renewCertificates(secret, testDnsNames, now.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, expected, secret)
+}
+
+func TestRenewCertificates_ReissueServingCertificate(t *testing.T) {
+	now := time.Now()
+	secret := &corev1.Secret{}
+	_, err := renewCertificates(secret, testDnsNames, now)
+	require.NoError(t, err)
+	expected := secret.DeepCopy()
+
+	tests := []struct {
+		name     string
+		dnsNames []string
+		now      time.Time
+	}{
+		{name: "new DNS name", dnsNames: append(testDnsNames, "webhook.mondoo-operator.svc"), now: now},
+		{name: "expiring certificate", dnsNames: testDnsNames, now: now.Add(servingValidity - renewBefore + time.Hour)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			secret := expected.DeepCopy()
+			changed, err := renewCertificates(secret, test.dnsNames, test.now)
+			require.NoError(t, err)
+			assert.True(t, changed)
+
+			// The CA is kept, so the CA bundle of the CRDs does not have to change.
+			assert.Equal(t, expected.Data[caCertKey], secret.Data[caCertKey])
+			assert.Equal(t, expected.Data[caKeyKey], secret.Data[caKeyKey])
+			assert.NotEqual(t, expected.Data[corev1.TLSCertKey], secret.Data[corev1.TLSCertKey])
+			assert.True(t, isCertificateValid(secret.Data[corev1.TLSCertKey], test.dnsNames, test.now))
+		})
+	}
+}
+
+func TestRenewCertificates_ExpiringCA(t *testing.T) {
+	now := time.Now()
+	secret := &corev1.Secret{}
+	_, err := renewCertificates(secret, testDnsNames, now)
+	require.NoError(t, err)
+	expected := secret.DeepCopy()
+
+	later := now.Add(caValidity - renewBefore + time.Hour)
+	changed, err := renewCertificates(secret, testDnsNames, later)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.NotEqual(t, expected.Data[caCertKey], secret.Data[caCertKey])
+	assert.True(t, isCertificateValid(secret.Data[corev1.TLSCertKey], testDnsNames, later))
+}
+
+func TestIsCertificateValid_Invalid(t *testing.T) {
+	assert.False(t, isCertificateValid(nil, nil, time.Now()))
+	assert.False(t, isCertificateValid([]byte("not a certificate"), nil, time.Now()))
+}
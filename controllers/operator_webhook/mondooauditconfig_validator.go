@@ -0,0 +1,298 @@
+/*
+Copyright 2022 Mondoo, Inc.
+
+This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at https://mozilla.org/MPL/2.0/.
+*/
+
+package operator_webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/gobwas/glob"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"go.mondoo.com/mondoo-operator/api/v1alpha2"
+	"go.mondoo.com/mondoo-operator/controllers/container_image"
+	"go.mondoo.com/mondoo-operator/controllers/k8s_scan"
+	"go.mondoo.com/mondoo-operator/controllers/nodes"
+	"go.mondoo.com/mondoo-operator/pkg/utils/k8s"
+)
+
+const validateMondooAuditConfigPath = "/validate-k8s-mondoo-com-v1alpha2-mondooauditconfig"
+
+//+kubebuilder:webhook:path=/validate-k8s-mondoo-com-v1alpha2-mondooauditconfig,mutating=false,failurePolicy=ignore,sideEffects=None,groups=k8s.mondoo.com,resources=mondooauditconfigs,verbs=create;update,versions=v1alpha2,name=vmondooauditconfig.k8s.mondoo.com,admissionReviewVersions=v1
+
+// mondooAuditConfigValidator rejects MondooAuditConfigs which the operator cannot reconcile and warns about
+// configurations which are valid, but are likely to cause problems.
+type mondooAuditConfigValidator struct {
+	client  client.Client
+	decoder *admission.Decoder
+}
+
+var _ admission.Handler = &mondooAuditConfigValidator{}
+
+func (v *mondooAuditConfigValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	auditConfig := &v1alpha2.MondooAuditConfig{}
+	if err := v.decoder.Decode(req, auditConfig); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// Never block the cleanup of a MondooAuditConfig that is being deleted.
+	if !auditConfig.DeletionTimestamp.IsZero() {
+		return admission.Allowed("")
+	}
+
+	warnings, errs, err := v.validate(ctx, auditConfig)
+	if err != nil {
+		logger.Error(err, "failed to validate MondooAuditConfig", "namespace", req.Namespace, "name", req.Name)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	if len(errs) > 0 && req.Operation == admissionv1.Update {
+		// MondooAuditConfigs created before the webhook was deployed might already be invalid. Updates which
+		// don't touch the spec (e.g. adding a finalizer) must still be possible.
+		old := &v1alpha2.MondooAuditConfig{}
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if reflect.DeepEqual(old.Spec, auditConfig.Spec) {
+			for _, e := range errs {
+				warnings = append(warnings, e.Error())
+			}
+			errs = nil
+		}
+	}
+
+	if len(errs) > 0 {
+		return admission.Denied(errs.ToAggregate().Error()).WithWarnings(warnings...)
+	}
+	return admission.Allowed("").WithWarnings(warnings...)
+}
+
+// InjectDecoder implements admission.DecoderInjector.
+func (v *mondooAuditConfigValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// validate returns the warnings and the validation errors for the MondooAuditConfig. An error is only returned
+// if the validation itself failed.
+func (v *mondooAuditConfigValidator) validate(ctx context.Context, m *v1alpha2.MondooAuditConfig) ([]string, field.ErrorList, error) {
+	var warnings []string
+	specPath := field.NewPath("spec")
+	errs := validateFiltering(m.Spec.Filtering, specPath.Child("filtering"))
+	errs = append(errs, validateSchedules(*m, specPath)...)
+	errs = append(errs, validateMonitoredResources(m.Spec.KubernetesResources.MonitoredResources,
+		specPath.Child("kubernetesResources", "monitoredResources"))...)
+	errs = append(errs, validateNodes(m.Spec.Nodes, specPath.Child("nodes"))...)
+	errs = append(errs, validateDecisionLog(m.Spec.Admission.DecisionLog, specPath.Child("admission", "decisionLog"))...)
+
+	if m.Spec.Nodes.Enable {
+		auditConfigs := &v1alpha2.MondooAuditConfigList{}
+		if err := v.client.List(ctx, auditConfigs, client.InNamespace(m.Namespace)); err != nil {
+			return nil, nil, err
+		}
+		for _, other := range auditConfigs.Items {
+			if other.Name != m.Name && other.Spec.Nodes.Enable && other.DeletionTimestamp.IsZero() {
+				errs = append(errs, field.Forbidden(specPath.Child("nodes", "enable"),
+					fmt.Sprintf("node scanning is already enabled by MondooAuditConfig %q in the same namespace", other.Name)))
+			}
+		}
+	}
+
+	if m.Spec.Admission.Enable && m.Spec.Admission.Mode == v1alpha2.Enforcing {
+		certMode := m.Spec.Admission.CertificateProvisioning.Mode
+		if certMode == "" || certMode == v1alpha2.ManualProvisioning {
+			warnings = append(warnings, "spec.admission.mode is 'enforcing', but the webhook certificate is provisioned manually. "+
+				"Admission requests are rejected until the certificate and the CA bundle are in place.")
+		}
+		if m.Spec.Admission.Replicas != nil && *m.Spec.Admission.Replicas < 2 {
+			warnings = append(warnings, "spec.admission.mode is 'enforcing', but spec.admission.replicas is less than 2. "+
+				"Admission requests are rejected while the webhook is unavailable.")
+		}
+		if m.Spec.Scanner.Replicas != nil && *m.Spec.Scanner.Replicas < 2 {
+			warnings = append(warnings, "spec.admission.mode is 'enforcing', but spec.scanner.replicas is less than 2. "+
+				"Admission requests are rejected while the scanner is unavailable.")
+		}
+	}
+
+	if len(m.Spec.Filtering.Namespaces.Include) > 0 && len(m.Spec.Filtering.Namespaces.Exclude) > 0 {
+		warnings = append(warnings, "spec.filtering.namespaces.exclude is ignored, because spec.filtering.namespaces.include is set.")
+	}
+
+	if m.Spec.Nodes.Style == v1alpha2.NodeScanStyleDaemonSet && m.Spec.Nodes.Schedule != "" {
+		warnings = append(warnings, "spec.nodes.schedule is ignored, because spec.nodes.style is 'daemonset'. "+
+			"The nodes are scanned every spec.nodes.intervalTimer minutes.")
+	}
+
+	credsWarning, err := v.validateCredentials(ctx, m)
+	if err != nil {
+		return nil, nil, err
+	}
+	if credsWarning != "" {
+		warnings = append(warnings, credsWarning)
+	}
+	return warnings, errs, nil
+}
+
+// validateCredentials returns a warning if neither the Mondoo credentials nor a token to create them exist.
+func (v *mondooAuditConfigValidator) validateCredentials(ctx context.Context, m *v1alpha2.MondooAuditConfig) (string, error) {
+	if m.Spec.MondooCredsSecretRef.Name == "" && m.Spec.MondooTokenSecretRef.Name == "" {
+		return "No Mondoo credentials configured: neither spec.mondooCredsSecretRef nor spec.mondooTokenSecretRef is set. " +
+			"The scans cannot be reported to Mondoo.", nil
+	}
+
+	refs := []struct {
+		path string
+		ref  corev1.LocalObjectReference
+	}{
+		{path: "spec.mondooCredsSecretRef", ref: m.Spec.MondooCredsSecretRef},
+		{path: "spec.mondooTokenSecretRef", ref: m.Spec.MondooTokenSecretRef},
+	}
+	var missing []string
+	for _, r := range refs {
+		if r.ref.Name == "" {
+			continue
+		}
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: r.ref.Name, Namespace: m.Namespace}}
+		exists, err := k8s.CheckIfExists(ctx, v.client, secret, secret)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return "", nil
+		}
+		missing = append(missing, fmt.Sprintf("Secret %q referenced by %s", r.ref.Name, r.path))
+	}
+	if len(missing) == 1 {
+		return fmt.Sprintf("%s does not exist in namespace %q. The scans cannot be reported to Mondoo until it is created.",
+			missing[0], m.Namespace), nil
+	}
+	return fmt.Sprintf("%s do not exist in namespace %q. The scans cannot be reported to Mondoo until one of them is created.",
+		strings.Join(missing, " and "), m.Namespace), nil
+}
+
+func validateFiltering(f v1alpha2.Filtering, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if _, err := k8s.NamespaceSelector(f); err != nil {
+		errs = append(errs, field.Invalid(path.Child("namespaceSelector"), f.NamespaceSelector, err.Error()))
+	}
+	namespacesPath := path.Child("namespaces")
+	for i, pattern := range f.Namespaces.Include {
+		if _, err := glob.Compile(pattern); err != nil {
+			errs = append(errs, field.Invalid(namespacesPath.Child("include").Index(i), pattern, err.Error()))
+		}
+	}
+	for i, pattern := range f.Namespaces.Exclude {
+		if _, err := glob.Compile(pattern); err != nil {
+			errs = append(errs, field.Invalid(namespacesPath.Child("exclude").Index(i), pattern, err.Error()))
+		}
+	}
+	return errs
+}
+
+func validateMonitoredResources(resources []v1alpha2.MonitoredResource, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	seen := make(map[v1alpha2.MonitoredResource]struct{})
+	for i, r := range resources {
+		p := path.Index(i)
+		if r.Group != "" {
+			for _, msg := range validation.IsDNS1123Subdomain(r.Group) {
+				errs = append(errs, field.Invalid(p.Child("group"), r.Group, msg))
+			}
+		}
+		if r.Version == "" {
+			errs = append(errs, field.Required(p.Child("version"), ""))
+		} else {
+			for _, msg := range validation.IsDNS1035Label(r.Version) {
+				errs = append(errs, field.Invalid(p.Child("version"), r.Version, msg))
+			}
+		}
+		if r.Kind == "" {
+			errs = append(errs, field.Required(p.Child("kind"), ""))
+		} else if strings.ContainsAny(r.Kind, "./ ") {
+			errs = append(errs, field.Invalid(p.Child("kind"), r.Kind, "must be the kind, e.g. Rollout, not the resource"))
+		}
+
+		if _, ok := seen[r]; ok {
+			errs = append(errs, field.Duplicate(p, r))
+		}
+		seen[r] = struct{}{}
+	}
+	return errs
+}
+
+func validateNodes(n v1alpha2.Nodes, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	switch n.Style {
+	case "", v1alpha2.NodeScanStyleCronJob, v1alpha2.NodeScanStyleDaemonSet:
+	default:
+		errs = append(errs, field.NotSupported(path.Child("style"), n.Style,
+			[]string{string(v1alpha2.NodeScanStyleCronJob), string(v1alpha2.NodeScanStyleDaemonSet)}))
+	}
+	// 0 means the default interval.
+	if n.IntervalTimer < 0 {
+		errs = append(errs, field.Invalid(path.Child("intervalTimer"), n.IntervalTimer, "must be at least 1"))
+	}
+	return errs
+}
+
+func validateDecisionLog(d v1alpha2.AdmissionDecisionLog, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	filePath := path.Child("file")
+	if d.File.MaxSizeMB < 0 {
+		errs = append(errs, field.Invalid(filePath.Child("maxSizeMB"), d.File.MaxSizeMB, "must be at least 1"))
+	}
+	if d.File.MaxBackups != nil && *d.File.MaxBackups < 0 {
+		errs = append(errs, field.Invalid(filePath.Child("maxBackups"), *d.File.MaxBackups, "must not be negative"))
+	}
+	if d.File.VolumeClaimName != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(d.File.VolumeClaimName) {
+			errs = append(errs, field.Invalid(filePath.Child("volumeClaimName"), d.File.VolumeClaimName, msg))
+		}
+	}
+
+	httpPath := path.Child("http")
+	if d.HTTP.Endpoint != "" {
+		if u, err := url.Parse(d.HTTP.Endpoint); err != nil {
+			errs = append(errs, field.Invalid(httpPath.Child("endpoint"), d.HTTP.Endpoint, err.Error()))
+		} else if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, field.Invalid(httpPath.Child("endpoint"), d.HTTP.Endpoint, "must be an http or https URL"))
+		}
+	}
+	if d.HTTP.BatchSize < 0 {
+		errs = append(errs, field.Invalid(httpPath.Child("batchSize"), d.HTTP.BatchSize, "must be at least 1"))
+	}
+	if d.HTTP.MaxRetries != nil && *d.HTTP.MaxRetries < 0 {
+		errs = append(errs, field.Invalid(httpPath.Child("maxRetries"), *d.HTTP.MaxRetries, "must not be negative"))
+	}
+	return errs
+}
+
+func validateSchedules(m v1alpha2.MondooAuditConfig, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if _, err := k8s_scan.CronJobSchedule(m); err != nil {
+		errs = append(errs, field.Invalid(path.Child("kubernetesResources", "schedule"), m.Spec.KubernetesResources.Schedule, err.Error()))
+	}
+	if _, err := nodes.CronJobSchedule(m); err != nil {
+		errs = append(errs, field.Invalid(path.Child("nodes", "schedule"), m.Spec.Nodes.Schedule, err.Error()))
+	}
+	if _, err := container_image.CronJobSchedule(m); err != nil {
+		errs = append(errs, field.Invalid(path.Child("containers", "schedule"), m.Spec.Containers.Schedule, err.Error()))
+	}
+	return errs
+}
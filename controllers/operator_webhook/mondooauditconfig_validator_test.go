@@ -0,0 +1,369 @@
+/*
+Copyright 2022 Mondoo, Inc.
+
+This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at https://mozilla.org/MPL/2.0/.
+*/
+
+package operator_webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"go.mondoo.com/mondoo-operator/api/v1alpha2"
+)
+
+const testNamespace = "mondoo-operator"
+
+func TestMondooAuditConfigValidator(t *testing.T) {
+	credsSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "mondoo-client", Namespace: testNamespace}}
+
+	tests := []struct {
+		name            string
+		auditConfig     func(*v1alpha2.MondooAuditConfig)
+		existing        []client.Object
+		expectAllowed   bool
+		expectWarnings  int
+		expectInMessage string
+		expectInWarning string
+	}{
+		{
+			name:          "valid",
+			existing:      []client.Object{credsSecret},
+			expectAllowed: true,
+		},
+		{
+			name: "invalid include glob",
+			auditConfig: func(m *v1alpha2.MondooAuditConfig) {
+				m.Spec.Filtering.Namespaces.Include = []string{"kube-*", "[abc"}
+			},
+			existing:        []client.Object{credsSecret},
+			expectInMessage: "spec.filtering.namespaces.include[1]",
+		},
+		{
+			name: "invalid exclude glob",
+			auditConfig: func(m *v1alpha2.MondooAuditConfig) {
+				m.Spec.Filtering.Namespaces.Exclude = []string{"[abc"}
+			},
+			existing:        []client.Object{credsSecret},
+			expectInMessage: "spec.filtering.namespaces.exclude[0]",
+		},
+		{
+			name: "invalid namespace selector",
+			auditConfig: func(m *v1alpha2.MondooAuditConfig) {
+				m.Spec.Filtering.NamespaceSelector = &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "team", Operator: "Equals"}},
+				}
+			},
+			existing:        []client.Object{credsSecret},
+			expectInMessage: "spec.filtering.namespaceSelector",
+		},
+		{
+			name: "invalid schedule",
+			auditConfig: func(m *v1alpha2.MondooAuditConfig) {
+				m.Spec.Nodes.Schedule = "every hour"
+			},
+			existing:        []client.Object{credsSecret},
+			expectInMessage: "spec.nodes.schedule",
+		},
+		{
+			name: "node scanning enabled twice in the same namespace",
+			auditConfig: func(m *v1alpha2.MondooAuditConfig) {
+				m.Spec.Nodes.Enable = true
+			},
+			existing: []client.Object{credsSecret, &v1alpha2.MondooAuditConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: testNamespace},
+				Spec:       v1alpha2.MondooAuditConfigSpec{Nodes: v1alpha2.Nodes{Enable: true}},
+			}},
+			expectInMessage: "spec.nodes.enable",
+		},
+		{
+			name: "node scanning enabled in another namespace",
+			auditConfig: func(m *v1alpha2.MondooAuditConfig) {
+				m.Spec.Nodes.Enable = true
+			},
+			existing: []client.Object{credsSecret, &v1alpha2.MondooAuditConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "other"},
+				Spec:       v1alpha2.MondooAuditConfigSpec{Nodes: v1alpha2.Nodes{Enable: true}},
+			}},
+			expectAllowed: true,
+		},
+		{
+			name: "enforcing with manual certificates and a single replica",
+			auditConfig: func(m *v1alpha2.MondooAuditConfig) {
+				m.Spec.Admission.Enable = true
+				m.Spec.Admission.Mode = v1alpha2.Enforcing
+			},
+			existing:       []client.Object{credsSecret},
+			expectAllowed:  true,
+			expectWarnings: 3,
+		},
+		{
+			name: "include and exclude",
+			auditConfig: func(m *v1alpha2.MondooAuditConfig) {
+				m.Spec.Filtering.Namespaces.Include = []string{"app-*"}
+				m.Spec.Filtering.Namespaces.Exclude = []string{"kube-*"}
+			},
+			existing:       []client.Object{credsSecret},
+			expectAllowed:  true,
+			expectWarnings: 1,
+		},
+		{
+			name:           "missing creds secret",
+			expectAllowed:  true,
+			expectWarnings: 1,
+		},
+		{
+			name: "missing creds secret with token",
+			auditConfig: func(m *v1alpha2.MondooAuditConfig) {
+				m.Spec.MondooTokenSecretRef.Name = "mondoo-token"
+			},
+			existing: []client.Object{
+				&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "mondoo-token", Namespace: testNamespace}},
+			},
+			expectAllowed: true,
+		},
+		{
+			name: "missing token secret",
+			auditConfig: func(m *v1alpha2.MondooAuditConfig) {
+				m.Spec.MondooCredsSecretRef.Name = ""
+				m.Spec.MondooTokenSecretRef.Name = "mondoo-token"
+			},
+			expectAllowed:   true,
+			expectWarnings:  1,
+			expectInWarning: `Secret "mondoo-token" referenced by spec.mondooTokenSecretRef does not exist`,
+		},
+		{
+			name: "missing creds and token secrets",
+			auditConfig: func(m *v1alpha2.MondooAuditConfig) {
+				m.Spec.MondooTokenSecretRef.Name = "mondoo-token"
+			},
+			expectAllowed:  true,
+			expectWarnings: 1,
+			expectInWarning: `Secret "mondoo-client" referenced by spec.mondooCredsSecretRef and ` +
+				`Secret "mondoo-token" referenced by spec.mondooTokenSecretRef do not exist`,
+		},
+		{
+			name: "no credentials configured",
+			auditConfig: func(m *v1alpha2.MondooAuditConfig) {
+				m.Spec.MondooCredsSecretRef.Name = ""
+			},
+			expectAllowed:   true,
+			expectWarnings:  1,
+			expectInWarning: "No Mondoo credentials configured",
+		},
+		{
+			name: "valid monitored resources",
+			auditConfig: func(m *v1alpha2.MondooAuditConfig) {
+				m.Spec.KubernetesResources.MonitoredResources = []v1alpha2.MonitoredResource{
+					{Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"},
+					{Version: "v1", Kind: "Service"},
+				}
+			},
+			existing:      []client.Object{credsSecret},
+			expectAllowed: true,
+		},
+		{
+			name: "monitored resource without version",
+			auditConfig: func(m *v1alpha2.MondooAuditConfig) {
+				m.Spec.KubernetesResources.MonitoredResources = []v1alpha2.MonitoredResource{{Group: "argoproj.io", Kind: "Rollout"}}
+			},
+			existing:        []client.Object{credsSecret},
+			expectInMessage: "spec.kubernetesResources.monitoredResources[0].version",
+		},
+		{
+			name: "monitored resource with a resource instead of a kind",
+			auditConfig: func(m *v1alpha2.MondooAuditConfig) {
+				m.Spec.KubernetesResources.MonitoredResources = []v1alpha2.MonitoredResource{
+					{Group: "argoproj.io", Version: "v1alpha1", Kind: "rollouts.argoproj.io"},
+				}
+			},
+			existing:        []client.Object{credsSecret},
+			expectInMessage: "spec.kubernetesResources.monitoredResources[0].kind",
+		},
+		{
+			name: "duplicate monitored resource",
+			auditConfig: func(m *v1alpha2.MondooAuditConfig) {
+				r := v1alpha2.MonitoredResource{Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"}
+				m.Spec.KubernetesResources.MonitoredResources = []v1alpha2.MonitoredResource{r, r}
+			},
+			existing:        []client.Object{credsSecret},
+			expectInMessage: "spec.kubernetesResources.monitoredResources[1]: Duplicate value",
+		},
+		{
+			name: "invalid node scanning style",
+			auditConfig: func(m *v1alpha2.MondooAuditConfig) {
+				m.Spec.Nodes.Style = "pod"
+			},
+			existing:        []client.Object{credsSecret},
+			expectInMessage: "spec.nodes.style",
+		},
+		{
+			name: "negative interval timer",
+			auditConfig: func(m *v1alpha2.MondooAuditConfig) {
+				m.Spec.Nodes.Style = v1alpha2.NodeScanStyleDaemonSet
+				m.Spec.Nodes.IntervalTimer = -5
+			},
+			existing:        []client.Object{credsSecret},
+			expectInMessage: "spec.nodes.intervalTimer",
+		},
+		{
+			name: "schedule with the daemonset style",
+			auditConfig: func(m *v1alpha2.MondooAuditConfig) {
+				m.Spec.Nodes.Style = v1alpha2.NodeScanStyleDaemonSet
+				m.Spec.Nodes.Schedule = "0 * * * *"
+			},
+			existing:        []client.Object{credsSecret},
+			expectAllowed:   true,
+			expectWarnings:  1,
+			expectInWarning: "spec.nodes.schedule is ignored",
+		},
+		{
+			name: "valid decision log",
+			auditConfig: func(m *v1alpha2.MondooAuditConfig) {
+				m.Spec.Admission.DecisionLog = v1alpha2.AdmissionDecisionLog{
+					File: v1alpha2.DecisionLogFile{Enable: true, VolumeClaimName: "decision-log", MaxSizeMB: 10, MaxBackups: pointer.Int32(0)},
+					HTTP: v1alpha2.DecisionLogHTTP{Endpoint: "https://audit.example.com/admission", BatchSize: 50, MaxRetries: pointer.Int32(3)},
+				}
+			},
+			existing:      []client.Object{credsSecret},
+			expectAllowed: true,
+		},
+		{
+			name: "decision log endpoint without scheme",
+			auditConfig: func(m *v1alpha2.MondooAuditConfig) {
+				m.Spec.Admission.DecisionLog.HTTP.Endpoint = "audit.example.com/admission"
+			},
+			existing:        []client.Object{credsSecret},
+			expectInMessage: "spec.admission.decisionLog.http.endpoint",
+		},
+		{
+			name: "negative decision log settings",
+			auditConfig: func(m *v1alpha2.MondooAuditConfig) {
+				m.Spec.Admission.DecisionLog.File.MaxBackups = pointer.Int32(-1)
+				m.Spec.Admission.DecisionLog.HTTP.BatchSize = -1
+			},
+			existing:        []client.Object{credsSecret},
+			expectInMessage: "spec.admission.decisionLog.file.maxBackups",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			auditConfig := testMondooAuditConfig()
+			if test.auditConfig != nil {
+				test.auditConfig(auditConfig)
+			}
+			validator := setupValidator(t, test.existing...)
+
+			response := validator.Handle(context.Background(), testRequest(t, admissionv1.Create, auditConfig, nil))
+
+			assert.Equal(t, test.expectAllowed, response.Allowed, response.Result.Reason)
+			assert.Len(t, response.Warnings, test.expectWarnings)
+			if test.expectInMessage != "" {
+				assert.Contains(t, string(response.Result.Reason), test.expectInMessage)
+			}
+			if test.expectInWarning != "" {
+				require.Len(t, response.Warnings, 1)
+				assert.Contains(t, response.Warnings[0], test.expectInWarning)
+			}
+		})
+	}
+}
+
+func TestMondooAuditConfigValidator_UpdateInvalidWithoutSpecChange(t *testing.T) {
+	old := testMondooAuditConfig()
+	old.Spec.Filtering.Namespaces.Include = []string{"[abc"}
+	auditConfig := old.DeepCopy()
+	auditConfig.Finalizers = []string{"k8s.mondoo.com/delete"}
+	validator := setupValidator(t, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "mondoo-client", Namespace: testNamespace}})
+
+	response := validator.Handle(context.Background(), testRequest(t, admissionv1.Update, auditConfig, old))
+	assert.True(t, response.Allowed)
+	assert.Len(t, response.Warnings, 1)
+
+	auditConfig.Spec.Nodes.Enable = true
+	response = validator.Handle(context.Background(), testRequest(t, admissionv1.Update, auditConfig, old))
+	assert.False(t, response.Allowed)
+}
+
+func TestMondooAuditConfigDefault(t *testing.T) {
+	auditConfig := &v1alpha2.MondooAuditConfig{}
+	auditConfig.Default()
+
+	assert.Equal(t, "mondoo-operator-k8s-resources-scanning", auditConfig.Spec.Scanner.ServiceAccountName)
+	assert.Equal(t, pointer.Int32(1), auditConfig.Spec.Scanner.Replicas)
+	assert.Equal(t, v1alpha2.Permissive, auditConfig.Spec.Admission.Mode)
+	assert.Equal(t, pointer.Int32(1), auditConfig.Spec.Admission.Replicas)
+	assert.Equal(t, v1alpha2.ManualProvisioning, auditConfig.Spec.Admission.CertificateProvisioning.Mode)
+	assert.Equal(t, "mondoo-operator-webhook", auditConfig.Spec.Admission.ServiceAccountName)
+	assert.Equal(t, pointer.Int32(100), auditConfig.Spec.Admission.ScoreThreshold)
+
+	// Values which are already set are kept.
+	auditConfig.Spec.Admission.Mode = v1alpha2.Enforcing
+	auditConfig.Spec.Scanner.Replicas = pointer.Int32(3)
+	auditConfig.Default()
+	assert.Equal(t, v1alpha2.Enforcing, auditConfig.Spec.Admission.Mode)
+	assert.Equal(t, pointer.Int32(3), auditConfig.Spec.Scanner.Replicas)
+}
+
+func testMondooAuditConfig() *v1alpha2.MondooAuditConfig {
+	auditConfig := &v1alpha2.MondooAuditConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "mondoo-client", Namespace: testNamespace},
+		Spec: v1alpha2.MondooAuditConfigSpec{
+			MondooCredsSecretRef: corev1.LocalObjectReference{Name: "mondoo-client"},
+		},
+	}
+	auditConfig.Default()
+	return auditConfig
+}
+
+func setupValidator(t *testing.T, objects ...client.Object) *mondooAuditConfigValidator {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, v1alpha2.AddToScheme(scheme))
+	decoder, err := admission.NewDecoder(scheme)
+	require.NoError(t, err)
+
+	validator := &mondooAuditConfigValidator{
+		client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+	}
+	require.NoError(t, validator.InjectDecoder(decoder))
+	return validator
+}
+
+func testRequest(t *testing.T, operation admissionv1.Operation, auditConfig, old *v1alpha2.MondooAuditConfig) admission.Request {
+	request := admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: operation,
+			Namespace: auditConfig.Namespace,
+			Name:      auditConfig.Name,
+			Object:    rawExtension(t, auditConfig),
+		},
+	}
+	if old != nil {
+		request.OldObject = rawExtension(t, old)
+	}
+	return request
+}
+
+func rawExtension(t *testing.T, auditConfig *v1alpha2.MondooAuditConfig) runtime.RawExtension {
+	data, err := json.Marshal(auditConfig)
+	require.NoError(t, err)
+	return runtime.RawExtension{Raw: data}
+}
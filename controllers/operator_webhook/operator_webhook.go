@@ -0,0 +1,390 @@
+/*
+Copyright 2022 Mondoo, Inc.
+
+This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at https://mozilla.org/MPL/2.0/.
+*/
+
+package operator_webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	"go.mondoo.com/mondoo-operator/api/v1alpha2"
+	"go.mondoo.com/mondoo-operator/pkg/utils/k8s"
+)
+
+var logger = ctrl.Log.WithName("operator-webhook")
+
+const (
+	// CertificateSecretName is the name of the Secret holding the CA and the serving certificate of the
+	// operator's webhook server.
+	CertificateSecretName = "mondoo-operator-webhook-server-cert"
+
+	// defaultServiceName is the name of the webhook Service when deploying the operator with the manifests
+	// or the Helm chart with the default release name.
+	defaultServiceName = "mondoo-operator-controller-manager-webhook-service"
+
+	renewInterval = 24 * time.Hour
+)
+
+var (
+	crdGVK = schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}
+
+	// conversionCRDs are the CRDs which are converted by the operator's webhook server.
+	conversionCRDs = []string{"mondooauditconfigs.k8s.mondoo.com", "mondoooperatorconfigs.k8s.mondoo.com"}
+
+	clientConfigPath = []string{"spec", "conversion", "webhook", "clientConfig"}
+
+	// admissionWebhooks are the names of the admission webhooks served by the operator's webhook server. The
+	// names of the webhook configurations depend on how the operator was deployed, so the webhooks are looked
+	// up by name instead.
+	admissionWebhooks = map[string]struct{}{
+		"mmondooauditconfig.k8s.mondoo.com": {},
+		"vmondooauditconfig.k8s.mondoo.com": {},
+	}
+)
+
+//+kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;patch
+//+kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=mutatingwebhookconfigurations,verbs=list;patch
+// Renewing the webhook serving certificate requires updating its Secret
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=update
+
+type certificateProvisioner struct {
+	reader    client.Reader
+	client    client.Client
+	namespace string
+	certDir   string
+	certName  string
+	keyName   string
+}
+
+// Add provisions a self-signed serving certificate for the operator's webhook server, injects its CA into
+// the conversion webhook configuration of the Mondoo CRDs and into the operator's admission webhooks, and
+// registers the webhooks. The certificate is renewed while the operator is running. Add has to be called
+// before the manager is started.
+func Add(ctx context.Context, mgr ctrl.Manager) error {
+	namespace, err := k8s.GetRunningNamespace()
+	if err != nil {
+		return err
+	}
+
+	server := mgr.GetWebhookServer()
+	if server.CertDir == "" {
+		server.CertDir = filepath.Join(os.TempDir(), "k8s-webhook-server", "serving-certs")
+	}
+	if server.CertName == "" {
+		server.CertName = corev1.TLSCertKey
+	}
+	if server.KeyName == "" {
+		server.KeyName = corev1.TLSPrivateKeyKey
+	}
+
+	p := &certificateProvisioner{
+		reader:    mgr.GetAPIReader(),
+		client:    mgr.GetClient(),
+		namespace: namespace,
+		certDir:   server.CertDir,
+		certName:  server.CertName,
+		keyName:   server.KeyName,
+	}
+
+	// The webhook server fails to start without a certificate, so it has to be provisioned right away.
+	if err := p.reconcile(ctx); err != nil {
+		return err
+	}
+
+	if err := (&v1alpha2.MondooAuditConfig{}).SetupWebhookWithManager(mgr); err != nil {
+		return err
+	}
+	if err := (&v1alpha2.MondooOperatorConfig{}).SetupWebhookWithManager(mgr); err != nil {
+		return err
+	}
+	server.Register(validateMondooAuditConfigPath, &webhook.Admission{
+		Handler: &mondooAuditConfigValidator{client: mgr.GetClient()},
+	})
+	return mgr.Add(p)
+}
+
+// Start periodically renews the serving certificate until the context is cancelled.
+func (p *certificateProvisioner) Start(ctx context.Context) error {
+	ticker := time.NewTicker(renewInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := p.reconcile(ctx); err != nil {
+				logger.Error(err, "failed to renew the webhook serving certificate")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection implements the LeaderElectionRunnable interface. Every replica of the operator serves
+// webhook requests, so every replica needs a valid certificate.
+func (p *certificateProvisioner) NeedLeaderElection() bool {
+	return false
+}
+
+func (p *certificateProvisioner) reconcile(ctx context.Context) error {
+	crds, err := p.getCRDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	webhookConfigs, err := p.getWebhookConfigurations(ctx)
+	if err != nil {
+		return err
+	}
+
+	secret, err := p.ensureSecret(ctx, p.dnsNames(crds, webhookConfigs))
+	if err != nil {
+		return err
+	}
+
+	if err := p.writeCertificate(secret); err != nil {
+		return err
+	}
+
+	for _, crd := range crds {
+		if err := p.injectCABundle(ctx, crd, secret.Data[caCertKey]); err != nil {
+			return err
+		}
+	}
+	for _, webhookConfig := range webhookConfigs {
+		if err := p.injectWebhookCABundle(ctx, webhookConfig, secret.Data[caCertKey]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *certificateProvisioner) getCRDs(ctx context.Context) ([]*unstructured.Unstructured, error) {
+	var crds []*unstructured.Unstructured
+	for _, name := range conversionCRDs {
+		crd := &unstructured.Unstructured{}
+		crd.SetGroupVersionKind(crdGVK)
+		if err := p.reader.Get(ctx, client.ObjectKey{Name: name}, crd); err != nil {
+			if apierrors.IsNotFound(err) {
+				logger.Info("CRD not found, skipping the conversion webhook configuration", "name", name)
+				continue
+			}
+			logger.Error(err, "failed to get CRD", "name", name)
+			return nil, err
+		}
+
+		if strategy, _, _ := unstructured.NestedString(crd.Object, "spec", "conversion", "strategy"); strategy != "Webhook" {
+			logger.Info("CRD does not use a conversion webhook", "name", name)
+			continue
+		}
+		crds = append(crds, crd)
+	}
+	return crds, nil
+}
+
+// webhookConfiguration is a Validating- or MutatingWebhookConfiguration together with the client configs of
+// its webhooks which are served by the operator.
+type webhookConfiguration struct {
+	object        client.Object
+	clientConfigs []*admissionregistrationv1.WebhookClientConfig
+}
+
+func (p *certificateProvisioner) getWebhookConfigurations(ctx context.Context) ([]webhookConfiguration, error) {
+	var webhookConfigs []webhookConfiguration
+
+	vwcs := &admissionregistrationv1.ValidatingWebhookConfigurationList{}
+	if err := p.reader.List(ctx, vwcs); err != nil {
+		logger.Error(err, "failed to list ValidatingWebhookConfigurations")
+		return nil, err
+	}
+	for i := range vwcs.Items {
+		webhookConfig := webhookConfiguration{object: &vwcs.Items[i]}
+		for j := range vwcs.Items[i].Webhooks {
+			webhookConfig.add(vwcs.Items[i].Webhooks[j].Name, &vwcs.Items[i].Webhooks[j].ClientConfig, p.namespace)
+		}
+		if len(webhookConfig.clientConfigs) > 0 {
+			webhookConfigs = append(webhookConfigs, webhookConfig)
+		}
+	}
+
+	mwcs := &admissionregistrationv1.MutatingWebhookConfigurationList{}
+	if err := p.reader.List(ctx, mwcs); err != nil {
+		logger.Error(err, "failed to list MutatingWebhookConfigurations")
+		return nil, err
+	}
+	for i := range mwcs.Items {
+		webhookConfig := webhookConfiguration{object: &mwcs.Items[i]}
+		for j := range mwcs.Items[i].Webhooks {
+			webhookConfig.add(mwcs.Items[i].Webhooks[j].Name, &mwcs.Items[i].Webhooks[j].ClientConfig, p.namespace)
+		}
+		if len(webhookConfig.clientConfigs) > 0 {
+			webhookConfigs = append(webhookConfigs, webhookConfig)
+		}
+	}
+	return webhookConfigs, nil
+}
+
+// add keeps track of the client config if the webhook is served by the operator running in the namespace.
+func (c *webhookConfiguration) add(name string, clientConfig *admissionregistrationv1.WebhookClientConfig, namespace string) {
+	if _, ok := admissionWebhooks[name]; !ok {
+		return
+	}
+	if clientConfig.Service == nil || clientConfig.Service.Namespace != namespace {
+		return
+	}
+	c.clientConfigs = append(c.clientConfigs, clientConfig)
+}
+
+// dnsNames returns the DNS names of the webhook Services referenced by the CRDs and the webhook configurations.
+func (p *certificateProvisioner) dnsNames(crds []*unstructured.Unstructured, webhookConfigs []webhookConfiguration) []string {
+	names := map[string]struct{}{
+		fmt.Sprintf("%s.%s.svc", defaultServiceName, p.namespace): {},
+	}
+	for _, crd := range crds {
+		service, _, _ := unstructured.NestedStringMap(crd.Object, append(clientConfigPath, "service")...)
+		if service["name"] == "" || service["namespace"] == "" {
+			continue
+		}
+		names[fmt.Sprintf("%s.%s.svc", service["name"], service["namespace"])] = struct{}{}
+	}
+	for _, webhookConfig := range webhookConfigs {
+		for _, clientConfig := range webhookConfig.clientConfigs {
+			names[fmt.Sprintf("%s.%s.svc", clientConfig.Service.Name, clientConfig.Service.Namespace)] = struct{}{}
+		}
+	}
+
+	dnsNames := make([]string, 0, len(names))
+	for name := range names {
+		dnsNames = append(dnsNames, name)
+	}
+	sort.Strings(dnsNames)
+	return dnsNames
+}
+
+func (p *certificateProvisioner) ensureSecret(ctx context.Context, dnsNames []string) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	retryable := func(err error) bool { return apierrors.IsAlreadyExists(err) || apierrors.IsConflict(err) }
+	err := retry.OnError(retry.DefaultRetry, retryable, func() error {
+		key := client.ObjectKey{Namespace: p.namespace, Name: CertificateSecretName}
+		if err := p.reader.Get(ctx, key, secret); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return err
+			}
+
+			secret = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+				Type:       corev1.SecretTypeTLS,
+			}
+			if _, err := renewCertificates(secret, dnsNames, time.Now()); err != nil {
+				return err
+			}
+			// Another replica of the operator might have created the Secret in the meantime. In that case
+			// the create fails and the Secret is read again.
+			if err := p.client.Create(ctx, secret); err != nil {
+				return err
+			}
+			logger.Info("Created webhook serving certificate", "namespace", secret.Namespace, "name", secret.Name)
+			return nil
+		}
+
+		changed, err := renewCertificates(secret, dnsNames, time.Now())
+		if err != nil || !changed {
+			return err
+		}
+		if err := p.client.Update(ctx, secret); err != nil {
+			return err
+		}
+		logger.Info("Renewed webhook serving certificate", "namespace", secret.Namespace, "name", secret.Name)
+		return nil
+	})
+	if err != nil {
+		logger.Error(err, "failed to provision the webhook serving certificate", "namespace", p.namespace, "name", CertificateSecretName)
+		return nil, err
+	}
+	return secret, nil
+}
+
+// writeCertificate writes the serving certificate to the directory the webhook server loads it from. The
+// webhook server watches the files and picks up renewed certificates automatically.
+func (p *certificateProvisioner) writeCertificate(secret *corev1.Secret) error {
+	if err := os.MkdirAll(p.certDir, 0o700); err != nil {
+		return fmt.Errorf("failed to create the webhook certificate directory: %w", err)
+	}
+
+	files := map[string][]byte{
+		p.keyName:  secret.Data[corev1.TLSPrivateKeyKey],
+		p.certName: secret.Data[corev1.TLSCertKey],
+	}
+	for name, data := range files {
+		path := filepath.Join(p.certDir, name)
+		if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, data) {
+			continue
+		}
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func (p *certificateProvisioner) injectCABundle(ctx context.Context, crd *unstructured.Unstructured, caBundle []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(caBundle)
+	existing, _, _ := unstructured.NestedString(crd.Object, append(clientConfigPath, "caBundle")...)
+	if existing == encoded {
+		return nil
+	}
+
+	patch := client.MergeFrom(crd.DeepCopy())
+	if err := unstructured.SetNestedField(crd.Object, encoded, append(clientConfigPath, "caBundle")...); err != nil {
+		return err
+	}
+	if err := p.client.Patch(ctx, crd, patch); err != nil {
+		logger.Error(err, "failed to inject the CA bundle into the CRD", "name", crd.GetName())
+		return err
+	}
+	logger.Info("Injected the CA bundle into the CRD", "name", crd.GetName())
+	return nil
+}
+
+func (p *certificateProvisioner) injectWebhookCABundle(ctx context.Context, webhookConfig webhookConfiguration, caBundle []byte) error {
+	patch := client.MergeFrom(webhookConfig.object.DeepCopyObject().(client.Object))
+	changed := false
+	for _, clientConfig := range webhookConfig.clientConfigs {
+		if !bytes.Equal(clientConfig.CABundle, caBundle) {
+			clientConfig.CABundle = caBundle
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	if err := p.client.Patch(ctx, webhookConfig.object, patch); err != nil {
+		logger.Error(err, "failed to inject the CA bundle into the webhook configuration", "name", webhookConfig.object.GetName())
+		return err
+	}
+	logger.Info("Injected the CA bundle into the webhook configuration", "name", webhookConfig.object.GetName())
+	return nil
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2022 Mondoo, Inc.
+
+This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at https://mozilla.org/MPL/2.0/.
+*/
+
+package resource_monitor
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mondoo.com/mondoo-operator/pkg/utils/k8s"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+var metricsSuppressedUpdates = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "mondoo_resource_monitor_suppressed_updates_total",
+		Help: "Number of updates which were not scanned, because only the status of the resource or the node of a Pod changed",
+	},
+	[]string{"kind"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(metricsSuppressedUpdates)
+}
+
+var _ predicate.Predicate = contentChangedPredicate{}
+
+// contentChangedPredicate allows create events and the update events which change the scannable content of a
+// resource. Updates of the status only, like replica counts or the phase of a Pod, are suppressed.
+type contentChangedPredicate struct {
+	k8s.CreateUpdateEventsPredicate
+	resourceType string
+}
+
+func (p contentChangedPredicate) Update(e event.UpdateEvent) bool {
+	if !p.CreateUpdateEventsPredicate.Update(e) {
+		return false
+	}
+	if contentChanged(e.ObjectOld, e.ObjectNew) {
+		return true
+	}
+	metricsSuppressedUpdates.WithLabelValues(p.resourceType).Inc()
+	return false
+}
+
+// contentChanged checks whether the scannable content of the resource changed. Labels and annotations are
+// compared, since they don't change the generation. The generation is used for all kinds that have one. For
+// kinds without a generation, like Pods, everything except the metadata, status and the fields set by the
+// scheduler is compared.
+func contentChanged(oldObj, newObj client.Object) bool {
+	if oldObj == nil || newObj == nil {
+		return true
+	}
+	if !equality.Semantic.DeepEqual(oldObj.GetLabels(), newObj.GetLabels()) ||
+		!equality.Semantic.DeepEqual(oldObj.GetAnnotations(), newObj.GetAnnotations()) {
+		return true
+	}
+	if oldObj.GetGeneration() > 0 && newObj.GetGeneration() > 0 {
+		return oldObj.GetGeneration() != newObj.GetGeneration()
+	}
+
+	oldContent, err := scannableContent(oldObj)
+	if err != nil {
+		return true
+	}
+	newContent, err := scannableContent(newObj)
+	if err != nil {
+		return true
+	}
+	return !equality.Semantic.DeepEqual(oldContent, newContent)
+}
+
+// scannableContent returns the fields of the object without its metadata and status. For Pods, the fields which
+// are set when the Pod is scheduled are removed as well.
+func scannableContent(obj client.Object) (map[string]interface{}, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	delete(content, "metadata")
+	delete(content, "status")
+	if spec, ok := content["spec"].(map[string]interface{}); ok && isPod(obj) {
+		removeSchedulingFields(spec)
+	}
+	return content, nil
+}
+
+func isPod(obj client.Object) bool {
+	if _, ok := obj.(*corev1.Pod); ok {
+		return true
+	}
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	return gvk.Group == "" && gvk.Kind == "Pod"
+}
+
+// removeSchedulingFields removes the fields of a Pod spec which are changed by the scheduler and the node lifecycle
+// instead of the owner of the Pod: the node the Pod is bound to, the removed scheduling gates and the tolerations
+// for the node.kubernetes.io/* taints.
+func removeSchedulingFields(spec map[string]interface{}) {
+	delete(spec, "nodeName")
+	delete(spec, "schedulingGates")
+
+	tolerations, ok := spec["tolerations"].([]interface{})
+	if !ok {
+		return
+	}
+	var kept []interface{}
+	for _, t := range tolerations {
+		if toleration, ok := t.(map[string]interface{}); ok {
+			if key, _ := toleration["key"].(string); strings.HasPrefix(key, "node.kubernetes.io/") {
+				continue
+			}
+		}
+		kept = append(kept, t)
+	}
+	if len(kept) == 0 {
+		delete(spec, "tolerations")
+	} else {
+		spec["tolerations"] = kept
+	}
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2022 Mondoo, Inc.
+
+This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at https://mozilla.org/MPL/2.0/.
+*/
+
+package resource_monitor
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func testDeployment() *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "dep", Namespace: "default", Generation: 1, ResourceVersion: "1"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "nginx:1.23"}}},
+			},
+		},
+	}
+}
+
+func testPod() *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default", ResourceVersion: "1"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "nginx:1.23"}}},
+		Status:     corev1.PodStatus{Phase: corev1.PodPending},
+	}
+}
+
+func TestContentChanged(t *testing.T) {
+	tests := []struct {
+		name    string
+		old     client.Object
+		new     func() client.Object
+		changed bool
+	}{
+		{
+			name: "deployment status update",
+			old:  testDeployment(),
+			new: func() client.Object {
+				d := testDeployment()
+				d.ResourceVersion = "2"
+				d.Status.ReadyReplicas = 3
+				d.Status.ObservedGeneration = 1
+				return d
+			},
+		},
+		{
+			name: "deployment spec update",
+			old:  testDeployment(),
+			new: func() client.Object {
+				d := testDeployment()
+				d.Generation = 2
+				d.Spec.Template.Spec.Containers[0].Image = "nginx:1.24"
+				return d
+			},
+			changed: true,
+		},
+		{
+			name: "deployment label update",
+			old:  testDeployment(),
+			new: func() client.Object {
+				d := testDeployment()
+				d.Labels = map[string]string{"team": "payments"}
+				return d
+			},
+			changed: true,
+		},
+		{
+			name: "pod phase update",
+			old:  testPod(),
+			new: func() client.Object {
+				p := testPod()
+				p.ResourceVersion = "2"
+				p.Status.Phase = corev1.PodRunning
+				return p
+			},
+		},
+		{
+			name: "pod scheduled",
+			old:  testPod(),
+			new: func() client.Object {
+				p := testPod()
+				p.ResourceVersion = "2"
+				p.Spec.NodeName = "node01"
+				return p
+			},
+		},
+		{
+			name: "pod with injected tolerations scheduled",
+			old:  testPod(),
+			new: func() client.Object {
+				p := testPod()
+				p.ResourceVersion = "2"
+				p.Spec.NodeName = "node01"
+				p.Spec.Tolerations = []corev1.Toleration{
+					{Key: "node.kubernetes.io/not-ready", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute},
+				}
+				return p
+			},
+		},
+		{
+			name: "pod toleration update",
+			old:  testPod(),
+			new: func() client.Object {
+				p := testPod()
+				p.Spec.Tolerations = []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}}
+				return p
+			},
+			changed: true,
+		},
+		{
+			name: "pod image update",
+			old:  testPod(),
+			new: func() client.Object {
+				p := testPod()
+				p.Spec.Containers[0].Image = "nginx:1.24"
+				return p
+			},
+			changed: true,
+		},
+		{
+			name: "custom resource status update",
+			old:  testRollout(1, "Progressing"),
+			new:  func() client.Object { return testRollout(1, "Healthy") },
+		},
+		{
+			name:    "custom resource spec update",
+			old:     testRollout(1, "Healthy"),
+			new:     func() client.Object { return testRollout(2, "Healthy") },
+			changed: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.changed, contentChanged(test.old, test.new()))
+		})
+	}
+}
+
+func TestContentChangedPredicate_CountsSuppressedUpdates(t *testing.T) {
+	p := contentChangedPredicate{resourceType: "pod"}
+	before := testutil.ToFloat64(metricsSuppressedUpdates.WithLabelValues("pod"))
+
+	updated := testPod()
+	updated.Status.Phase = corev1.PodRunning
+	assert.False(t, p.Update(event.UpdateEvent{ObjectOld: testPod(), ObjectNew: updated}))
+	assert.Equal(t, before+1, testutil.ToFloat64(metricsSuppressedUpdates.WithLabelValues("pod")))
+
+	// Binding the Pod to a node isn't a change of its content.
+	scheduled := testPod()
+	scheduled.Spec.NodeName = "node01"
+	assert.False(t, p.Update(event.UpdateEvent{ObjectOld: testPod(), ObjectNew: scheduled}))
+	assert.Equal(t, before+2, testutil.ToFloat64(metricsSuppressedUpdates.WithLabelValues("pod")))
+
+	// Updates caused by the deletion are ignored, but they aren't counted as suppressed.
+	deleted := testPod()
+	deleted.DeletionTimestamp = &metav1.Time{}
+	assert.False(t, p.Update(event.UpdateEvent{ObjectOld: testPod(), ObjectNew: deleted}))
+	assert.Equal(t, before+2, testutil.ToFloat64(metricsSuppressedUpdates.WithLabelValues("pod")))
+
+	assert.True(t, p.Create(event.CreateEvent{Object: testPod()}))
+}
+
+func testRollout(generation int64, phase string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec":   map[string]interface{}{"replicas": int64(3)},
+		"status": map[string]interface{}{"phase": phase},
+	}}
+	obj.SetGroupVersionKind(rolloutGVK)
+	obj.SetName("rollout")
+	obj.SetNamespace("default")
+	obj.SetGeneration(generation)
+	return obj
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2022 Mondoo, Inc.
+
+This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at https://mozilla.org/MPL/2.0/.
+*/
+
+package resource_monitor
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const CheckpointConfigMapName = "mondoo-resource-monitor-checkpoint"
+
+// CheckpointStore keeps the resourceVersion up to which the changes of every resource type were scheduled for a
+// scan. The checkpoints are stored in a ConfigMap, so changes which happen while the operator is down can be
+// scanned after a restart.
+type CheckpointStore struct {
+	client client.Client
+	// reader reads directly from the API server. Reading the ConfigMap through the cache would start an informer
+	// for all ConfigMaps in the cluster.
+	reader    client.Reader
+	namespace string
+}
+
+func NewCheckpointStore(kubeClient client.Client, reader client.Reader, namespace string) *CheckpointStore {
+	return &CheckpointStore{client: kubeClient, reader: reader, namespace: namespace}
+}
+
+// Load returns the checkpoint of the resource type. If there is no checkpoint yet, the current resourceVersion of
+// the resource type is returned, so the existing resources aren't scanned on the first start of the operator.
+//
+// The checkpoints fail open. If the checkpoint or the current resourceVersion can't be parsed, or the checkpoint is
+// newer than the current resourceVersion, e.g. because etcd was restored from a backup, the checkpoint is cleared
+// and 0 is returned, so all resources are scanned.
+func (s *CheckpointStore) Load(ctx context.Context, resourceType string, gvk schema.GroupVersionKind) (uint64, error) {
+	cm := &corev1.ConfigMap{}
+	err := s.reader.Get(ctx, types.NamespacedName{Name: CheckpointConfigMapName, Namespace: s.namespace}, cm)
+	if err != nil && !errors.IsNotFound(err) {
+		return 0, err
+	}
+
+	list := &metav1.PartialObjectMetadataList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := s.reader.List(ctx, list, client.Limit(1)); err != nil {
+		return 0, err
+	}
+	current, currentErr := parseResourceVersion(list.GetResourceVersion())
+
+	rv, ok := cm.Data[resourceType]
+	if !ok {
+		if currentErr != nil {
+			logger.Error(currentErr, "the current resourceVersion is not a number, scanning all resources",
+				"resourceType", resourceType, "resourceVersion", list.GetResourceVersion())
+			return 0, nil
+		}
+		return current, nil
+	}
+
+	checkpoint, err := parseResourceVersion(rv)
+	switch {
+	case err != nil:
+		logger.Error(err, "clearing invalid checkpoint", "resourceType", resourceType, "checkpoint", rv)
+	case currentErr != nil:
+		logger.Error(currentErr, "clearing checkpoint, because the current resourceVersion is not a number",
+			"resourceType", resourceType, "resourceVersion", list.GetResourceVersion())
+	case checkpoint > current:
+		logger.Info("clearing checkpoint, because it is newer than the current resourceVersion",
+			"resourceType", resourceType, "checkpoint", checkpoint, "resourceVersion", current)
+	default:
+		return checkpoint, nil
+	}
+	if err := s.clear(ctx, resourceType); err != nil {
+		return 0, err
+	}
+	return 0, nil
+}
+
+// clear removes the checkpoint of the resource type.
+func (s *CheckpointStore) clear(ctx context.Context, resourceType string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"data": map[string]interface{}{resourceType: nil},
+	})
+	if err != nil {
+		return err
+	}
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: CheckpointConfigMapName, Namespace: s.namespace}}
+	return client.IgnoreNotFound(s.client.Patch(ctx, cm, client.RawPatch(types.MergePatchType, patch)))
+}
+
+// Save stores the checkpoint of the resource type. Only the key of the resource type is patched, so the
+// resource monitors of the other resource types don't conflict.
+func (s *CheckpointStore) Save(ctx context.Context, resourceType string, checkpoint uint64) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"data": map[string]string{resourceType: strconv.FormatUint(checkpoint, 10)},
+	})
+	if err != nil {
+		return err
+	}
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: CheckpointConfigMapName, Namespace: s.namespace}}
+	err = s.client.Patch(ctx, cm, client.RawPatch(types.MergePatchType, patch))
+	if !errors.IsNotFound(err) {
+		return err
+	}
+
+	cm.Labels = map[string]string{"app.kubernetes.io/managed-by": "mondoo-operator"}
+	cm.Data = map[string]string{resourceType: strconv.FormatUint(checkpoint, 10)}
+	err = s.client.Create(ctx, cm)
+	if errors.IsAlreadyExists(err) {
+		// Another resource monitor created the ConfigMap in the meantime.
+		return s.client.Patch(ctx, cm, client.RawPatch(types.MergePatchType, patch))
+	}
+	return err
+}
+
+// parseResourceVersion parses a resourceVersion. The API server treats resourceVersions as opaque strings. The
+// checkpoints rely on the API server using increasing etcd revisions as resourceVersions, like the upstream API
+// server does. This assumption is documented together with the --resource-scan-checkpoints flag, which disables
+// the checkpoints for other API servers. Load clears the checkpoints which contradict it.
+func parseResourceVersion(rv string) (uint64, error) {
+	return strconv.ParseUint(rv, 10, 64)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2022 Mondoo, Inc.
+
+This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at https://mozilla.org/MPL/2.0/.
+*/
+
+package resource_monitor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCheckpointStore_SaveAndLoad(t *testing.T) {
+	ctx := context.Background()
+	kubeClient := fake.NewClientBuilder().Build()
+	store := NewCheckpointStore(kubeClient, listVersionReader{Reader: kubeClient, resourceVersion: "30"}, "mondoo-operator")
+	podGVK := corev1.SchemeGroupVersion.WithKind("Pod")
+
+	require.NoError(t, store.Save(ctx, "pod", 10))
+	require.NoError(t, store.Save(ctx, "deployment", 20))
+	require.NoError(t, store.Save(ctx, "pod", 15))
+
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, kubeClient.Get(ctx, types.NamespacedName{Name: CheckpointConfigMapName, Namespace: "mondoo-operator"}, cm))
+	assert.Equal(t, map[string]string{"pod": "15", "deployment": "20"}, cm.Data)
+
+	checkpoint, err := store.Load(ctx, "pod", podGVK)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(15), checkpoint)
+}
+
+func TestCheckpointStore_LoadWithoutCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	kubeClient := fake.NewClientBuilder().WithObjects(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}},
+	).Build()
+	store := NewCheckpointStore(kubeClient, listVersionReader{Reader: kubeClient, resourceVersion: "42"}, "mondoo-operator")
+
+	// Without a checkpoint, the current resourceVersion of the resource type is used.
+	checkpoint, err := store.Load(ctx, "pod", corev1.SchemeGroupVersion.WithKind("Pod"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), checkpoint)
+}
+
+func TestCheckpointStore_LoadClearsCheckpoint(t *testing.T) {
+	tests := []struct {
+		name            string
+		checkpoint      string
+		resourceVersion string
+	}{
+		{name: "checkpoint newer than the current resourceVersion", checkpoint: "100", resourceVersion: "42"},
+		{name: "invalid checkpoint", checkpoint: "abc", resourceVersion: "42"},
+		{name: "invalid current resourceVersion", checkpoint: "10", resourceVersion: "opaque-42"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			kubeClient := fake.NewClientBuilder().WithObjects(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: CheckpointConfigMapName, Namespace: "mondoo-operator"},
+				Data:       map[string]string{"pod": test.checkpoint, "deployment": "5"},
+			}).Build()
+			store := NewCheckpointStore(kubeClient, listVersionReader{Reader: kubeClient, resourceVersion: test.resourceVersion}, "mondoo-operator")
+
+			// Verify all resources are scanned instead of treating them as already scanned.
+			checkpoint, err := store.Load(ctx, "pod", corev1.SchemeGroupVersion.WithKind("Pod"))
+			require.NoError(t, err)
+			assert.Zero(t, checkpoint)
+
+			cm := &corev1.ConfigMap{}
+			require.NoError(t, kubeClient.Get(ctx, types.NamespacedName{Name: CheckpointConfigMapName, Namespace: "mondoo-operator"}, cm))
+			assert.Equal(t, map[string]string{"deployment": "5"}, cm.Data)
+		})
+	}
+}
+
+func TestCheckpointStore_LoadWithoutCheckpointInvalidResourceVersion(t *testing.T) {
+	kubeClient := fake.NewClientBuilder().Build()
+	store := NewCheckpointStore(kubeClient, listVersionReader{Reader: kubeClient, resourceVersion: "opaque-42"}, "mondoo-operator")
+
+	checkpoint, err := store.Load(context.Background(), "pod", corev1.SchemeGroupVersion.WithKind("Pod"))
+	require.NoError(t, err)
+	assert.Zero(t, checkpoint)
+}
+
+// listVersionReader sets the resourceVersion of lists like the API server does. The fake client leaves it empty.
+type listVersionReader struct {
+	client.Reader
+	resourceVersion string
+}
+
+func (r listVersionReader) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if err := r.Reader.List(ctx, list, opts...); err != nil {
+		return err
+	}
+	list.SetResourceVersion(r.resourceVersion)
+	return nil
+}
@@ -11,15 +11,39 @@ package debouncer
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
+	"go.mondoo.com/cnspec/policy/scan"
 	"go.mondoo.com/mondoo-operator/controllers/resource_monitor/scan_api_store"
-	"go.mondoo.com/mondoo-operator/pkg/utils"
+	"go.mondoo.com/mondoo-operator/pkg/utils/k8s"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
-const defaultFlushTimeout = 5
+const (
+	// DefaultFlushInterval is the default time between two flushes of the changed resources.
+	DefaultFlushInterval = 5 * time.Second
+	// DefaultShutdownTimeout is the default deadline of the final flush when the debouncer is stopped.
+	DefaultShutdownTimeout = 10 * time.Second
+	// DefaultMaxBatchSize is the default maximum number of resources scheduled with a single scan request
+	DefaultMaxBatchSize = 50
+
+	// maxScheduleRetries is the number of times a failed scan request is retried before the resources are dropped.
+	maxScheduleRetries = 5
+	// initialRetryBackoff is the time before the first retry. It doubles with every retry.
+	initialRetryBackoff = 10 * time.Second
+	maxRetryBackoff     = 5 * time.Minute
+	// maxBufferedResources is the number of changed resources which are kept for an unhealthy scan api. If more
+	// resources change while the scan api is unhealthy, the oldest changes are dropped. The same limit applies to the
+	// deleted resources.
+	maxBufferedResources = 1000
+
+	// AssetUIDLabel is the asset label with the UID of the Kubernetes resource the asset was discovered from.
+	AssetUIDLabel = "k8s.mondoo.com/uid"
+)
 
 var logger = log.Log.WithName("scan-api-store")
 
@@ -27,73 +51,471 @@ var logger = log.Log.WithName("scan-api-store")
 
 type Debouncer interface {
 	Start(ctx context.Context, managedBy string)
-	Add(res string)
+	Add(res string, resourceVersion uint64)
+	Delete(res, uid string)
+}
+
+// deletion is a deleted resource whose assets are garbage collected on the next flush.
+type deletion struct {
+	res string
+	uid string
+}
+
+// retry is a resource whose scan request to the scan api with the url failed. It is added back to the queue
+// with the backoff of the rate limiter.
+type retry struct {
+	res string
+	url string
+}
+
+// Options configures the debouncer.
+type Options struct {
+	// Kind is the resource type of the debouncer. It is used as label of the metrics.
+	Kind string
+	// FlushInterval is the time between two flushes. DefaultFlushInterval is used if it is not positive.
+	FlushInterval time.Duration
+	// ShutdownTimeout is the deadline of the final flush when the debouncer is stopped. DefaultShutdownTimeout
+	// is used if it is not positive.
+	ShutdownTimeout time.Duration
+	// MaxBatchSize is the maximum number of resources scheduled with a single scan request. DefaultMaxBatchSize
+	// is used if it is not positive.
+	MaxBatchSize int
+	// OnFlush is called after a flush with the checkpoint, which is the highest resourceVersion up to which all
+	// changes were scheduled for a scan. It is 0 if no resourceVersion was scheduled yet.
+	OnFlush func(ctx context.Context, checkpoint uint64)
+	// SkipFirstFlush drops the changed resources of the first flush. These are the existing resources which are
+	// observed when the resource monitor starts. It is used if there are no checkpoints to filter them.
+	SkipFirstFlush bool
+}
+
+// batch is a scan request for a group of resources.
+type batch struct {
+	client    scan_api_store.ClientConfiguration
+	resources []string
 }
 
 type debouncer struct {
-	isFirstFlush bool
-	flushTimeout time.Duration
-	resChan      chan string
-	resources    map[string]struct{}
-	scanApiStore scan_api_store.ScanApiStore
+	kind            string
+	flushInterval   time.Duration
+	shutdownTimeout time.Duration
+	maxBatchSize    int
+	onFlush         func(ctx context.Context, checkpoint uint64)
+	isFirstFlush    bool
+	// queue holds the changed resources, the deletions and the failed resources which are retried. Adding to the
+	// queue never blocks, so a slow flush doesn't block the reconciles of the resource monitor.
+	queue     workqueue.RateLimitingInterface
+	resources map[string]struct{}
+	deletions map[string]string
+	// retries are the resources to retry for every scan api by its url.
+	retries map[string][]string
+	// buffered are the changed resources of every unhealthy scan api by its url. They are scheduled once the scan
+	// api is healthy again.
+	buffered map[string][]string
+	// bufferedDeletions are the deleted resources of every unhealthy scan api by its url. Their assets are garbage
+	// collected once the scan api is healthy again.
+	bufferedDeletions map[string][]deletion
+	maxBuffered       int
+	versions          *versionTracker
+	scanApiStore      scan_api_store.ScanApiStore
+	// kubeClient is used to read the labels of the namespaces when filtering with a namespace selector.
+	kubeClient client.Reader
 }
 
-func NewDebouncer(scanApiStore scan_api_store.ScanApiStore) Debouncer {
+func NewDebouncer(kubeClient client.Reader, scanApiStore scan_api_store.ScanApiStore, opts Options) Debouncer {
+	flushInterval := opts.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = DefaultFlushInterval
+	}
+	shutdownTimeout := opts.ShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = DefaultShutdownTimeout
+	}
+	maxBatchSize := opts.MaxBatchSize
+	if maxBatchSize <= 0 {
+		maxBatchSize = DefaultMaxBatchSize
+	}
 	return &debouncer{
-		isFirstFlush: true,
-		flushTimeout: defaultFlushTimeout * time.Second,
-		resChan:      make(chan string),
-		resources:    make(map[string]struct{}),
-		scanApiStore: scanApiStore,
+		kind:              opts.Kind,
+		flushInterval:     flushInterval,
+		shutdownTimeout:   shutdownTimeout,
+		maxBatchSize:      maxBatchSize,
+		onFlush:           opts.OnFlush,
+		isFirstFlush:      opts.SkipFirstFlush,
+		queue:             newQueue(initialRetryBackoff),
+		resources:         make(map[string]struct{}),
+		deletions:         make(map[string]string),
+		retries:           make(map[string][]string),
+		buffered:          make(map[string][]string),
+		bufferedDeletions: make(map[string][]deletion),
+		maxBuffered:       maxBufferedResources,
+		versions:          newVersionTracker(),
+		scanApiStore:      scanApiStore,
+		kubeClient:        kubeClient,
 	}
 }
 
+func newQueue(retryBackoff time.Duration) workqueue.RateLimitingInterface {
+	return workqueue.NewRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(retryBackoff, maxRetryBackoff))
+}
+
 func (d *debouncer) Start(ctx context.Context, managedBy string) {
+	defer d.queue.ShutDown()
+
+	ticker := time.NewTicker(d.flushInterval)
+	defer ticker.Stop()
 	for {
 		select {
 		case <-ctx.Done():
+			// The pending changes would be lost otherwise. The context is already done, so the final flush
+			// gets a context with its own deadline.
+			flushCtx, cancel := context.WithTimeout(context.Background(), d.shutdownTimeout)
+			logger.Info("Flushing pending changes before shutdown", "kind", d.kind, "resources", d.queue.Len())
+			d.flush(flushCtx, managedBy)
+			cancel()
 			return
-		case res := <-d.resChan:
-			d.resources[res] = struct{}{}
-		case <-time.After(d.flushTimeout):
-			// If this is the first flush do not trigger scan for the resources. Initially, when the operator
-			// starts all current cluster resources are observed as "new". We don't want to scan the entire
-			// cluster for every operator start.
-			if d.isFirstFlush {
-				d.resources = make(map[string]struct{})
-				d.isFirstFlush = false
-				continue
-			}
+		case <-ticker.C:
+			d.flush(ctx, managedBy)
+		}
+	}
+}
+
+// Add schedules a scan of the changed resource. The resourceVersion of the change is used for the checkpoint. It
+// is 0 if it is unknown.
+func (d *debouncer) Add(res string, resourceVersion uint64) {
+	// The resourceVersion is recorded first, so the checkpoint never covers a change which is in the queue.
+	d.versions.queue(res, resourceVersion)
+	d.queue.Add(res)
+	metricsQueueDepth.WithLabelValues(d.kind).Set(float64(d.queue.Len()))
+}
+
+// Delete retires the assets of a deleted resource. The assets are found by the UID of the resource, since a new
+// resource with the same name could already exist.
+func (d *debouncer) Delete(res, uid string) {
+	d.queue.Add(deletion{res: res, uid: uid})
+	metricsQueueDepth.WithLabelValues(d.kind).Set(float64(d.queue.Len()))
+}
+
+// drain moves the queued items to the pending resources, deletions and retries.
+func (d *debouncer) drain() {
+	for d.queue.Len() > 0 {
+		item, shutdown := d.queue.Get()
+		if shutdown {
+			return
+		}
+		switch i := item.(type) {
+		case string:
+			d.resources[i] = struct{}{}
+			d.versions.drain(i)
+		case deletion:
+			// There is no need to scan a resource which doesn't exist anymore.
+			delete(d.resources, i.res)
+			d.versions.drain(i.res)
+			d.unbuffer(i.res)
+			d.deletions[i.uid] = i.res
+		case retry:
+			d.retries[i.url] = append(d.retries[i.url], i.res)
+		}
+		d.queue.Done(item)
+	}
+	metricsQueueDepth.WithLabelValues(d.kind).Set(0)
+}
+
+// flush schedules the scans of the pending resources and garbage collects the assets of the deleted resources.
+func (d *debouncer) flush(ctx context.Context, managedBy string) {
+	d.drain()
+	if d.isFirstFlush {
+		d.isFirstFlush = false
+		logger.V(1).Info("Skipping the existing resources on startup", "kind", d.kind, "resources", len(d.resources))
+		for res := range d.resources {
+			d.versions.release(res, "", true)
+		}
+		d.versions.complete()
+		d.resources = make(map[string]struct{})
+	}
+	if len(d.resources) == 0 && len(d.deletions) == 0 && len(d.retries) == 0 && len(d.buffered) == 0 &&
+		len(d.bufferedDeletions) == 0 {
+		return
+	}
+	start := time.Now()
+
+	clients := d.scanApiStore.GetAll()
+	d.pruneBuffered(clients)
+	for _, c := range clients {
+		resources := mergeResources(d.retries[c.Url], d.allowedResources(ctx, c))
+		delete(d.retries, c.Url)
+		deletions := d.allowedDeletions(ctx, c)
+		if c.Unhealthy {
+			d.forget(c.Url, resources)
+			d.buffer(c, resources)
+			d.bufferDeletions(c, deletions)
+			continue
+		}
+		for _, b := range d.batches(c, d.replay(c, resources)) {
+			d.schedule(ctx, b, managedBy)
+		}
+		d.garbageCollect(ctx, c, d.replayDeletions(c, deletions), managedBy)
+	}
+	for url, resources := range d.retries {
+		logger.Info("Scan API was removed, dropping retries", "url", url, "resources", len(resources))
+		d.forget(url, resources)
+		d.release(url, resources, true)
+	}
+	d.resources = make(map[string]struct{})
+	d.deletions = make(map[string]string)
+	d.retries = make(map[string][]string)
+	d.versions.complete()
+	if d.onFlush != nil {
+		d.onFlush(ctx, d.versions.checkpoint())
+	}
+	metricsFlushDuration.WithLabelValues(d.kind).Observe(time.Since(start).Seconds())
+}
+
+// allowedResources returns the pending resources which are allowed for the scan client.
+func (d *debouncer) allowedResources(ctx context.Context, c scan_api_store.ClientConfiguration) []string {
+	var allowed []string
+	for res := range d.resources {
+		if d.isAllowed(ctx, c, res) {
+			allowed = append(allowed, res)
+		}
+	}
+	// Sorting keeps the batches stable, which makes them easier to follow in the logs.
+	sort.Strings(allowed)
+	return allowed
+}
+
+// allowedDeletions returns the deleted resources which are allowed for the scan client.
+func (d *debouncer) allowedDeletions(ctx context.Context, c scan_api_store.ClientConfiguration) []deletion {
+	var allowed []deletion
+	for uid, res := range d.deletions {
+		if d.isAllowed(ctx, c, res) {
+			allowed = append(allowed, deletion{res: res, uid: uid})
+		}
+	}
+	sort.Slice(allowed, func(i, j int) bool { return allowed[i].uid < allowed[j].uid })
+	return allowed
+}
+
+// buffer keeps the resources for the unhealthy scan client. The oldest resources are dropped if there are more than
+// maxBuffered resources.
+func (d *debouncer) buffer(c scan_api_store.ClientConfiguration, resources []string) {
+	for _, res := range resources {
+		d.versions.hold(res, c.Url)
+	}
+	buffered := mergeResources(d.buffered[c.Url], resources)
+	if dropped := len(buffered) - d.maxBuffered; dropped > 0 {
+		logger.Info("Too many changes for unhealthy scan API, dropping the oldest", "url", c.Url, "dropped", dropped)
+		d.release(c.Url, buffered[:dropped], true)
+		buffered = buffered[dropped:]
+	}
+	if len(buffered) > 0 {
+		d.buffered[c.Url] = buffered
+	}
+	logger.V(1).Info("Buffering changes for unhealthy scan API", "url", c.Url, "resources", len(buffered))
+}
 
-			clients := d.scanApiStore.GetAll()
-
-			for res := range d.resources {
-				for _, c := range clients {
-					fields := strings.Split(res, ":")
-					if len(fields) != 3 {
-						err := fmt.Errorf("unpacking resource to scan has unexpected number of fields")
-						logger.Error(err, "skipping resource", "request", res)
-						continue
-					}
-					namespace := fields[1]
-					allow, err := utils.AllowNamespace(namespace, c.IncludeNamespaces, c.ExcludeNamespaces)
-					if err != nil {
-						logger.Error(err, "skipping resource", "request", res)
-						continue
-					}
-					if allow {
-						logger.Info("Reconciling change", "request", res, "integration-mrn", c.IntegrationMrn)
-						if _, err := c.Client.ScheduleKubernetesResourceScan(ctx, c.IntegrationMrn, res, managedBy); err != nil {
-							logger.Error(err, "Failed to schedule resource scan", "request", res)
-						}
-					}
-				}
+// bufferDeletions keeps the deleted resources for the unhealthy scan client. The oldest deletions are dropped if
+// there are more than maxBuffered deletions. Their assets are removed by the garbage collection after the next
+// scheduled scan instead.
+func (d *debouncer) bufferDeletions(c scan_api_store.ClientConfiguration, deletions []deletion) {
+	buffered := mergeDeletions(d.bufferedDeletions[c.Url], deletions)
+	if dropped := len(buffered) - d.maxBuffered; dropped > 0 {
+		logger.Info("Too many deletions for unhealthy scan API, dropping the oldest", "url", c.Url, "dropped", dropped)
+		buffered = buffered[dropped:]
+	}
+	if len(buffered) > 0 {
+		d.bufferedDeletions[c.Url] = buffered
+	}
+	logger.V(1).Info("Buffering deletions for unhealthy scan API", "url", c.Url, "deletions", len(buffered))
+}
+
+// unbuffer removes a deleted resource from the buffered resources of all scan apis. There is no need to scan it
+// anymore.
+func (d *debouncer) unbuffer(res string) {
+	for url, buffered := range d.buffered {
+		var kept []string
+		for _, b := range buffered {
+			if b != res {
+				kept = append(kept, b)
 			}
-			d.resources = make(map[string]struct{})
 		}
+		if len(kept) == len(buffered) {
+			continue
+		}
+		d.release(url, []string{res}, true)
+		if len(kept) == 0 {
+			delete(d.buffered, url)
+		} else {
+			d.buffered[url] = kept
+		}
+	}
+}
+
+// replay returns the buffered resources of the scan client together with the new resources and clears the buffer.
+func (d *debouncer) replay(c scan_api_store.ClientConfiguration, resources []string) []string {
+	buffered, ok := d.buffered[c.Url]
+	if !ok {
+		return resources
+	}
+	delete(d.buffered, c.Url)
+	logger.Info("Scan API is healthy again, replaying buffered changes", "url", c.Url, "resources", len(buffered))
+	return mergeResources(buffered, resources)
+}
+
+// replayDeletions returns the buffered deletions of the scan client together with the new deletions and clears
+// the buffer.
+func (d *debouncer) replayDeletions(c scan_api_store.ClientConfiguration, deletions []deletion) []deletion {
+	buffered, ok := d.bufferedDeletions[c.Url]
+	if !ok {
+		return deletions
+	}
+	delete(d.bufferedDeletions, c.Url)
+	logger.Info("Scan API is healthy again, replaying buffered deletions", "url", c.Url, "deletions", len(buffered))
+	return mergeDeletions(buffered, deletions)
+}
+
+// pruneBuffered drops the buffered resources and deletions of scan apis which were removed from the store.
+func (d *debouncer) pruneBuffered(clients []scan_api_store.ClientConfiguration) {
+	urls := make(map[string]struct{}, len(clients))
+	for _, c := range clients {
+		urls[c.Url] = struct{}{}
+	}
+	for url, buffered := range d.buffered {
+		if _, ok := urls[url]; !ok {
+			d.release(url, buffered, true)
+			delete(d.buffered, url)
+		}
+	}
+	for url := range d.bufferedDeletions {
+		if _, ok := urls[url]; !ok {
+			delete(d.bufferedDeletions, url)
+		}
+	}
+}
+
+// mergeResources appends the resources which are not part of the existing resources yet.
+func mergeResources(existing, resources []string) []string {
+	seen := make(map[string]struct{}, len(existing))
+	for _, res := range existing {
+		seen[res] = struct{}{}
+	}
+	merged := existing
+	for _, res := range resources {
+		if _, ok := seen[res]; !ok {
+			merged = append(merged, res)
+		}
+	}
+	return merged
+}
+
+// mergeDeletions appends the deletions whose UID is not part of the existing deletions yet.
+func mergeDeletions(existing, deletions []deletion) []deletion {
+	seen := make(map[string]struct{}, len(existing))
+	for _, del := range existing {
+		seen[del.uid] = struct{}{}
+	}
+	merged := existing
+	for _, del := range deletions {
+		if _, ok := seen[del.uid]; !ok {
+			merged = append(merged, del)
+		}
+	}
+	return merged
+}
+
+// batches groups the resources into batches of at most maxBatchSize resources.
+func (d *debouncer) batches(c scan_api_store.ClientConfiguration, allowed []string) []batch {
+	var batches []batch
+	for len(allowed) > 0 {
+		n := d.maxBatchSize
+		if n > len(allowed) {
+			n = len(allowed)
+		}
+		batches = append(batches, batch{client: c, resources: allowed[:n]})
+		allowed = allowed[n:]
+	}
+	return batches
+}
+
+// schedule sends the scan request for the batch. The resources of a failed batch are retried with an exponential
+// backoff on the following flushes, until maxScheduleRetries is reached.
+func (d *debouncer) schedule(ctx context.Context, b batch, managedBy string) {
+	logger.Info("Reconciling changes", "resources", len(b.resources), "integration-mrn", b.client.IntegrationMrn)
+	logger.V(1).Info("Scheduling resource scan", "requests", b.resources)
+	_, err := b.client.Client.ScheduleKubernetesResourceScans(ctx, b.client.IntegrationMrn, b.resources, managedBy)
+	if err == nil {
+		d.forget(b.client.Url, b.resources)
+		d.release(b.client.Url, b.resources, false)
+		return
+	}
+
+	var dropped []string
+	for _, res := range b.resources {
+		r := retry{res: res, url: b.client.Url}
+		if d.queue.NumRequeues(r) >= maxScheduleRetries {
+			d.queue.Forget(r)
+			d.versions.release(res, r.url, true)
+			dropped = append(dropped, res)
+			continue
+		}
+		d.versions.hold(res, r.url)
+		d.queue.AddRateLimited(r)
+	}
+	if len(dropped) > 0 {
+		logger.Error(err, "Failed to schedule resource scan, giving up", "requests", dropped, "attempts", maxScheduleRetries+1)
+	}
+	if len(dropped) < len(b.resources) {
+		logger.Error(err, "Failed to schedule resource scan, retrying", "requests", b.resources)
 	}
 }
 
-func (d *debouncer) Add(res string) {
-	d.resChan <- res
+// forget resets the retries of the resources for the scan api with the url.
+func (d *debouncer) forget(url string, resources []string) {
+	for _, res := range resources {
+		d.queue.Forget(retry{res: res, url: url})
+	}
+}
+
+// release records that the scan api with the url scheduled the resources, or dropped them.
+func (d *debouncer) release(url string, resources []string, dropped bool) {
+	for _, res := range resources {
+		d.versions.release(res, url, dropped)
+	}
+}
+
+// garbageCollect deletes the assets of the deleted resources with the scan client.
+func (d *debouncer) garbageCollect(
+	ctx context.Context, c scan_api_store.ClientConfiguration, deletions []deletion, managedBy string,
+) {
+	for _, del := range deletions {
+		logger.V(1).Info("Garbage collecting assets of deleted resource", "request", del.res, "uid", del.uid)
+		err := c.Client.GarbageCollectAssets(ctx, &scan.GarbageCollectOptions{
+			ManagedBy: managedBy,
+			Labels:    map[string]string{AssetUIDLabel: del.uid},
+		})
+		if err != nil {
+			logger.Error(err, "Failed to garbage collect assets of deleted resource", "request", del.res, "uid", del.uid)
+		}
+	}
+}
+
+// isAllowed checks whether the namespace of the resource is allowed for the scan client.
+func (d *debouncer) isAllowed(ctx context.Context, c scan_api_store.ClientConfiguration, res string) bool {
+	fields := strings.Split(res, ":")
+	if len(fields) != 3 {
+		err := fmt.Errorf("unpacking resource to scan has unexpected number of fields")
+		logger.Error(err, "skipping resource", "request", res)
+		return false
+	}
+	namespace := fields[1]
+	if namespace == "" {
+		// The namespace filtering doesn't apply to cluster-scoped resources.
+		return true
+	}
+	allow, err := k8s.IsNamespaceAllowed(
+		ctx, d.kubeClient, namespace, c.IncludeNamespaces, c.ExcludeNamespaces, c.NamespaceSelector)
+	if err != nil {
+		logger.Error(err, "skipping resource", "request", res)
+		return false
+	}
+	return allow
 }
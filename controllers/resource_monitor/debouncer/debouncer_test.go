@@ -10,14 +10,22 @@ package debouncer
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/suite"
+	"go.mondoo.com/cnspec/policy/scan"
 	"go.mondoo.com/mondoo-operator/controllers/resource_monitor/scan_api_store"
 	scanapistoremock "go.mondoo.com/mondoo-operator/controllers/resource_monitor/scan_api_store/mock"
+	"go.mondoo.com/mondoo-operator/pkg/mondooclient"
 	"go.mondoo.com/mondoo-operator/pkg/mondooclient/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 type DebouncerSuite struct {
@@ -27,6 +35,7 @@ type DebouncerSuite struct {
 	mockCtrl         *gomock.Controller
 	mockMondooClient *mock.MockClient
 	scanApiStore     *scanapistoremock.MockScanApiStore
+	kubeClient       client.Client
 	debouncer        *debouncer
 }
 
@@ -35,8 +44,11 @@ func (s *DebouncerSuite) BeforeTest(suiteName, testName string) {
 	s.mockCtrl = gomock.NewController(s.T())
 	s.mockMondooClient = mock.NewMockClient(s.mockCtrl)
 	s.scanApiStore = scanapistoremock.NewMockScanApiStore(s.mockCtrl)
-	s.debouncer = NewDebouncer(s.scanApiStore).(*debouncer)
-	s.debouncer.flushTimeout = 1 * time.Second
+	s.kubeClient = fake.NewClientBuilder().WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test-ns", Labels: map[string]string{"team": "payments"}}},
+	).Build()
+	s.debouncer = NewDebouncer(s.kubeClient, s.scanApiStore, Options{FlushInterval: time.Second}).(*debouncer)
 }
 
 func (s *DebouncerSuite) AfterTest(suiteName, testName string) {
@@ -44,29 +56,33 @@ func (s *DebouncerSuite) AfterTest(suiteName, testName string) {
 	s.mockCtrl.Finish()
 }
 
-func (s *DebouncerSuite) TestStart_IgnoreInitialResources() {
+func (s *DebouncerSuite) TestStart_OnFlush() {
+	flushed := make(chan struct{}, 1)
+	s.debouncer.onFlush = func(ctx context.Context, checkpoint uint64) { flushed <- struct{}{} }
 	go s.debouncer.Start(s.ctx, "")
 
-	keys := []string{"pod:default:test", "deployment:test-ns:dep"}
-	for _, k := range keys {
-		for i := 0; i < 100; i++ {
-			s.debouncer.Add(k)
-		}
-	}
+	s.debouncer.Add("pod:default:test", 0)
+
+	s.scanApiStore.EXPECT().GetAll().Times(1).Return([]scan_api_store.ClientConfiguration{})
 
-	time.Sleep(s.debouncer.flushTimeout + 100*time.Millisecond)
+	select {
+	case <-flushed:
+	case <-time.After(s.debouncer.flushInterval + 100*time.Millisecond):
+		s.Fail("onFlush was not called")
+	}
 
-	s.Empty(s.debouncer.resources)
+	// Verify onFlush isn't called again when there are no pending resources.
+	time.Sleep(s.debouncer.flushInterval + 100*time.Millisecond)
+	s.Empty(flushed)
 }
 
 func (s *DebouncerSuite) TestStart_Debounce() {
-	s.debouncer.isFirstFlush = false
 	go s.debouncer.Start(s.ctx, "")
 
 	keys := []string{"pod:default:test", "deployment:test-ns:dep"}
 	for _, k := range keys {
 		for i := 0; i < 100; i++ {
-			s.debouncer.Add(k)
+			s.debouncer.Add(k, 0)
 		}
 	}
 
@@ -75,27 +91,49 @@ func (s *DebouncerSuite) TestStart_Debounce() {
 		{Client: s.mockMondooClient, IntegrationMrn: integrationMrn},
 	})
 
-	// Verify we schedule a scan once per resource.
-	for _, k := range keys {
-		s.mockMondooClient.EXPECT().
-			ScheduleKubernetesResourceScan(gomock.Any(), integrationMrn, k, "").
-			Times(1).
-			Return(nil, nil)
-	}
+	// Verify we schedule a single scan for all resources.
+	s.mockMondooClient.EXPECT().
+		ScheduleKubernetesResourceScans(gomock.Any(), integrationMrn, []string{"deployment:test-ns:dep", "pod:default:test"}, "").
+		Times(1).
+		Return(nil, nil)
 
-	time.Sleep(s.debouncer.flushTimeout + 100*time.Millisecond)
+	time.Sleep(s.debouncer.flushInterval + 100*time.Millisecond)
 
 	s.Empty(s.debouncer.resources)
 }
 
+func (s *DebouncerSuite) TestStart_SkipFirstFlush() {
+	s.debouncer.isFirstFlush = true
+	s.debouncer.flushInterval = 200 * time.Millisecond
+	go s.debouncer.Start(s.ctx, "")
+
+	// The resources of the first flush are observed on startup and are not scanned.
+	s.debouncer.Add("pod:default:existing", 0)
+	time.Sleep(s.debouncer.flushInterval + 100*time.Millisecond)
+
+	integrationMrn := "integration-mrn"
+	s.scanApiStore.EXPECT().GetAll().Times(1).Return([]scan_api_store.ClientConfiguration{
+		{Client: s.mockMondooClient, IntegrationMrn: integrationMrn},
+	})
+	s.mockMondooClient.EXPECT().
+		ScheduleKubernetesResourceScans(gomock.Any(), integrationMrn, []string{"pod:default:changed"}, "").
+		Times(1).
+		Return(nil, nil)
+
+	s.debouncer.Add("pod:default:changed", 0)
+	time.Sleep(s.debouncer.flushInterval + 100*time.Millisecond)
+
+	s.Empty(s.debouncer.resources)
+	s.Zero(s.debouncer.versions.checkpoint())
+}
+
 func (s *DebouncerSuite) TestStart_DebounceManagedBy() {
-	s.debouncer.isFirstFlush = false
 	go s.debouncer.Start(s.ctx, "test")
 
 	keys := []string{"pod:default:test", "deployment:test-ns:dep"}
 	for _, k := range keys {
 		for i := 0; i < 100; i++ {
-			s.debouncer.Add(k)
+			s.debouncer.Add(k, 0)
 		}
 	}
 
@@ -104,46 +142,42 @@ func (s *DebouncerSuite) TestStart_DebounceManagedBy() {
 		{Client: s.mockMondooClient, IntegrationMrn: integrationMrn},
 	})
 
-	// Verify we schedule a scan once per resource.
-	for _, k := range keys {
-		s.mockMondooClient.EXPECT().
-			ScheduleKubernetesResourceScan(gomock.Any(), integrationMrn, k, "test").
-			Times(1).
-			Return(nil, nil)
-	}
+	// Verify we schedule a single scan for all resources.
+	s.mockMondooClient.EXPECT().
+		ScheduleKubernetesResourceScans(gomock.Any(), integrationMrn, []string{"deployment:test-ns:dep", "pod:default:test"}, "test").
+		Times(1).
+		Return(nil, nil)
 
-	time.Sleep(s.debouncer.flushTimeout + 100*time.Millisecond)
+	time.Sleep(s.debouncer.flushInterval + 100*time.Millisecond)
 
 	s.Empty(s.debouncer.resources)
 }
 
 func (s *DebouncerSuite) TestStart_NoScanApiClients() {
-	s.debouncer.isFirstFlush = false
 	go s.debouncer.Start(s.ctx, "")
 
 	keys := []string{"pod:default:test", "deployment:test-ns:dep"}
 	for _, k := range keys {
 		for i := 0; i < 100; i++ {
-			s.debouncer.Add(k)
+			s.debouncer.Add(k, 0)
 		}
 	}
 
 	s.scanApiStore.EXPECT().GetAll().Times(1).Return([]scan_api_store.ClientConfiguration{})
 
-	time.Sleep(s.debouncer.flushTimeout + 100*time.Millisecond)
+	time.Sleep(s.debouncer.flushInterval + 100*time.Millisecond)
 
 	// Verify the resources are flushed even when there are no scan APIs.
 	s.Empty(s.debouncer.resources)
 }
 
 func (s *DebouncerSuite) TestStart_MultipleScanApiClients() {
-	s.debouncer.isFirstFlush = false
 	go s.debouncer.Start(s.ctx, "")
 
 	keys := []string{"pod:default:test", "deployment:test-ns:dep"}
 	for _, k := range keys {
 		for i := 0; i < 100; i++ {
-			s.debouncer.Add(k)
+			s.debouncer.Add(k, 0)
 		}
 	}
 
@@ -155,22 +189,416 @@ func (s *DebouncerSuite) TestStart_MultipleScanApiClients() {
 		{Client: mockMondooClient2, IntegrationMrn: integrationMrn + "2"},
 	})
 
-	// Verify we schedule a scan once per resource per client.
+	// Verify we schedule a single scan per client.
+	s.mockMondooClient.EXPECT().
+		ScheduleKubernetesResourceScans(gomock.Any(), integrationMrn, []string{"deployment:test-ns:dep", "pod:default:test"}, "").
+		Times(1).
+		Return(nil, nil)
+	mockMondooClient2.EXPECT().
+		ScheduleKubernetesResourceScans(gomock.Any(), integrationMrn+"2", []string{"deployment:test-ns:dep", "pod:default:test"}, "").
+		Times(1).
+		Return(nil, nil)
+
+	time.Sleep(s.debouncer.flushInterval + 100*time.Millisecond)
+
+	// Verify the resources are flushed even when there are no scan APIs.
+	s.Empty(s.debouncer.resources)
+}
+
+func (s *DebouncerSuite) TestStart_NamespaceSelector() {
+	go s.debouncer.Start(s.ctx, "")
+
+	keys := []string{"pod:default:test", "deployment:test-ns:dep"}
 	for _, k := range keys {
+		s.debouncer.Add(k, 0)
+	}
+
+	integrationMrn := "integration-mrn"
+	s.scanApiStore.EXPECT().GetAll().Times(1).Return([]scan_api_store.ClientConfiguration{
+		{
+			Client:            s.mockMondooClient,
+			IntegrationMrn:    integrationMrn,
+			NamespaceSelector: labels.SelectorFromSet(labels.Set{"team": "payments"}),
+		},
+	})
+
+	// Verify only the resource in the namespace matching the selector is scanned.
+	s.mockMondooClient.EXPECT().
+		ScheduleKubernetesResourceScans(gomock.Any(), integrationMrn, []string{"deployment:test-ns:dep"}, "").
+		Times(1).
+		Return(nil, nil)
+
+	time.Sleep(s.debouncer.flushInterval + 100*time.Millisecond)
+
+	s.Empty(s.debouncer.resources)
+}
+
+func (s *DebouncerSuite) TestStart_MaxBatchSize() {
+	s.debouncer.maxBatchSize = 2
+	go s.debouncer.Start(s.ctx, "")
+
+	keys := []string{"pod:default:a", "pod:default:b", "pod:default:c", "pod:default:d", "pod:default:e"}
+	for _, k := range keys {
+		s.debouncer.Add(k, 0)
+	}
+
+	integrationMrn := "integration-mrn"
+	s.scanApiStore.EXPECT().GetAll().Times(1).Return([]scan_api_store.ClientConfiguration{
+		{Client: s.mockMondooClient, IntegrationMrn: integrationMrn},
+	})
+
+	// Verify the resources are split into batches of at most maxBatchSize.
+	gomock.InOrder(
+		s.mockMondooClient.EXPECT().
+			ScheduleKubernetesResourceScans(gomock.Any(), integrationMrn, keys[0:2], "").
+			Times(1).
+			Return(nil, nil),
+		s.mockMondooClient.EXPECT().
+			ScheduleKubernetesResourceScans(gomock.Any(), integrationMrn, keys[2:4], "").
+			Times(1).
+			Return(nil, nil),
+		s.mockMondooClient.EXPECT().
+			ScheduleKubernetesResourceScans(gomock.Any(), integrationMrn, keys[4:], "").
+			Times(1).
+			Return(nil, nil),
+	)
+
+	time.Sleep(s.debouncer.flushInterval + 100*time.Millisecond)
+
+	s.Empty(s.debouncer.resources)
+}
+
+func (s *DebouncerSuite) TestStart_RetryFailedBatch() {
+	s.debouncer.queue = newQueue(0)
+	go s.debouncer.Start(s.ctx, "")
+
+	s.debouncer.Add("pod:default:test", 0)
+
+	integrationMrn := "integration-mrn"
+	s.scanApiStore.EXPECT().GetAll().Times(2).Return([]scan_api_store.ClientConfiguration{
+		{Client: s.mockMondooClient, IntegrationMrn: integrationMrn},
+	})
+
+	// Verify the failed batch is scheduled again on the next flush.
+	gomock.InOrder(
 		s.mockMondooClient.EXPECT().
-			ScheduleKubernetesResourceScan(gomock.Any(), integrationMrn, k, "").
+			ScheduleKubernetesResourceScans(gomock.Any(), integrationMrn, []string{"pod:default:test"}, "").
 			Times(1).
-			Return(nil, nil)
-		mockMondooClient2.EXPECT().
-			ScheduleKubernetesResourceScan(gomock.Any(), integrationMrn+"2", k, "").
+			Return(nil, fmt.Errorf("scan API unavailable")),
+		s.mockMondooClient.EXPECT().
+			ScheduleKubernetesResourceScans(gomock.Any(), integrationMrn, []string{"pod:default:test"}, "").
 			Times(1).
-			Return(nil, nil)
+			Return(nil, nil),
+	)
+
+	time.Sleep(2*s.debouncer.flushInterval + 100*time.Millisecond)
+
+	s.Empty(s.debouncer.retries)
+	s.Zero(s.debouncer.queue.NumRequeues(retry{res: "pod:default:test"}))
+}
+
+func (s *DebouncerSuite) TestStart_RetryGivesUp() {
+	s.debouncer.queue = newQueue(0)
+	s.debouncer.flushInterval = 100 * time.Millisecond
+	go s.debouncer.Start(s.ctx, "")
+
+	s.debouncer.Add("pod:default:test", 0)
+
+	integrationMrn := "integration-mrn"
+	s.scanApiStore.EXPECT().GetAll().AnyTimes().Return([]scan_api_store.ClientConfiguration{
+		{Client: s.mockMondooClient, IntegrationMrn: integrationMrn},
+	})
+
+	// Verify the batch is dropped after the initial attempt and maxScheduleRetries retries.
+	s.mockMondooClient.EXPECT().
+		ScheduleKubernetesResourceScans(gomock.Any(), integrationMrn, []string{"pod:default:test"}, "").
+		Times(maxScheduleRetries+1).
+		Return(nil, fmt.Errorf("scan API unavailable"))
+
+	time.Sleep(time.Duration(maxScheduleRetries+3) * s.debouncer.flushInterval)
+
+	s.Empty(s.debouncer.retries)
+	s.Zero(s.debouncer.queue.Len())
+}
+
+func (s *DebouncerSuite) TestStart_CheckpointRetry() {
+	s.debouncer.queue = newQueue(0)
+	checkpoints := make(chan uint64, 10)
+	s.debouncer.onFlush = func(ctx context.Context, checkpoint uint64) { checkpoints <- checkpoint }
+	go s.debouncer.Start(s.ctx, "")
+
+	integrationMrn := "integration-mrn"
+	s.scanApiStore.EXPECT().GetAll().AnyTimes().Return([]scan_api_store.ClientConfiguration{
+		{Client: s.mockMondooClient, IntegrationMrn: integrationMrn},
+	})
+	gomock.InOrder(
+		s.mockMondooClient.EXPECT().
+			ScheduleKubernetesResourceScans(gomock.Any(), integrationMrn, []string{"pod:default:a"}, "").
+			Times(1).
+			Return(nil, nil),
+		s.mockMondooClient.EXPECT().
+			ScheduleKubernetesResourceScans(gomock.Any(), integrationMrn, []string{"pod:default:b"}, "").
+			Times(1).
+			Return(nil, fmt.Errorf("scan API unavailable")),
+		s.mockMondooClient.EXPECT().
+			ScheduleKubernetesResourceScans(gomock.Any(), integrationMrn, []string{"pod:default:b"}, "").
+			Times(1).
+			Return(nil, nil),
+	)
+
+	s.debouncer.Add("pod:default:a", 10)
+	s.Equal(uint64(10), s.receiveCheckpoint(checkpoints))
+
+	// Verify the checkpoint doesn't advance while the scan of the change is retried.
+	s.debouncer.Add("pod:default:b", 20)
+	s.Equal(uint64(10), s.receiveCheckpoint(checkpoints))
+	s.Equal(uint64(20), s.receiveCheckpoint(checkpoints))
+}
+
+func (s *DebouncerSuite) receiveCheckpoint(checkpoints <-chan uint64) uint64 {
+	select {
+	case checkpoint := <-checkpoints:
+		return checkpoint
+	case <-time.After(s.debouncer.flushInterval + time.Second):
+		s.FailNow("onFlush was not called")
+		return 0
 	}
+}
 
-	time.Sleep(s.debouncer.flushTimeout + 100*time.Millisecond)
+func (s *DebouncerSuite) TestStart_Delete() {
+	go s.debouncer.Start(s.ctx, "test")
 
-	// Verify the resources are flushed even when there are no scan APIs.
+	s.debouncer.Add("pod:default:test", 0)
+	s.debouncer.Add("deployment:test-ns:dep", 0)
+	s.debouncer.Delete("pod:default:test", "pod-uid")
+
+	integrationMrn := "integration-mrn"
+	s.scanApiStore.EXPECT().GetAll().Times(1).Return([]scan_api_store.ClientConfiguration{
+		{Client: s.mockMondooClient, IntegrationMrn: integrationMrn},
+	})
+
+	// Verify the deleted resource isn't scanned, but its assets are garbage collected.
+	s.mockMondooClient.EXPECT().
+		ScheduleKubernetesResourceScans(gomock.Any(), integrationMrn, []string{"deployment:test-ns:dep"}, "test").
+		Times(1).
+		Return(nil, nil)
+	s.mockMondooClient.EXPECT().
+		GarbageCollectAssets(gomock.Any(), &scan.GarbageCollectOptions{
+			ManagedBy: "test",
+			Labels:    map[string]string{AssetUIDLabel: "pod-uid"},
+		}).
+		Times(1).
+		Return(nil)
+
+	time.Sleep(s.debouncer.flushInterval + 100*time.Millisecond)
+
+	s.Empty(s.debouncer.resources)
+	s.Empty(s.debouncer.deletions)
+}
+
+func (s *DebouncerSuite) TestStart_DeleteNamespaceSelector() {
+	go s.debouncer.Start(s.ctx, "")
+
+	s.debouncer.Delete("pod:default:test", "pod-uid")
+	s.debouncer.Delete("deployment:test-ns:dep", "dep-uid")
+
+	integrationMrn := "integration-mrn"
+	s.scanApiStore.EXPECT().GetAll().Times(1).Return([]scan_api_store.ClientConfiguration{
+		{
+			Client:            s.mockMondooClient,
+			IntegrationMrn:    integrationMrn,
+			NamespaceSelector: labels.SelectorFromSet(labels.Set{"team": "payments"}),
+		},
+	})
+
+	// Verify only the assets of the resource in the namespace matching the selector are garbage collected.
+	s.mockMondooClient.EXPECT().
+		GarbageCollectAssets(gomock.Any(), &scan.GarbageCollectOptions{
+			Labels: map[string]string{AssetUIDLabel: "dep-uid"},
+		}).
+		Times(1).
+		Return(nil)
+
+	time.Sleep(s.debouncer.flushInterval + 100*time.Millisecond)
+
+	s.Empty(s.debouncer.deletions)
+}
+
+func (s *DebouncerSuite) TestStart_FlushOnShutdown() {
+	done := make(chan struct{})
+	go func() {
+		s.debouncer.Start(s.ctx, "")
+		close(done)
+	}()
+
+	s.debouncer.Add("pod:default:test", 0)
+	s.debouncer.Delete("deployment:test-ns:dep", "dep-uid")
+
+	integrationMrn := "integration-mrn"
+	s.scanApiStore.EXPECT().GetAll().Times(1).Return([]scan_api_store.ClientConfiguration{
+		{Client: s.mockMondooClient, IntegrationMrn: integrationMrn},
+	})
+
+	// Verify the pending changes are flushed when the debouncer is stopped before the next flush.
+	s.mockMondooClient.EXPECT().
+		ScheduleKubernetesResourceScans(gomock.Any(), integrationMrn, []string{"pod:default:test"}, "").
+		Times(1).
+		Return(nil, nil)
+	s.mockMondooClient.EXPECT().
+		GarbageCollectAssets(gomock.Any(), &scan.GarbageCollectOptions{
+			Labels: map[string]string{AssetUIDLabel: "dep-uid"},
+		}).
+		Times(1).
+		Return(nil)
+
+	s.ctxCancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		s.Fail("debouncer was not stopped")
+	}
 	s.Empty(s.debouncer.resources)
+	s.Empty(s.debouncer.deletions)
+}
+
+func (s *DebouncerSuite) TestStart_ShutdownTimeout() {
+	s.debouncer.shutdownTimeout = 100 * time.Millisecond
+	done := make(chan struct{})
+	go func() {
+		s.debouncer.Start(s.ctx, "")
+		close(done)
+	}()
+
+	s.debouncer.Add("pod:default:test", 0)
+
+	integrationMrn := "integration-mrn"
+	s.scanApiStore.EXPECT().GetAll().Times(1).Return([]scan_api_store.ClientConfiguration{
+		{Client: s.mockMondooClient, IntegrationMrn: integrationMrn},
+	})
+
+	// Verify a hanging scan API doesn't block the shutdown longer than the deadline of the final flush.
+	s.mockMondooClient.EXPECT().
+		ScheduleKubernetesResourceScans(gomock.Any(), integrationMrn, []string{"pod:default:test"}, "").
+		Times(1).
+		DoAndReturn(func(ctx context.Context, _ string, _ []string, _ string) (*mondooclient.Empty, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+
+	s.ctxCancel()
+	select {
+	case <-done:
+	case <-time.After(s.debouncer.shutdownTimeout + 500*time.Millisecond):
+		s.Fail("final flush exceeded the shutdown timeout")
+	}
+}
+
+func (s *DebouncerSuite) TestAdd_NonBlocking() {
+	// Verify adding doesn't block while the debouncer isn't consuming the queue.
+	for i := 0; i < 1000; i++ {
+		s.debouncer.Add(fmt.Sprintf("pod:default:test-%d", i), 0)
+	}
+	s.debouncer.Add("pod:default:test-0", 0)
+	s.Equal(1000, s.debouncer.queue.Len())
+}
+
+func (s *DebouncerSuite) TestStart_BufferUnhealthy() {
+	s.debouncer.flushInterval = 200 * time.Millisecond
+	checkpoints := make(chan uint64, 10)
+	s.debouncer.onFlush = func(ctx context.Context, checkpoint uint64) { checkpoints <- checkpoint }
+	go s.debouncer.Start(s.ctx, "")
+
+	integrationMrn := "integration-mrn"
+	unhealthy := scan_api_store.ClientConfiguration{
+		Client: s.mockMondooClient, Url: "scan-api", IntegrationMrn: integrationMrn, Unhealthy: true,
+	}
+	healthy := unhealthy
+	healthy.Unhealthy = false
+	gomock.InOrder(
+		s.scanApiStore.EXPECT().GetAll().Times(2).Return([]scan_api_store.ClientConfiguration{unhealthy}),
+		s.scanApiStore.EXPECT().GetAll().Times(1).Return([]scan_api_store.ClientConfiguration{healthy}),
+	)
+
+	// Verify the changes are buffered while the scan API is unhealthy and replayed once it is healthy again.
+	s.mockMondooClient.EXPECT().
+		ScheduleKubernetesResourceScans(gomock.Any(), integrationMrn, []string{"pod:default:test", "deployment:test-ns:dep"}, "").
+		Times(1).
+		Return(nil, nil)
+
+	s.debouncer.Add("pod:default:test", 10)
+	time.Sleep(s.debouncer.flushInterval + 100*time.Millisecond)
+	s.debouncer.Add("deployment:test-ns:dep", 20)
+	time.Sleep(2*s.debouncer.flushInterval + 100*time.Millisecond)
+
+	s.Empty(s.debouncer.buffered)
+
+	// Verify the checkpoint only advances once the buffered changes were scheduled.
+	s.Equal([]uint64{0, 0, 20}, []uint64{<-checkpoints, <-checkpoints, <-checkpoints})
+}
+
+func (s *DebouncerSuite) TestStart_BufferDeletionsUnhealthy() {
+	s.debouncer.flushInterval = 200 * time.Millisecond
+	go s.debouncer.Start(s.ctx, "test")
+
+	integrationMrn := "integration-mrn"
+	unhealthy := scan_api_store.ClientConfiguration{
+		Client: s.mockMondooClient, Url: "scan-api", IntegrationMrn: integrationMrn, Unhealthy: true,
+	}
+	healthy := unhealthy
+	healthy.Unhealthy = false
+	gomock.InOrder(
+		s.scanApiStore.EXPECT().GetAll().Times(2).Return([]scan_api_store.ClientConfiguration{unhealthy}),
+		s.scanApiStore.EXPECT().GetAll().Times(1).Return([]scan_api_store.ClientConfiguration{healthy}),
+	)
+
+	// Verify the deletions are buffered while the scan API is unhealthy and garbage collected once it is healthy
+	// again. The buffered change of the deleted pod is not scanned anymore.
+	s.mockMondooClient.EXPECT().
+		ScheduleKubernetesResourceScans(gomock.Any(), integrationMrn, []string{"deployment:test-ns:dep"}, "test").
+		Times(1).
+		Return(nil, nil)
+	s.mockMondooClient.EXPECT().
+		GarbageCollectAssets(gomock.Any(), &scan.GarbageCollectOptions{
+			ManagedBy: "test",
+			Labels:    map[string]string{AssetUIDLabel: "pod-uid"},
+		}).
+		Times(1).
+		Return(nil)
+
+	s.debouncer.Add("pod:default:test", 10)
+	s.debouncer.Add("deployment:test-ns:dep", 20)
+	time.Sleep(s.debouncer.flushInterval + 100*time.Millisecond)
+	s.debouncer.Delete("pod:default:test", "pod-uid")
+	time.Sleep(2*s.debouncer.flushInterval + 100*time.Millisecond)
+
+	s.Empty(s.debouncer.buffered)
+	s.Empty(s.debouncer.bufferedDeletions)
+}
+
+func (s *DebouncerSuite) TestBufferDeletions_DropsOldest() {
+	s.debouncer.maxBuffered = 2
+	c := scan_api_store.ClientConfiguration{Url: "scan-api", Unhealthy: true}
+
+	s.debouncer.bufferDeletions(c, []deletion{{res: "pod:default:a", uid: "a"}, {res: "pod:default:b", uid: "b"}})
+	s.debouncer.bufferDeletions(c, []deletion{{res: "pod:default:b", uid: "b"}, {res: "pod:default:c", uid: "c"}})
+	s.Equal([]deletion{{res: "pod:default:b", uid: "b"}, {res: "pod:default:c", uid: "c"}}, s.debouncer.bufferedDeletions["scan-api"])
+
+	// Buffers of scan APIs which were removed from the store are dropped.
+	s.debouncer.pruneBuffered(nil)
+	s.Empty(s.debouncer.bufferedDeletions)
+}
+
+func (s *DebouncerSuite) TestBuffer_DropsOldest() {
+	s.debouncer.maxBuffered = 2
+	c := scan_api_store.ClientConfiguration{Url: "scan-api", Unhealthy: true}
+
+	s.debouncer.buffer(c, []string{"pod:default:a", "pod:default:b"})
+	s.debouncer.buffer(c, []string{"pod:default:b", "pod:default:c"})
+	s.Equal([]string{"pod:default:b", "pod:default:c"}, s.debouncer.buffered["scan-api"])
+
+	// Buffers of scan APIs which were removed from the store are dropped.
+	s.debouncer.pruneBuffered(nil)
+	s.Empty(s.debouncer.buffered)
 }
 
 func TestDebouncerSuite(t *testing.T) {
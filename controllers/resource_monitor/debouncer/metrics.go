@@ -0,0 +1,36 @@
+/*
+Copyright 2022 Mondoo, Inc.
+
+This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at https://mozilla.org/MPL/2.0/.
+*/
+
+package debouncer
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	metricsQueueDepth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mondoo_resource_monitor_queue_depth",
+			Help: "Number of changed and deleted resources waiting for the next flush of the resource monitor",
+		},
+		[]string{"kind"},
+	)
+	metricsFlushDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "mondoo_resource_monitor_flush_duration_seconds",
+			Help:    "Duration of the flushes of the resource monitor, including the requests to the scan APIs",
+			Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10, 30, 60},
+		},
+		[]string{"kind"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(metricsQueueDepth, metricsFlushDuration)
+}
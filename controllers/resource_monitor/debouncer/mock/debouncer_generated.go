@@ -35,15 +35,27 @@ func (m *MockDebouncer) EXPECT() *MockDebouncerMockRecorder {
 }
 
 // Add mocks base method.
-func (m *MockDebouncer) Add(res string) {
+func (m *MockDebouncer) Add(res string, resourceVersion uint64) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "Add", res)
+	m.ctrl.Call(m, "Add", res, resourceVersion)
 }
 
 // Add indicates an expected call of Add.
-func (mr *MockDebouncerMockRecorder) Add(res interface{}) *gomock.Call {
+func (mr *MockDebouncerMockRecorder) Add(res, resourceVersion interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockDebouncer)(nil).Add), res)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockDebouncer)(nil).Add), res, resourceVersion)
+}
+
+// Delete mocks base method.
+func (m *MockDebouncer) Delete(res, uid string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Delete", res, uid)
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockDebouncerMockRecorder) Delete(res, uid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockDebouncer)(nil).Delete), res, uid)
 }
 
 // Start mocks base method.
@@ -0,0 +1,134 @@
+/*
+Copyright 2022 Mondoo, Inc.
+
+This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at https://mozilla.org/MPL/2.0/.
+*/
+
+package debouncer
+
+import "sync"
+
+// versionRange is a range of resourceVersions. The zero value is the empty range.
+type versionRange struct {
+	min, max uint64
+}
+
+func (r *versionRange) add(o versionRange) {
+	if o.min != 0 && (r.min == 0 || o.min < r.min) {
+		r.min = o.min
+	}
+	if o.max > r.max {
+		r.max = o.max
+	}
+}
+
+// resourceVersions are the resourceVersions of a changed resource which were not scheduled for a scan yet.
+type resourceVersions struct {
+	// queued were added since the resource was last drained from the queue.
+	queued versionRange
+	// inFlight were drained from the queue, but the resource wasn't scheduled for a scan by all scan apis yet.
+	inFlight versionRange
+	// pending are the urls of the scan apis which retry or buffer the resource.
+	pending map[string]struct{}
+	// dropped is set if a scan api gave up on the resource.
+	dropped bool
+}
+
+// versionTracker tracks the resourceVersions of the changed resources until they were scheduled for a scan. It
+// computes the checkpoint up to which all changes were scheduled.
+type versionTracker struct {
+	mu        sync.Mutex
+	resources map[string]*resourceVersions
+	// scheduled is the highest resourceVersion which was scheduled for a scan.
+	scheduled uint64
+}
+
+func newVersionTracker() *versionTracker {
+	return &versionTracker{resources: make(map[string]*resourceVersions)}
+}
+
+// queue records the resourceVersion of a change which was added to the queue. A resourceVersion of 0 is unknown
+// and doesn't affect the checkpoint.
+func (t *versionTracker) queue(res string, rv uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	v := t.get(res)
+	v.queued.add(versionRange{min: rv, max: rv})
+}
+
+// drain moves the queued resourceVersions of the resource to the ones in flight.
+func (t *versionTracker) drain(res string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if v, ok := t.resources[res]; ok {
+		v.inFlight.add(v.queued)
+		v.queued = versionRange{}
+	}
+}
+
+// hold records that the scan api with the url still has to schedule the resource.
+func (t *versionTracker) hold(res, url string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.get(res).pending[url] = struct{}{}
+}
+
+// release records that the scan api with the url scheduled the resource, or gave up on it.
+func (t *versionTracker) release(res, url string, dropped bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	v, ok := t.resources[res]
+	if !ok {
+		return
+	}
+	delete(v.pending, url)
+	if dropped {
+		v.dropped = true
+	}
+}
+
+// complete marks the resourceVersions in flight as scheduled for all resources without pending scan apis.
+// The resourceVersions of dropped resources are not scheduled, but they no longer hold back the checkpoint.
+func (t *versionTracker) complete() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for res, v := range t.resources {
+		if len(v.pending) > 0 {
+			continue
+		}
+		if !v.dropped && v.inFlight.max > t.scheduled {
+			t.scheduled = v.inFlight.max
+		}
+		v.inFlight, v.dropped = versionRange{}, false
+		if v.queued == (versionRange{}) {
+			delete(t.resources, res)
+		}
+	}
+}
+
+// checkpoint returns the highest resourceVersion up to which all changes were scheduled for a scan. It is below
+// the lowest resourceVersion which is still queued, retried or buffered.
+func (t *versionTracker) checkpoint() uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	checkpoint := t.scheduled
+	for _, v := range t.resources {
+		for _, r := range []versionRange{v.queued, v.inFlight} {
+			if r.min != 0 && r.min-1 < checkpoint {
+				checkpoint = r.min - 1
+			}
+		}
+	}
+	return checkpoint
+}
+
+func (t *versionTracker) get(res string) *resourceVersions {
+	v, ok := t.resources[res]
+	if !ok {
+		v = &resourceVersions{pending: make(map[string]struct{})}
+		t.resources[res] = v
+	}
+	return v
+}
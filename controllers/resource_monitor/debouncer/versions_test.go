@@ -0,0 +1,82 @@
+/*
+Copyright 2022 Mondoo, Inc.
+
+This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at https://mozilla.org/MPL/2.0/.
+*/
+
+package debouncer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionTracker_Scheduled(t *testing.T) {
+	v := newVersionTracker()
+	v.queue("pod:default:a", 10)
+	v.queue("pod:default:a", 15)
+	v.queue("pod:default:b", 20)
+	v.drain("pod:default:a")
+	v.drain("pod:default:b")
+	v.complete()
+
+	assert.Equal(t, uint64(20), v.checkpoint())
+	assert.Empty(t, v.resources)
+}
+
+func TestVersionTracker_QueuedAfterDrain(t *testing.T) {
+	v := newVersionTracker()
+	v.queue("pod:default:a", 10)
+	v.drain("pod:default:a")
+	// The resource changed again after it was drained, so the scan may not cover the change.
+	v.queue("pod:default:a", 30)
+	v.queue("pod:default:b", 20)
+	v.complete()
+
+	assert.Equal(t, uint64(10), v.checkpoint())
+
+	v.drain("pod:default:a")
+	v.drain("pod:default:b")
+	v.complete()
+	assert.Equal(t, uint64(30), v.checkpoint())
+}
+
+func TestVersionTracker_Pending(t *testing.T) {
+	v := newVersionTracker()
+	v.queue("pod:default:a", 10)
+	v.queue("pod:default:b", 20)
+	v.drain("pod:default:a")
+	v.drain("pod:default:b")
+	// The first scan api retries or buffers the resource, the second one scheduled it.
+	v.hold("pod:default:a", "scan-api-1")
+	v.complete()
+
+	assert.Equal(t, uint64(9), v.checkpoint())
+
+	v.release("pod:default:a", "scan-api-1", false)
+	v.complete()
+	assert.Equal(t, uint64(20), v.checkpoint())
+}
+
+func TestVersionTracker_Dropped(t *testing.T) {
+	v := newVersionTracker()
+	v.queue("pod:default:a", 10)
+	v.drain("pod:default:a")
+	v.hold("pod:default:a", "scan-api")
+	v.complete()
+	assert.Equal(t, uint64(0), v.checkpoint())
+
+	// Dropped resources are not scheduled, but they don't hold back the checkpoint forever.
+	v.release("pod:default:a", "scan-api", true)
+	v.complete()
+	assert.Equal(t, uint64(0), v.checkpoint())
+	assert.Empty(t, v.resources)
+
+	v.queue("pod:default:b", 20)
+	v.drain("pod:default:b")
+	v.complete()
+	assert.Equal(t, uint64(20), v.checkpoint())
+}
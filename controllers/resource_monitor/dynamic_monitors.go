@@ -0,0 +1,271 @@
+/*
+Copyright 2022 Mondoo, Inc.
+
+This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at https://mozilla.org/MPL/2.0/.
+*/
+
+package resource_monitor
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mondoo.com/mondoo-operator/api/v1alpha2"
+	"go.mondoo.com/mondoo-operator/controllers/resource_monitor/debouncer"
+	"go.mondoo.com/mondoo-operator/controllers/resource_monitor/scan_api_store"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// DefaultDiscoveryInterval is the time between two checks for newly created or deleted CRDs of the monitored kinds.
+const DefaultDiscoveryInterval = time.Minute
+
+// DynamicResourceMonitors runs the resource monitors for the kinds configured in the MondooAuditConfigs. Every kind
+// gets its own cache and controller, so the monitor can be stopped when the kind is removed from the configuration
+// or its CRD is deleted. A nil DynamicResourceMonitors ignores the configuration.
+type DynamicResourceMonitors struct {
+	mgr               manager.Manager
+	scanApiStore      scan_api_store.ScanApiStore
+	checkpoints       *CheckpointStore
+	debouncerOpts     debouncer.Options
+	discovery         discovery.DiscoveryInterface
+	discoveryInterval time.Duration
+	// builtin are the kinds which are always monitored by the controllers registered with the manager.
+	builtin map[schema.GroupVersionKind]struct{}
+
+	mu sync.Mutex
+	// desired are the monitored kinds of every MondooAuditConfig.
+	desired map[types.NamespacedName][]schema.GroupVersionKind
+	trigger chan struct{}
+
+	// running is only accessed by the Start loop.
+	running map[schema.GroupVersionKind]*runningMonitor
+	// startMonitor starts the monitor for a kind. It can be replaced in tests.
+	startMonitor func(ctx context.Context, gvk schema.GroupVersionKind) (*runningMonitor, error)
+}
+
+type runningMonitor struct {
+	cancel context.CancelFunc
+	// done is closed when the monitor stopped, e.g. because its cache couldn't sync.
+	done chan struct{}
+}
+
+func NewDynamicResourceMonitors(
+	mgr manager.Manager,
+	scanApiStore scan_api_store.ScanApiStore,
+	checkpoints *CheckpointStore,
+	debouncerOpts debouncer.Options,
+) (*DynamicResourceMonitors, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	d := &DynamicResourceMonitors{
+		mgr:               mgr,
+		scanApiStore:      scanApiStore,
+		checkpoints:       checkpoints,
+		debouncerOpts:     debouncerOpts,
+		discovery:         discoveryClient,
+		discoveryInterval: DefaultDiscoveryInterval,
+		builtin:           make(map[schema.GroupVersionKind]struct{}),
+		desired:           make(map[types.NamespacedName][]schema.GroupVersionKind),
+		trigger:           make(chan struct{}, 1),
+		running:           make(map[schema.GroupVersionKind]*runningMonitor),
+	}
+	for _, r := range resourceTypes {
+		gvk, err := apiutil.GVKForObject(r(), mgr.GetScheme())
+		if err != nil {
+			return nil, err
+		}
+		d.builtin[gvk] = struct{}{}
+	}
+	d.startMonitor = d.start
+	return d, nil
+}
+
+// SetResources sets the monitored kinds of a MondooAuditConfig. The monitors are started and stopped
+// asynchronously. Setting no resources removes the MondooAuditConfig.
+func (d *DynamicResourceMonitors) SetResources(auditConfig types.NamespacedName, resources []v1alpha2.MonitoredResource) {
+	if d == nil {
+		return
+	}
+
+	d.mu.Lock()
+	if len(resources) == 0 {
+		delete(d.desired, auditConfig)
+	} else {
+		gvks := make([]schema.GroupVersionKind, 0, len(resources))
+		for _, r := range resources {
+			gvks = append(gvks, schema.GroupVersionKind{Group: r.Group, Version: r.Version, Kind: r.Kind})
+		}
+		d.desired[auditConfig] = gvks
+	}
+	d.mu.Unlock()
+
+	select {
+	case d.trigger <- struct{}{}:
+	default:
+		// A sync is already pending.
+	}
+}
+
+// Start starts and stops the monitors until the context is done. It implements manager.Runnable.
+func (d *DynamicResourceMonitors) Start(ctx context.Context) error {
+	ticker := time.NewTicker(d.discoveryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			for _, m := range d.running {
+				m.cancel()
+			}
+			return nil
+		case <-d.trigger:
+			d.sync(ctx)
+		case <-ticker.C:
+			d.sync(ctx)
+		}
+	}
+}
+
+// sync starts the monitors of the desired kinds which are served by the API server and stops all others.
+func (d *DynamicResourceMonitors) sync(ctx context.Context) {
+	desired := make(map[schema.GroupVersionKind]struct{})
+	d.mu.Lock()
+	for _, gvks := range d.desired {
+		for _, gvk := range gvks {
+			if _, ok := d.builtin[gvk]; !ok {
+				desired[gvk] = struct{}{}
+			}
+		}
+	}
+	d.mu.Unlock()
+
+	for gvk, m := range d.running {
+		_, ok := desired[gvk]
+		if ok && !isDone(m.done) && d.isServed(gvk) {
+			continue
+		}
+		logger.Info("Stopping resource monitor", "gvk", gvk)
+		m.cancel()
+		delete(d.running, gvk)
+	}
+
+	for gvk := range desired {
+		if _, ok := d.running[gvk]; ok || !d.isServed(gvk) {
+			continue
+		}
+		logger.Info("Starting resource monitor", "gvk", gvk)
+		m, err := d.startMonitor(ctx, gvk)
+		if err != nil {
+			logger.Error(err, "Failed to start resource monitor", "gvk", gvk)
+			continue
+		}
+		d.running[gvk] = m
+	}
+}
+
+// isServed checks whether the API server serves the kind, i.e. whether its CRD exists.
+func (d *DynamicResourceMonitors) isServed(gvk schema.GroupVersionKind) bool {
+	resources, err := d.discovery.ServerResourcesForGroupVersion(gvk.GroupVersion().String())
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Error(err, "Failed to discover resources", "groupVersion", gvk.GroupVersion())
+		}
+		return false
+	}
+	for _, r := range resources.APIResources {
+		// Subresources share the kind of their parent resource.
+		if r.Kind == gvk.Kind && !strings.Contains(r.Name, "/") {
+			return true
+		}
+	}
+	logger.V(1).Info("Kind is not served yet", "gvk", gvk)
+	return false
+}
+
+// start starts a resource monitor for the kind with its own cache.
+func (d *DynamicResourceMonitors) start(ctx context.Context, gvk schema.GroupVersionKind) (*runningMonitor, error) {
+	informers, err := cache.New(d.mgr.GetConfig(), cache.Options{Scheme: d.mgr.GetScheme(), Mapper: d.mgr.GetRESTMapper()})
+	if err != nil {
+		return nil, err
+	}
+	createRes := func() client.Object {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		return obj
+	}
+
+	r, err := NewResourceMonitorController(d.mgr.GetClient(), createRes, d.scanApiStore, d.checkpoints, d.debouncerOpts)
+	if err != nil {
+		return nil, err
+	}
+	r.reader = informers
+	r.checkpointKey = checkpointKey(gvk)
+
+	c, err := controller.NewUnmanaged("resourcemonitor-"+r.checkpointKey, d.mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Watch(source.NewKindWithCache(createRes(), informers), &handler.EnqueueRequestForObject{}, contentChangedPredicate{resourceType: r.resourceType}); err != nil {
+		return nil, err
+	}
+	if err := c.Watch(source.NewKindWithCache(createRes(), informers), handler.Funcs{DeleteFunc: r.onDelete}); err != nil {
+		return nil, err
+	}
+
+	monitorCtx, cancel := context.WithCancel(ctx)
+	m := &runningMonitor{cancel: cancel, done: make(chan struct{})}
+	go func() {
+		if err := informers.Start(monitorCtx); err != nil {
+			logger.Error(err, "Resource monitor cache stopped", "gvk", gvk)
+		}
+	}()
+	go func() {
+		if err := r.Start(monitorCtx); err != nil {
+			logger.Error(err, "Resource monitor debouncer stopped", "gvk", gvk)
+		}
+	}()
+	go func() {
+		defer close(m.done)
+		defer cancel()
+		if err := c.Start(monitorCtx); err != nil {
+			logger.Error(err, "Resource monitor stopped", "gvk", gvk)
+		}
+	}()
+	return m, nil
+}
+
+// checkpointKey is the key of the kind in the checkpoint ConfigMap. The group is part of the key, since different
+// groups can have kinds with the same name.
+func checkpointKey(gvk schema.GroupVersionKind) string {
+	key := strings.ToLower(gvk.Kind)
+	if gvk.Group != "" {
+		key += "." + gvk.Group
+	}
+	return key
+}
+
+func isDone(done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
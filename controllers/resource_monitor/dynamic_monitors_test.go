@@ -0,0 +1,129 @@
+/*
+Copyright 2022 Mondoo, Inc.
+
+This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at https://mozilla.org/MPL/2.0/.
+*/
+
+package resource_monitor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mondoo.com/mondoo-operator/api/v1alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+var (
+	rolloutGVK    = schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"}
+	serviceGVK    = schema.GroupVersionKind{Group: "serving.knative.dev", Version: "v1", Kind: "Service"}
+	deploymentGVK = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+)
+
+func newTestDynamicResourceMonitors(resources ...*metav1.APIResourceList) (*DynamicResourceMonitors, map[schema.GroupVersionKind]int) {
+	discovery := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: resources}}
+	d := &DynamicResourceMonitors{
+		discovery: discovery,
+		builtin:   map[schema.GroupVersionKind]struct{}{deploymentGVK: {}},
+		desired:   make(map[types.NamespacedName][]schema.GroupVersionKind),
+		trigger:   make(chan struct{}, 1),
+		running:   make(map[schema.GroupVersionKind]*runningMonitor),
+	}
+	started := make(map[schema.GroupVersionKind]int)
+	d.startMonitor = func(ctx context.Context, gvk schema.GroupVersionKind) (*runningMonitor, error) {
+		started[gvk]++
+		_, cancel := context.WithCancel(ctx)
+		return &runningMonitor{cancel: cancel, done: make(chan struct{})}, nil
+	}
+	return d, started
+}
+
+func rolloutResources() *metav1.APIResourceList {
+	return &metav1.APIResourceList{
+		GroupVersion: "argoproj.io/v1alpha1",
+		APIResources: []metav1.APIResource{
+			{Name: "rollouts", Kind: "Rollout", Namespaced: true},
+			{Name: "rollouts/status", Kind: "Rollout", Namespaced: true},
+		},
+	}
+}
+
+func TestDynamicResourceMonitors_StartAndStop(t *testing.T) {
+	ctx := context.Background()
+	d, started := newTestDynamicResourceMonitors(rolloutResources())
+	key := types.NamespacedName{Name: "mondoo-client", Namespace: "mondoo-operator"}
+
+	d.SetResources(key, []v1alpha2.MonitoredResource{
+		{Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"},
+		// Built-in kinds are already monitored.
+		{Group: "apps", Version: "v1", Kind: "Deployment"},
+	})
+	d.sync(ctx)
+	assert.Equal(t, map[schema.GroupVersionKind]int{rolloutGVK: 1}, started)
+	assert.Contains(t, d.running, rolloutGVK)
+
+	// Syncing again doesn't restart running monitors.
+	d.sync(ctx)
+	assert.Equal(t, 1, started[rolloutGVK])
+
+	d.SetResources(key, nil)
+	d.sync(ctx)
+	assert.Empty(t, d.running)
+}
+
+func TestDynamicResourceMonitors_CRDCreatedLater(t *testing.T) {
+	ctx := context.Background()
+	d, started := newTestDynamicResourceMonitors()
+	fake := d.discovery.(*fakediscovery.FakeDiscovery)
+
+	d.SetResources(types.NamespacedName{Name: "mondoo-client", Namespace: "mondoo-operator"}, []v1alpha2.MonitoredResource{
+		{Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"},
+		{Group: "serving.knative.dev", Version: "v1", Kind: "Service"},
+	})
+	d.sync(ctx)
+	assert.Empty(t, started)
+
+	// The monitor is started on the first sync after the CRD was created.
+	fake.Resources = []*metav1.APIResourceList{rolloutResources()}
+	d.sync(ctx)
+	assert.Equal(t, map[schema.GroupVersionKind]int{rolloutGVK: 1}, started)
+	assert.NotContains(t, d.running, serviceGVK)
+
+	// The monitor is stopped when the CRD is deleted.
+	fake.Resources = nil
+	d.sync(ctx)
+	assert.Empty(t, d.running)
+}
+
+func TestDynamicResourceMonitors_RestartStopped(t *testing.T) {
+	ctx := context.Background()
+	d, started := newTestDynamicResourceMonitors(rolloutResources())
+
+	d.SetResources(types.NamespacedName{Name: "mondoo-client", Namespace: "mondoo-operator"}, []v1alpha2.MonitoredResource{
+		{Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"},
+	})
+	d.sync(ctx)
+
+	// A monitor which stopped on its own, e.g. because its cache didn't sync, is started again.
+	close(d.running[rolloutGVK].done)
+	d.sync(ctx)
+	d.sync(ctx)
+	assert.Equal(t, 2, started[rolloutGVK])
+}
+
+func TestDynamicResourceMonitors_NilIgnoresResources(t *testing.T) {
+	var d *DynamicResourceMonitors
+	d.SetResources(types.NamespacedName{Name: "mondoo-client"}, []v1alpha2.MonitoredResource{{Version: "v1", Kind: "Service"}})
+}
+
+func TestCheckpointKey(t *testing.T) {
+	assert.Equal(t, "rollout.argoproj.io", checkpointKey(rolloutGVK))
+	assert.Equal(t, "pod", checkpointKey(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}))
+}
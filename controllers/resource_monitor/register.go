@@ -9,7 +9,9 @@ file, You can obtain one at https://mozilla.org/MPL/2.0/.
 package resource_monitor
 
 import (
+	"go.mondoo.com/mondoo-operator/controllers/resource_monitor/debouncer"
 	"go.mondoo.com/mondoo-operator/controllers/resource_monitor/scan_api_store"
+	"go.mondoo.com/mondoo-operator/pkg/utils/k8s"
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -27,15 +29,37 @@ var resourceTypes = []func() client.Object{
 	func() client.Object { return &batchv1.CronJob{} },
 }
 
-func RegisterResourceMonitors(mgr manager.Manager, scanApiStore scan_api_store.ScanApiStore) error {
+// RegisterResourceMonitors registers the resource monitors of the built-in kinds with the manager. The returned
+// DynamicResourceMonitors runs the monitors of the kinds configured in the MondooAuditConfigs. Without checkpoints,
+// the existing resources are skipped when the operator starts, like on the first start with checkpoints.
+func RegisterResourceMonitors(
+	mgr manager.Manager, scanApiStore scan_api_store.ScanApiStore, debouncerOpts debouncer.Options, enableCheckpoints bool,
+) (*DynamicResourceMonitors, error) {
+	var checkpoints *CheckpointStore
+	if enableCheckpoints {
+		namespace, err := k8s.GetRunningNamespace()
+		if err != nil {
+			return nil, err
+		}
+		checkpoints = NewCheckpointStore(mgr.GetClient(), mgr.GetAPIReader(), namespace)
+	}
+
 	for _, r := range resourceTypes {
-		resMon, err := NewResourceMonitorController(mgr.GetClient(), r, scanApiStore)
+		resMon, err := NewResourceMonitorController(mgr.GetClient(), r, scanApiStore, checkpoints, debouncerOpts)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if err := resMon.SetupWithManager(mgr); err != nil {
-			return err
+			return nil, err
 		}
 	}
-	return nil
+
+	dynamicMonitors, err := NewDynamicResourceMonitors(mgr, scanApiStore, checkpoints, debouncerOpts)
+	if err != nil {
+		return nil, err
+	}
+	if err := mgr.Add(dynamicMonitors); err != nil {
+		return nil, err
+	}
+	return dynamicMonitors, nil
 }
@@ -12,14 +12,22 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 
 	"go.mondoo.com/mondoo-operator/controllers/resource_monitor/debouncer"
 	"go.mondoo.com/mondoo-operator/controllers/resource_monitor/scan_api_store"
 	"go.mondoo.com/mondoo-operator/pkg/utils/k8s"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 var logger = log.Log.WithName("resource-monitor")
@@ -28,14 +36,33 @@ type ResourceMonitorController struct {
 	client.Client
 	createRes    func() client.Object
 	debouncer    debouncer.Debouncer
+	gvk          schema.GroupVersionKind
 	resourceType string
 	scanApiStore scan_api_store.ScanApiStore
+	// reader is used to get the reconciled resources. It is the cache of the monitor for dynamically monitored kinds.
+	reader        client.Reader
+	checkpoints   *CheckpointStore
+	checkpointKey string
+
+	// mu guards the checkpoint fields, which are used by the reconciler and the debouncer.
+	mu sync.Mutex
+	// checkpoint is the resourceVersion loaded on startup. Resources with a resourceVersion up to the checkpoint
+	// didn't change since they were last scheduled for a scan.
+	checkpoint       uint64
+	checkpointLoaded bool
+	// saved is the last saved checkpoint.
+	saved uint64
 }
 
+// NewResourceMonitorController creates a resource monitor for the resource type. If checkpoints is nil, the
+// resources which are observed in the first flush after the operator starts are not scanned, since they were
+// scanned before. The changes while the operator was down are only scanned by the next scheduled scan then.
 func NewResourceMonitorController(
 	kubeClient client.Client,
 	createRes func() client.Object,
 	scanApiStore scan_api_store.ScanApiStore,
+	checkpoints *CheckpointStore,
+	debouncerOpts debouncer.Options,
 ) (*ResourceMonitorController, error) {
 	gvk, err := apiutil.GVKForObject(createRes(), kubeClient.Scheme())
 	if err != nil {
@@ -43,20 +70,33 @@ func NewResourceMonitorController(
 		panic(err)
 	}
 
-	return &ResourceMonitorController{
-		Client:       kubeClient,
-		createRes:    createRes,
-		debouncer:    debouncer.NewDebouncer(scanApiStore),
-		resourceType: strings.ToLower(gvk.Kind),
-		scanApiStore: scanApiStore,
-	}, nil
+	r := &ResourceMonitorController{
+		Client:        kubeClient,
+		createRes:     createRes,
+		gvk:           gvk,
+		resourceType:  strings.ToLower(gvk.Kind),
+		scanApiStore:  scanApiStore,
+		reader:        kubeClient,
+		checkpoints:   checkpoints,
+		checkpointKey: strings.ToLower(gvk.Kind),
+	}
+	debouncerOpts.Kind = r.resourceType
+	if checkpoints != nil {
+		debouncerOpts.OnFlush = r.saveCheckpoint
+	} else {
+		debouncerOpts.SkipFirstFlush = true
+	}
+	r.debouncer = debouncer.NewDebouncer(kubeClient, scanApiStore, debouncerOpts)
+	return r, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ResourceMonitorController) SetupWithManager(mgr ctrl.Manager) error {
 	if err := ctrl.NewControllerManagedBy(mgr).
-		For(r.createRes()).
-		WithEventFilter(k8s.CreateUpdateEventsPredicate{}).
+		For(r.createRes(), builder.WithPredicates(contentChangedPredicate{resourceType: r.resourceType})).
+		// Deleted resources can't be reconciled, because their UID is lost by then. The deletions are passed
+		// to the debouncer directly instead.
+		Watches(&source.Kind{Type: r.createRes()}, handler.Funcs{DeleteFunc: r.onDelete}).
 		Complete(r); err != nil {
 		return err
 	}
@@ -87,6 +127,71 @@ func (r *ResourceMonitorController) Start(ctx context.Context) error {
 }
 
 func (r *ResourceMonitorController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	r.debouncer.Add(fmt.Sprintf("%s:%s:%s", r.resourceType, req.Namespace, req.Name))
+	var rv uint64
+	if r.checkpoints != nil {
+		checkpoint, err := r.loadCheckpoint(ctx)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		obj := r.createRes()
+		if err := r.reader.Get(ctx, req.NamespacedName, obj); err != nil && !errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		} else if err == nil {
+			// Objects with an invalid resourceVersion are always scanned.
+			rv, _ = parseResourceVersion(obj.GetResourceVersion())
+			if rv != 0 && rv <= checkpoint {
+				return ctrl.Result{}, nil
+			}
+		}
+	}
+
+	r.debouncer.Add(fmt.Sprintf("%s:%s:%s", r.resourceType, req.Namespace, req.Name), rv)
 	return ctrl.Result{}, nil
 }
+
+// onDelete retires the assets of a deleted resource.
+func (r *ResourceMonitorController) onDelete(e event.DeleteEvent, _ workqueue.RateLimitingInterface) {
+	obj := e.Object
+	if obj == nil || obj.GetUID() == "" {
+		return
+	}
+	r.debouncer.Delete(fmt.Sprintf("%s:%s:%s", r.resourceType, obj.GetNamespace(), obj.GetName()), string(obj.GetUID()))
+}
+
+// loadCheckpoint loads the checkpoint of the resource type on the first call.
+func (r *ResourceMonitorController) loadCheckpoint(ctx context.Context) (uint64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.checkpointLoaded {
+		return r.checkpoint, nil
+	}
+
+	checkpoint, err := r.checkpoints.Load(ctx, r.checkpointKey, r.gvk)
+	if err != nil {
+		logger.Error(err, "Failed to load the checkpoint", "resourceType", r.checkpointKey)
+		return 0, err
+	}
+	logger.V(1).Info("Loaded checkpoint", "resourceType", r.checkpointKey, "checkpoint", checkpoint)
+	r.checkpoint, r.saved, r.checkpointLoaded = checkpoint, checkpoint, true
+	return checkpoint, nil
+}
+
+// saveCheckpoint saves the resourceVersion up to which all changes were scheduled for a scan. It is called by the
+// debouncer after every flush.
+func (r *ResourceMonitorController) saveCheckpoint(ctx context.Context, checkpoint uint64) {
+	r.mu.Lock()
+	saved := r.saved
+	r.mu.Unlock()
+	if checkpoint <= saved {
+		return
+	}
+
+	if err := r.checkpoints.Save(ctx, r.checkpointKey, checkpoint); err != nil {
+		logger.Error(err, "Failed to save the checkpoint", "resourceType", r.checkpointKey)
+		return
+	}
+	r.mu.Lock()
+	r.saved = checkpoint
+	r.mu.Unlock()
+}
@@ -12,10 +12,15 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/suite"
+	"go.mondoo.com/mondoo-operator/controllers/resource_monitor/debouncer"
 	"go.mondoo.com/mondoo-operator/controllers/resource_monitor/debouncer/mock"
+	"go.mondoo.com/mondoo-operator/controllers/resource_monitor/scan_api_store"
+	scanapistoremock "go.mondoo.com/mondoo-operator/controllers/resource_monitor/scan_api_store/mock"
+	mondooclientmock "go.mondoo.com/mondoo-operator/pkg/mondooclient/mock"
 	"go.mondoo.com/mondoo-operator/tests/framework/utils"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -23,6 +28,7 @@ import (
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 )
 
 type ResourceMonitorControllerSuite struct {
@@ -47,13 +53,15 @@ func (s *ResourceMonitorControllerSuite) TestReconcile_Pod() {
 	r, err := NewResourceMonitorController(
 		s.fakeClientBuilder.Build(),
 		func() client.Object { return &corev1.Pod{} },
-		nil)
+		nil,
+		nil,
+		debouncer.Options{})
 	s.Require().NoError(err)
 	r.debouncer = s.debouncerMock
 
 	ns := utils.RandString(10)
 	name := utils.RandString(10)
-	s.debouncerMock.EXPECT().Add(fmt.Sprintf("pod:%s:%s", ns, name)).Times(1)
+	s.debouncerMock.EXPECT().Add(fmt.Sprintf("pod:%s:%s", ns, name), uint64(0)).Times(1)
 
 	res, err := r.Reconcile(ctx, controllerruntime.Request{
 		NamespacedName: types.NamespacedName{
@@ -65,6 +73,105 @@ func (s *ResourceMonitorControllerSuite) TestReconcile_Pod() {
 	s.NoError(err)
 }
 
+func (s *ResourceMonitorControllerSuite) TestWithoutCheckpoints_SkipsExistingResources() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	scanApiStore := scanapistoremock.NewMockScanApiStore(s.mockCtrl)
+	mondooClient := mondooclientmock.NewMockClient(s.mockCtrl)
+	r, err := NewResourceMonitorController(
+		s.fakeClientBuilder.Build(),
+		func() client.Object { return &corev1.Pod{} },
+		scanApiStore,
+		nil,
+		debouncer.Options{FlushInterval: 200 * time.Millisecond})
+	s.Require().NoError(err)
+
+	reconcile := func(name string) {
+		res, err := r.Reconcile(ctx, controllerruntime.Request{
+			NamespacedName: types.NamespacedName{Namespace: "default", Name: name},
+		})
+		s.True(res.IsZero())
+		s.NoError(err)
+	}
+
+	// Verify the pods observed on startup are not scanned again after a restart.
+	reconcile("existing")
+	go r.debouncer.Start(ctx, "")
+	time.Sleep(300 * time.Millisecond)
+
+	scanApiStore.EXPECT().GetAll().Times(1).Return([]scan_api_store.ClientConfiguration{
+		{Client: mondooClient, IntegrationMrn: "integration-mrn"},
+	})
+	mondooClient.EXPECT().
+		ScheduleKubernetesResourceScans(gomock.Any(), "integration-mrn", []string{"pod:default:changed"}, "").
+		Times(1).
+		Return(nil, nil)
+
+	reconcile("changed")
+	time.Sleep(300 * time.Millisecond)
+}
+
+func (s *ResourceMonitorControllerSuite) TestOnDelete() {
+	r, err := NewResourceMonitorController(
+		s.fakeClientBuilder.Build(),
+		func() client.Object { return &corev1.Pod{} },
+		nil,
+		nil,
+		debouncer.Options{})
+	s.Require().NoError(err)
+	r.debouncer = s.debouncerMock
+
+	s.debouncerMock.EXPECT().Delete("pod:default:test", "pod-uid").Times(1)
+
+	r.onDelete(event.DeleteEvent{
+		Object: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", UID: "pod-uid"}},
+	}, nil)
+}
+
+func (s *ResourceMonitorControllerSuite) TestReconcile_Checkpoint() {
+	ctx := context.Background()
+	kubeClient := s.fakeClientBuilder.WithObjects(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: CheckpointConfigMapName, Namespace: "mondoo-operator"},
+			Data:       map[string]string{"pod": "100"},
+		},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "unchanged", Namespace: "default", ResourceVersion: "90"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "changed", Namespace: "default", ResourceVersion: "110"}},
+	).Build()
+	checkpoints := NewCheckpointStore(kubeClient, listVersionReader{Reader: kubeClient, resourceVersion: "120"}, "mondoo-operator")
+	r, err := NewResourceMonitorController(
+		kubeClient,
+		func() client.Object { return &corev1.Pod{} },
+		nil,
+		checkpoints,
+		debouncer.Options{})
+	s.Require().NoError(err)
+	r.debouncer = s.debouncerMock
+
+	// Verify only the pod which changed after the checkpoint is scheduled for a scan.
+	s.debouncerMock.EXPECT().Add("pod:default:changed", uint64(110)).Times(1)
+
+	for _, name := range []string{"unchanged", "changed"} {
+		res, err := r.Reconcile(ctx, controllerruntime.Request{
+			NamespacedName: types.NamespacedName{Namespace: "default", Name: name},
+		})
+		s.True(res.IsZero())
+		s.NoError(err)
+	}
+
+	r.saveCheckpoint(ctx, 110)
+
+	checkpoint, err := checkpoints.Load(ctx, "pod", corev1.SchemeGroupVersion.WithKind("Pod"))
+	s.NoError(err)
+	s.Equal(uint64(110), checkpoint)
+
+	// Verify the checkpoint never moves back.
+	r.saveCheckpoint(ctx, 105)
+	checkpoint, err = checkpoints.Load(ctx, "pod", corev1.SchemeGroupVersion.WithKind("Pod"))
+	s.NoError(err)
+	s.Equal(uint64(110), checkpoint)
+}
+
 func TestResourceMonitorControllerSuite(t *testing.T) {
 	suite.Run(t, new(ResourceMonitorControllerSuite))
 }
@@ -9,6 +9,7 @@ import (
 
 	gomock "github.com/golang/mock/gomock"
 	scan_api_store "go.mondoo.com/mondoo-operator/controllers/resource_monitor/scan_api_store"
+	event "sigs.k8s.io/controller-runtime/pkg/event"
 )
 
 // MockScanApiStore is a mock of ScanApiStore interface.
@@ -72,6 +73,34 @@ func (mr *MockScanApiStoreMockRecorder) GetAll() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockScanApiStore)(nil).GetAll))
 }
 
+// HealthError mocks base method.
+func (m *MockScanApiStore) HealthError(url string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HealthError", url)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HealthError indicates an expected call of HealthError.
+func (mr *MockScanApiStoreMockRecorder) HealthError(url interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HealthError", reflect.TypeOf((*MockScanApiStore)(nil).HealthError), url)
+}
+
+// HealthEvents mocks base method.
+func (m *MockScanApiStore) HealthEvents() <-chan event.GenericEvent {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HealthEvents")
+	ret0, _ := ret[0].(<-chan event.GenericEvent)
+	return ret0
+}
+
+// HealthEvents indicates an expected call of HealthEvents.
+func (mr *MockScanApiStoreMockRecorder) HealthEvents() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HealthEvents", reflect.TypeOf((*MockScanApiStore)(nil).HealthEvents))
+}
+
 // Start mocks base method.
 func (m *MockScanApiStore) Start() {
 	m.ctrl.T.Helper()
@@ -10,11 +10,30 @@ package scan_api_store
 
 import (
 	"context"
+	"fmt"
+	"time"
 
+	"go.mondoo.com/mondoo-operator/api/v1alpha2"
 	"go.mondoo.com/mondoo-operator/pkg/mondooclient"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
+const (
+	// DefaultProbeInterval is the time between two health checks of a healthy scan API.
+	DefaultProbeInterval = 30 * time.Second
+
+	// probeTick is the time between two checks for scan APIs which are due for a health check. It is also the
+	// backoff after the first failed health check.
+	probeTick = 5 * time.Second
+	// maxProbeBackoff is the maximum time between two health checks of an unhealthy scan API.
+	maxProbeBackoff = 5 * time.Minute
+	probeTimeout    = 5 * time.Second
+)
+
 var logger = log.Log.WithName("scan-api-store")
 
 //go:generate ./../../../bin/mockgen -source=./scan_api_store.go -destination=./mock/scan_api_store_generated.go -package=mock
@@ -27,13 +46,29 @@ type ScanApiStore interface {
 	Delete(url string)
 	// GetAll returns all scan api urls.
 	GetAll() []ClientConfiguration
+	// HealthError returns the error of the last health check of the scan api url. It is nil if the scan api is
+	// healthy or was not checked yet.
+	HealthError(url string) error
+	// HealthEvents returns a channel which receives an event for the MondooAuditConfig of a scan api url whenever
+	// the scan api becomes healthy or unhealthy.
+	HealthEvents() <-chan event.GenericEvent
 }
 
 type ClientConfiguration struct {
 	Client            mondooclient.Client
+	Url               string
 	IntegrationMrn    string
 	IncludeNamespaces []string
 	ExcludeNamespaces []string
+	// NamespaceSelector is nil if no namespace selector is configured.
+	NamespaceSelector labels.Selector
+	// Unhealthy is true if the last health check of the scan api failed. Scan apis which were not checked yet are
+	// considered healthy.
+	Unhealthy bool
+	// LastHealthCheck is zero if the scan api was not checked yet.
+	LastHealthCheck time.Time
+	// HealthError is the error of the last health check.
+	HealthError error
 }
 
 type requestType string
@@ -46,10 +81,12 @@ const (
 type urlRequest struct {
 	requestType       requestType
 	url               string
+	auditConfig       types.NamespacedName
 	token             string
 	integrationMrn    string
 	includeNamespaces []string
 	excludeNamespaces []string
+	namespaceSelector labels.Selector
 }
 
 type scanApiStore struct {
@@ -65,6 +102,27 @@ type scanApiStore struct {
 	outChan             chan []ClientConfiguration
 	scanClients         map[string]ClientConfiguration
 	mondooClientBuilder func(mondooclient.ClientOptions) mondooclient.Client
+
+	// health is the health of every scan api url. It is kept when a scan api url is added again.
+	health        map[string]*endpointHealth
+	probeInterval time.Duration
+	probeTick     time.Duration
+	probeResults  chan probeResult
+	healthEvents  chan event.GenericEvent
+}
+
+type endpointHealth struct {
+	auditConfig types.NamespacedName
+	failures    int
+	lastProbe   time.Time
+	lastErr     error
+	nextProbe   time.Time
+	probing     bool
+}
+
+type probeResult struct {
+	url string
+	err error
 }
 
 func NewScanApiStore(ctx context.Context) ScanApiStore {
@@ -75,10 +133,17 @@ func NewScanApiStore(ctx context.Context) ScanApiStore {
 		outChan:             make(chan []ClientConfiguration),
 		scanClients:         make(map[string]ClientConfiguration),
 		mondooClientBuilder: mondooclient.NewClient,
+		health:              make(map[string]*endpointHealth),
+		probeInterval:       DefaultProbeInterval,
+		probeTick:           probeTick,
+		probeResults:        make(chan probeResult),
+		healthEvents:        make(chan event.GenericEvent, 100),
 	}
 }
 
 func (s *scanApiStore) Start() {
+	ticker := time.NewTicker(s.probeTick)
+	defer ticker.Stop()
 	for {
 		select {
 		case <-s.ctx.Done():
@@ -89,31 +154,127 @@ func (s *scanApiStore) Start() {
 				s.scanClients[req.url] = ClientConfiguration{
 					Client: s.mondooClientBuilder(
 						mondooclient.ClientOptions{ApiEndpoint: req.url, Token: req.token}),
+					Url:               req.url,
 					IntegrationMrn:    req.integrationMrn,
 					IncludeNamespaces: req.includeNamespaces,
 					ExcludeNamespaces: req.excludeNamespaces,
+					NamespaceSelector: req.namespaceSelector,
+				}
+				if h, ok := s.health[req.url]; ok {
+					h.auditConfig = req.auditConfig
+				} else {
+					s.health[req.url] = &endpointHealth{auditConfig: req.auditConfig}
 				}
 			case DeleteRequest:
 				delete(s.scanClients, req.url)
+				delete(s.health, req.url)
 			default:
 				logger.Error(nil, "Unknown request type", "requestType", req.requestType)
 			}
 		case <-s.getChan:
 			clients := make([]ClientConfiguration, 0, len(s.scanClients))
-			for _, c := range s.scanClients {
+			for url, c := range s.scanClients {
+				if h, ok := s.health[url]; ok {
+					c.Unhealthy = h.lastErr != nil
+					c.LastHealthCheck = h.lastProbe
+					c.HealthError = h.lastErr
+				}
 				clients = append(clients, c)
 			}
 			s.outChan <- clients
+		case <-ticker.C:
+			s.probe()
+		case res := <-s.probeResults:
+			s.updateHealth(res)
+		}
+	}
+}
+
+// probe starts the health checks of all scan apis which are due. The health checks run concurrently, so a scan
+// api which doesn't respond doesn't block the store.
+func (s *scanApiStore) probe() {
+	now := time.Now()
+	for url, h := range s.health {
+		c, ok := s.scanClients[url]
+		if !ok || h.probing || now.Before(h.nextProbe) {
+			continue
 		}
+		h.probing = true
+		go func(url string, client mondooclient.Client) {
+			ctx, cancel := context.WithTimeout(s.ctx, probeTimeout)
+			defer cancel()
+			err := checkHealth(ctx, client)
+			select {
+			case s.probeResults <- probeResult{url: url, err: err}:
+			case <-s.ctx.Done():
+			}
+		}(url, c.Client)
 	}
 }
 
+// updateHealth records the result of a health check. Unhealthy scan apis are checked again with an exponential
+// backoff. An event is sent for the MondooAuditConfig whenever the health of the scan api changes.
+func (s *scanApiStore) updateHealth(res probeResult) {
+	h, ok := s.health[res.url]
+	if !ok {
+		// The scan api was deleted while it was checked.
+		return
+	}
+	wasHealthy := h.lastErr == nil
+	h.probing = false
+	h.lastProbe = time.Now()
+	h.lastErr = res.err
+
+	if res.err == nil {
+		if !wasHealthy {
+			logger.Info("Scan API is healthy again", "url", res.url)
+		}
+		h.failures = 0
+		h.nextProbe = h.lastProbe.Add(s.probeInterval)
+	} else {
+		if wasHealthy {
+			logger.Error(res.err, "Scan API is unhealthy", "url", res.url)
+		}
+		backoff := s.probeTick << h.failures
+		if backoff > maxProbeBackoff || backoff <= 0 {
+			backoff = maxProbeBackoff
+		}
+		h.failures++
+		h.nextProbe = h.lastProbe.Add(backoff)
+	}
+
+	if wasHealthy != (res.err == nil) && h.auditConfig.Name != "" {
+		mac := &v1alpha2.MondooAuditConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: h.auditConfig.Name, Namespace: h.auditConfig.Namespace},
+		}
+		select {
+		case s.healthEvents <- event.GenericEvent{Object: mac}:
+		default:
+			logger.V(1).Info("Dropping scan API health event, too many pending events", "url", res.url)
+		}
+	}
+}
+
+func checkHealth(ctx context.Context, client mondooclient.Client) error {
+	res, err := client.HealthCheck(ctx, &mondooclient.HealthCheckRequest{})
+	if err != nil {
+		return err
+	}
+	if res.Status != "SERVING" {
+		return fmt.Errorf("scan API health check returned status %q", res.Status)
+	}
+	return nil
+}
+
 type ScanApiStoreAddOpts struct {
-	Url               string
+	Url string
+	// AuditConfig is the MondooAuditConfig of the scan api. It receives the health events of the scan api.
+	AuditConfig       types.NamespacedName
 	Token             string
 	IntegrationMrn    string
 	IncludeNamespaces []string
 	ExcludeNamespaces []string
+	NamespaceSelector labels.Selector
 }
 
 // Add adds a scan api url to the store. The operatorion is idempotent.
@@ -121,10 +282,12 @@ func (s *scanApiStore) Add(opts *ScanApiStoreAddOpts) {
 	s.urlReqChan <- urlRequest{
 		requestType:       AddRequest,
 		url:               opts.Url,
+		auditConfig:       opts.AuditConfig,
 		token:             opts.Token,
 		integrationMrn:    opts.IntegrationMrn,
 		includeNamespaces: opts.IncludeNamespaces,
 		excludeNamespaces: opts.ExcludeNamespaces,
+		namespaceSelector: opts.NamespaceSelector,
 	}
 }
 
@@ -141,3 +304,20 @@ func (s *scanApiStore) GetAll() []ClientConfiguration {
 	s.getChan <- struct{}{}
 	return <-s.outChan
 }
+
+// HealthError returns the error of the last health check of the scan api url. It is nil if the scan api is
+// healthy or was not checked yet.
+func (s *scanApiStore) HealthError(url string) error {
+	for _, c := range s.GetAll() {
+		if c.Url == url {
+			return c.HealthError
+		}
+	}
+	return nil
+}
+
+// HealthEvents returns a channel which receives an event for the MondooAuditConfig of a scan api url whenever
+// the scan api becomes healthy or unhealthy.
+func (s *scanApiStore) HealthEvents() <-chan event.GenericEvent {
+	return s.healthEvents
+}
@@ -10,13 +10,17 @@ package scan_api_store
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/suite"
 	"go.mondoo.com/mondoo-operator/pkg/mondooclient"
 	"go.mondoo.com/mondoo-operator/pkg/mondooclient/mock"
 	"go.mondoo.com/mondoo-operator/tests/framework/utils"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 )
 
 type ScanApiStoreSuite struct {
@@ -129,6 +133,73 @@ func (s *ScanApiStoreSuite) TestDelete() {
 	s.Equal(integrationMrn2, clients[0].IntegrationMrn)
 }
 
+func (s *ScanApiStoreSuite) TestHealthCheck() {
+	s.scanApiStore.probeTick = 10 * time.Millisecond
+	s.scanApiStore.probeInterval = 10 * time.Millisecond
+	go s.scanApiStore.Start()
+
+	url := utils.RandString(10)
+	auditConfig := types.NamespacedName{Name: "mondoo-client", Namespace: "mondoo-operator"}
+	s.scanApiStore.mondooClientBuilder = func(opts mondooclient.ClientOptions) mondooclient.Client {
+		return s.mockMondooClient
+	}
+
+	gomock.InOrder(
+		s.mockMondooClient.EXPECT().HealthCheck(gomock.Any(), gomock.Any()).
+			Return(nil, fmt.Errorf("connection refused")),
+		s.mockMondooClient.EXPECT().HealthCheck(gomock.Any(), gomock.Any()).
+			Return(&mondooclient.HealthCheckResponse{Status: "SERVING"}, nil).
+			AnyTimes(),
+	)
+
+	s.scanApiStore.Add(&ScanApiStoreAddOpts{Url: url, Token: "token", AuditConfig: auditConfig})
+
+	// Verify the scan API is marked unhealthy and the MondooAuditConfig is notified.
+	e := s.receiveHealthEvent()
+	s.Equal(auditConfig.Name, e.Object.GetName())
+	s.Equal(auditConfig.Namespace, e.Object.GetNamespace())
+
+	// Verify the scan API is marked healthy again once the health check succeeds.
+	s.receiveHealthEvent()
+	clients := s.scanApiStore.GetAll()
+	s.Require().Equal(1, len(clients))
+	s.False(clients[0].Unhealthy)
+	s.False(clients[0].LastHealthCheck.IsZero())
+	s.NoError(s.scanApiStore.HealthError(url))
+}
+
+func (s *ScanApiStoreSuite) TestHealthCheck_Backoff() {
+	store := s.scanApiStore
+	store.health["url"] = &endpointHealth{}
+	store.scanClients["url"] = ClientConfiguration{Url: "url"}
+
+	for i, backoff := range []time.Duration{probeTick, 2 * probeTick, 4 * probeTick} {
+		store.updateHealth(probeResult{url: "url", err: fmt.Errorf("connection refused")})
+		h := store.health["url"]
+		s.Equal(i+1, h.failures)
+		s.Equal(h.lastProbe.Add(backoff), h.nextProbe)
+	}
+
+	store.health["url"].failures = 100
+	store.updateHealth(probeResult{url: "url", err: fmt.Errorf("connection refused")})
+	h := store.health["url"]
+	s.Equal(h.lastProbe.Add(maxProbeBackoff), h.nextProbe)
+
+	store.updateHealth(probeResult{url: "url"})
+	s.Equal(0, h.failures)
+	s.Equal(h.lastProbe.Add(store.probeInterval), h.nextProbe)
+}
+
+func (s *ScanApiStoreSuite) receiveHealthEvent() event.GenericEvent {
+	select {
+	case e := <-s.scanApiStore.HealthEvents():
+		return e
+	case <-time.After(time.Second):
+		s.FailNow("no health event received")
+		return event.GenericEvent{}
+	}
+}
+
 func TestScanApiStoreSuite(t *testing.T) {
 	suite.Run(t, new(ScanApiStoreSuite))
 }
@@ -33,13 +33,19 @@ func HandleAuditConfig(ctx context.Context, kubeClient client.Client, scanApiSto
 		if err != nil {
 			return err
 		}
+		namespaceSelector, err := k8s.NamespaceSelector(auditConfig.Spec.Filtering)
+		if err != nil {
+			return err
+		}
 
 		opts := &ScanApiStoreAddOpts{
 			Url:               scanapi.ScanApiServiceUrl(auditConfig),
+			AuditConfig:       client.ObjectKeyFromObject(&auditConfig),
 			Token:             string(secret.Data[constants.MondooTokenSecretKey]),
 			IntegrationMrn:    integrationMrn,
 			IncludeNamespaces: auditConfig.Spec.Filtering.Namespaces.Include,
 			ExcludeNamespaces: auditConfig.Spec.Filtering.Namespaces.Exclude,
+			NamespaceSelector: namespaceSelector,
 		}
 		scanApiStore.Add(opts)
 	}
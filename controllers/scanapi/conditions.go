@@ -17,7 +17,11 @@ import (
 	corev1 "k8s.io/api/core/v1"
 )
 
-func updateScanAPIConditions(config *mondoov1alpha2.MondooAuditConfig, degradedStatus bool, conditions []appsv1.DeploymentCondition) {
+// updateScanAPIConditions sets the ScanAPIDegraded condition. The scan API is degraded if its Deployment is unavailable or
+// if it is unreachable, according to the healthErr of the last health check.
+func updateScanAPIConditions(
+	config *mondoov1alpha2.MondooAuditConfig, degradedStatus bool, conditions []appsv1.DeploymentCondition, healthErr error,
+) {
 	msg := "ScanAPI controller is available"
 	reason := "ScanAPIAvailable"
 	status := corev1.ConditionFalse
@@ -40,6 +44,10 @@ func updateScanAPIConditions(config *mondoov1alpha2.MondooAuditConfig, degradedS
 
 		reason = "ScanAPIUnvailable"
 		status = corev1.ConditionTrue
+	} else if healthErr != nil {
+		msg = "ScanAPI is unreachable: " + healthErr.Error()
+		reason = "ScanAPIUnreachable"
+		status = corev1.ConditionTrue
 	}
 
 	config.Status.Conditions = mondoo.SetMondooAuditCondition(config.Status.Conditions, mondoov1alpha2.ScanAPIDegraded, status, reason, msg, updateCheck)
@@ -31,6 +31,8 @@ type DeploymentHandler struct {
 	ContainerImageResolver mondoo.ContainerImageResolver
 	MondooOperatorConfig   *v1alpha2.MondooOperatorConfig
 	DeployOnOpenShift      bool
+	// HealthError returns the error of the last health check of the scan API url. It is optional.
+	HealthError func(url string) error
 }
 
 func (n *DeploymentHandler) Reconcile(ctx context.Context) (ctrl.Result, error) {
@@ -70,7 +72,7 @@ func (n *DeploymentHandler) down(ctx context.Context) error {
 	}
 
 	// Make sure to clear any degraded status
-	updateScanAPIConditions(n.Mondoo, false, []appsv1.DeploymentCondition{})
+	updateScanAPIConditions(n.Mondoo, false, []appsv1.DeploymentCondition{}, nil)
 
 	return nil
 }
@@ -148,7 +150,11 @@ func (n *DeploymentHandler) syncDeployment(ctx context.Context) error {
 		return nil
 	}
 
-	updateScanAPIConditions(n.Mondoo, existingDeployment.Status.UnavailableReplicas != 0, existingDeployment.Status.Conditions)
+	var healthErr error
+	if n.HealthError != nil {
+		healthErr = n.HealthError(ScanApiServiceUrl(*n.Mondoo))
+	}
+	updateScanAPIConditions(n.Mondoo, existingDeployment.Status.UnavailableReplicas != 0, existingDeployment.Status.Conditions, healthErr)
 
 	if !k8s.AreDeploymentsEqual(*deployment, existingDeployment) {
 		logger.Info("Update needed for scan API Deployment")
@@ -10,6 +10,7 @@ package scanapi
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"testing"
 
@@ -358,6 +359,38 @@ func (s *DeploymentHandlerSuite) TestDeploy_CreateMissingServiceAccount() {
 	s.Assertions.Truef(foundMissingServiceAccountCondition, "No Condition for missing service account found")
 }
 
+func (s *DeploymentHandlerSuite) TestReconcile_Unreachable() {
+	s.auditConfig = utils.DefaultAuditConfig("test-ns", true, false, false, false)
+	image, err := s.containerImageResolver.CnspecImage(
+		s.auditConfig.Spec.Scanner.Image.Name, s.auditConfig.Spec.Scanner.Image.Tag, false)
+	s.NoError(err)
+
+	deployment := ScanApiDeployment(s.auditConfig.Namespace, image, s.auditConfig, "", false)
+	s.fakeClientBuilder = s.fakeClientBuilder.WithObjects(&s.auditConfig, deployment)
+
+	d := s.createDeploymentHandler()
+	d.HealthError = func(url string) error {
+		s.Equal(ScanApiServiceUrl(s.auditConfig), url)
+		return fmt.Errorf("connection refused")
+	}
+	result, err := d.Reconcile(s.ctx)
+	s.NoError(err)
+	s.True(result.IsZero())
+
+	condition := mondoo.FindMondooAuditConditions(s.auditConfig.Status.Conditions, mondoov1alpha2.ScanAPIDegraded)
+	s.Require().NotNil(condition)
+	s.Equal(corev1.ConditionTrue, condition.Status)
+	s.Equal("ScanAPIUnreachable", condition.Reason)
+	s.Equal("ScanAPI is unreachable: connection refused", condition.Message)
+
+	// The condition is cleared once the scan API is reachable again.
+	d.HealthError = func(string) error { return nil }
+	_, err = d.Reconcile(s.ctx)
+	s.NoError(err)
+	condition = mondoo.FindMondooAuditConditions(s.auditConfig.Status.Conditions, mondoov1alpha2.ScanAPIDegraded)
+	s.Equal(corev1.ConditionFalse, condition.Status)
+}
+
 func (s *DeploymentHandlerSuite) TestReconcile_Update() {
 	image, err := s.containerImageResolver.CnspecImage(
 		s.auditConfig.Spec.Scanner.Image.Name, s.auditConfig.Spec.Scanner.Image.Tag, false)
@@ -24,6 +24,7 @@ import (
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 
 	mondoov1alpha2 "go.mondoo.com/mondoo-operator/api/v1alpha2"
+	"go.mondoo.com/mondoo-operator/controllers/admission"
 	"go.mondoo.com/mondoo-operator/pkg/utils/k8s"
 	"go.mondoo.com/mondoo-operator/pkg/utils/mondoo"
 )
@@ -37,14 +38,19 @@ func (s *ServiceMonitor) serviceMonitorName() string {
 	return "mondoo-operator-metrics-monitor"
 }
 
-func (s *ServiceMonitor) declareServiceMonitor(ctx context.Context, clt client.Client, scheme *runtime.Scheme) (ctrl.Result, error) {
+func (s *ServiceMonitor) webhookServiceMonitorName() string {
+	return "mondoo-operator-webhook-metrics-monitor"
+}
+
+func (s *ServiceMonitor) declareServiceMonitor(
+	ctx context.Context, clt client.Client, scheme *runtime.Scheme, declared *monitoringv1.ServiceMonitor,
+) (ctrl.Result, error) {
 	log := ctrllog.FromContext(ctx)
 
 	found := &monitoringv1.ServiceMonitor{}
-	err := clt.Get(ctx, types.NamespacedName{Name: s.serviceMonitorName(), Namespace: s.TargetNamespace}, found)
+	err := clt.Get(ctx, types.NamespacedName{Name: declared.Name, Namespace: s.TargetNamespace}, found)
 	if err != nil && errors.IsNotFound(err) {
 
-		declared := s.serviceMonitorForMondoo(s.Config)
 		if err := ctrl.SetControllerReference(s.Config, declared, scheme); err != nil {
 			log.Error(err, "Failed to set ControllerReference", "ServiceMonitor.Namespace", declared.Namespace, "ServiceMonitor.Name", declared.Name)
 			return ctrl.Result{}, err
@@ -60,7 +66,6 @@ func (s *ServiceMonitor) declareServiceMonitor(ctx context.Context, clt client.C
 
 	} else if err == nil {
 
-		declared := s.serviceMonitorForMondoo(s.Config)
 		if !reflect.DeepEqual(found.Spec, declared.Spec) {
 			found.Spec = declared.Spec
 			err = clt.Update(ctx, found)
@@ -113,6 +118,38 @@ func (s *ServiceMonitor) serviceMonitorForMondoo(m *mondoov1alpha2.MondooOperato
 	return dep
 }
 
+// webhookServiceMonitorForMondoo returns the ServiceMonitor for the admission webhooks. The webhooks run in the
+// namespaces of their MondooAuditConfigs, so the metrics Services are selected in all namespaces.
+func (s *ServiceMonitor) webhookServiceMonitorForMondoo(m *mondoov1alpha2.MondooOperatorConfig) *monitoringv1.ServiceMonitor {
+	ls := labelsForMondoo(m.Name)
+	for key, value := range s.Config.Spec.Metrics.ResourceLabels {
+		ls[key] = value
+	}
+	return &monitoringv1.ServiceMonitor{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      s.webhookServiceMonitorName(),
+			Namespace: s.TargetNamespace,
+			Labels:    ls,
+		},
+		Spec: monitoringv1.ServiceMonitorSpec{
+			Endpoints: []monitoringv1.Endpoint{
+				{
+					Path: "/metrics",
+					// The named port of the webhook metrics Service
+					Port:   "metrics",
+					Scheme: "http",
+				},
+			},
+			Selector: metav1.LabelSelector{
+				MatchLabels: admission.WebhookDeploymentLabels(),
+			},
+			NamespaceSelector: monitoringv1.NamespaceSelector{
+				Any: true,
+			},
+		},
+	}
+}
+
 func (s *ServiceMonitor) Reconcile(ctx context.Context, clt client.Client, scheme *runtime.Scheme, req ctrl.Request) (ctrl.Result, error) {
 	log := ctrllog.FromContext(ctx)
 	found, err := k8s.VerifyAPI(monitoringv1.SchemeGroupVersion.Group, monitoringv1.SchemeGroupVersion.Version)
@@ -132,10 +169,14 @@ func (s *ServiceMonitor) Reconcile(ctx context.Context, clt client.Client, schem
 			// exit early as there is no ServiceMonitor CRD
 			return ctrl.Result{}, nil
 		}
-		// Create/Update the ServiceMonitor
-		result, err := s.declareServiceMonitor(ctx, clt, scheme)
-		if err != nil || result.Requeue {
-			return result, err
+		// Create/Update the ServiceMonitors
+		for _, declared := range []*monitoringv1.ServiceMonitor{
+			s.serviceMonitorForMondoo(s.Config), s.webhookServiceMonitorForMondoo(s.Config),
+		} {
+			result, err := s.declareServiceMonitor(ctx, clt, scheme, declared)
+			if err != nil || result.Requeue {
+				return result, err
+			}
 		}
 	} else {
 		if found {
@@ -163,22 +204,26 @@ func updatePrometheusNotInstalledCondition(config *mondoov1alpha2.MondooOperator
 func (s *ServiceMonitor) down(ctx context.Context, clt client.Client) (ctrl.Result, error) {
 	log := ctrllog.FromContext(ctx)
 
-	found := &monitoringv1.ServiceMonitor{}
-	err := clt.Get(ctx, types.NamespacedName{Name: s.serviceMonitorName(), Namespace: s.TargetNamespace}, found)
+	requeue := false
+	for _, name := range []string{s.serviceMonitorName(), s.webhookServiceMonitorName()} {
+		found := &monitoringv1.ServiceMonitor{}
+		err := clt.Get(ctx, types.NamespacedName{Name: name, Namespace: s.TargetNamespace}, found)
 
-	if err != nil && errors.IsNotFound(err) {
-		return ctrl.Result{}, nil
-	} else if err != nil {
-		log.Error(err, "Failed to get ServiceMonitor")
-		return ctrl.Result{}, err
-	}
-
-	// If the ServiceMonitor was created by mondoo-operator then delete it
-	if found.Labels["app"] == "mondoo" {
-		if err := clt.Delete(ctx, found); err != nil {
-			log.Error(err, "Failed to delete ServiceMonitor", "ServiceMonitor.Namespace", found.Namespace, "ServiceMonitor.Name", found.Name)
+		if err != nil && errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			log.Error(err, "Failed to get ServiceMonitor")
 			return ctrl.Result{}, err
 		}
+
+		// If the ServiceMonitor was created by mondoo-operator then delete it
+		if found.Labels["app"] == "mondoo" {
+			if err := clt.Delete(ctx, found); err != nil {
+				log.Error(err, "Failed to delete ServiceMonitor", "ServiceMonitor.Namespace", found.Namespace, "ServiceMonitor.Name", found.Name)
+				return ctrl.Result{}, err
+			}
+		}
+		requeue = true
 	}
-	return ctrl.Result{Requeue: true}, err
+	return ctrl.Result{Requeue: requeue}, nil
 }
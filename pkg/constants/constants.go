@@ -13,4 +13,11 @@ const (
 	// MondooAssetsIntegrationLabel is the label we set for any assets whenever the consoleIntegration is enabled
 	// (for consistency with other integrations, the integration tag will not use the 'k8s' prefix)
 	MondooAssetsIntegrationLabel = "mondoo.com/" + "integration-mrn"
+	// AdmissionModeNamespaceLabel is the label on a Namespace which overrides the admission mode of the
+	// MondooAuditConfig for that Namespace. Valid values are "enforcing", "permissive", "audit" and "disabled".
+	AdmissionModeNamespaceLabel = "admission.k8s.mondoo.com/mode"
+	// AdmissionModeDisabled is the AdmissionModeNamespaceLabel value which turns off admission checks for a Namespace
+	AdmissionModeDisabled = "disabled"
+	// AdmissionSkipAnnotation exempts a resource from the admission checks. The value is the reason for the exemption.
+	AdmissionSkipAnnotation = "admission.k8s.mondoo.com/skip"
 )
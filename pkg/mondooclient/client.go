@@ -44,6 +44,7 @@ type Client interface {
 	RunAdmissionReview(context.Context, *AdmissionReviewJob) (*ScanResult, error)
 	ScanKubernetesResources(ctx context.Context, scanOpts *ScanKubernetesResourcesOpts) (*ScanResult, error)
 	ScheduleKubernetesResourceScan(ctx context.Context, integrationMrn, resourceKey, managedBy string) (*Empty, error)
+	ScheduleKubernetesResourceScans(ctx context.Context, integrationMrn string, resourceKeys []string, managedBy string) (*Empty, error)
 	GarbageCollectAssets(context.Context, *scan.GarbageCollectOptions) error
 
 	IntegrationRegister(context.Context, *IntegrationRegisterInput) (*IntegrationRegisterOutput, error)
@@ -234,6 +235,31 @@ type File struct {
 type ScanResult struct {
 	WorstScore *Score `json:"worstScore,omitempty"`
 	Ok         bool   `json:"ok,omitempty"`
+	// Full is only set if the scan was requested with ReportType_FULL
+	Full *ReportCollection `json:"full,omitempty"`
+}
+
+// ReportCollection holds the reports of all scanned assets together with the policy bundle used for the scan.
+// Only the fields needed to explain a scan result are mapped.
+type ReportCollection struct {
+	Bundle  *Bundle            `json:"bundle,omitempty"`
+	Reports map[string]*Report `json:"reports,omitempty"`
+}
+
+type Bundle struct {
+	Queries []*Query `json:"queries,omitempty"`
+}
+
+type Query struct {
+	Mrn    string `json:"mrn,omitempty"`
+	CodeId string `json:"code_id,omitempty"`
+	Title  string `json:"title,omitempty"`
+}
+
+type Report struct {
+	EntityMrn string `json:"entity_mrn,omitempty"`
+	// Scores maps the QrId of every check and policy to its score
+	Scores map[string]*Score `json:"scores,omitempty"`
 }
 
 type Score struct {
@@ -314,6 +340,14 @@ type Empty struct{}
 const ScheduleKubernetesResourceScanEndpoint = "/Scan/Schedule"
 
 func (s *mondooClient) ScheduleKubernetesResourceScan(ctx context.Context, integrationMrn, resourceKey, managedBy string) (*Empty, error) {
+	return s.ScheduleKubernetesResourceScans(ctx, integrationMrn, []string{resourceKey}, managedBy)
+}
+
+// ScheduleKubernetesResourceScans schedules a scan for all resources with a single request. The resource keys have
+// the format <type>:<namespace>:<name>.
+func (s *mondooClient) ScheduleKubernetesResourceScans(
+	ctx context.Context, integrationMrn string, resourceKeys []string, managedBy string,
+) (*Empty, error) {
 	url := s.ApiEndpoint + ScheduleKubernetesResourceScanEndpoint
 	scanJob := &ScanJob{
 		ReportType: ReportType_ERROR,
@@ -325,7 +359,7 @@ func (s *mondooClient) ScheduleKubernetesResourceScan(ctx context.Context, integ
 							{
 								Backend: providers.ProviderType_K8S,
 								Options: map[string]string{
-									"k8s-resources": resourceKey,
+									"k8s-resources": strings.Join(resourceKeys, ","),
 								},
 								Discover: &providers.Discovery{
 									Targets: []string{"auto"},
@@ -168,3 +168,18 @@ func (mr *MockClientMockRecorder) ScheduleKubernetesResourceScan(ctx, integratio
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleKubernetesResourceScan", reflect.TypeOf((*MockClient)(nil).ScheduleKubernetesResourceScan), ctx, integrationMrn, resourceKey, managedBy)
 }
+
+// ScheduleKubernetesResourceScans mocks base method.
+func (m *MockClient) ScheduleKubernetesResourceScans(ctx context.Context, integrationMrn string, resourceKeys []string, managedBy string) (*mondooclient.Empty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleKubernetesResourceScans", ctx, integrationMrn, resourceKeys, managedBy)
+	ret0, _ := ret[0].(*mondooclient.Empty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScheduleKubernetesResourceScans indicates an expected call of ScheduleKubernetesResourceScans.
+func (mr *MockClientMockRecorder) ScheduleKubernetesResourceScans(ctx, integrationMrn, resourceKeys, managedBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleKubernetesResourceScans", reflect.TypeOf((*MockClient)(nil).ScheduleKubernetesResourceScans), ctx, integrationMrn, resourceKeys, managedBy)
+}
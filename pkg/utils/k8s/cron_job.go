@@ -8,7 +8,52 @@ file, You can obtain one at https://mozilla.org/MPL/2.0/.
 
 package k8s
 
-import batchv1 "k8s.io/api/batch/v1"
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"go.mondoo.com/mondoo-operator/api/v1alpha2"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CustomScheduleAnnotation marks CronJobs which run on a schedule configured in the MondooAuditConfig. CronJobs
+// without it run on a default schedule picked by the operator.
+const CustomScheduleAnnotation = "mondoo.com/custom-schedule"
+
+type cronField struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	cronMacros = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+
+	cronFields = []cronField{
+		{name: "minute", min: 0, max: 59},
+		{name: "hour", min: 0, max: 23},
+		{name: "day of month", min: 1, max: 31},
+		{name: "month", min: 1, max: 12, names: map[string]int{
+			"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+			"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+		}},
+		{name: "day of week", min: 0, max: 7, names: map[string]int{
+			"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+		}},
+	}
+)
 
 // AreCronJobsSuccessful returns true if the latest runs of all of the provided CronJobs has been
 // successful.
@@ -22,3 +67,150 @@ func AreCronJobsSuccessful(cs []batchv1.CronJob) bool {
 	}
 	return true
 }
+
+// SetCustomSchedule sets the schedule of the CronJob and marks it as configured in the MondooAuditConfig.
+func SetCustomSchedule(c *batchv1.CronJob, schedule string) {
+	c.Spec.Schedule = schedule
+	metav1.SetMetaDataAnnotation(&c.ObjectMeta, CustomScheduleAnnotation, "true")
+}
+
+// HasCustomSchedule returns true if the CronJob runs on a schedule configured in the MondooAuditConfig.
+func HasCustomSchedule(c batchv1.CronJob) bool {
+	return c.Annotations[CustomScheduleAnnotation] == "true"
+}
+
+// UpdateSchedule copies the schedule of src to dst, including whether it is configured in the MondooAuditConfig.
+func UpdateSchedule(dst *batchv1.CronJob, src batchv1.CronJob) {
+	if HasCustomSchedule(src) {
+		SetCustomSchedule(dst, src.Spec.Schedule)
+		return
+	}
+	dst.Spec.Schedule = src.Spec.Schedule
+	delete(dst.Annotations, CustomScheduleAnnotation)
+}
+
+// ValidateCronSchedule returns an error if the provided schedule is not a standard cron expression. Both the
+// 5-field format and the predefined macros (e.g. "@hourly") are accepted.
+func ValidateCronSchedule(schedule string) error {
+	_, err := parseCronSchedule(schedule)
+	return err
+}
+
+// CronScheduleWithJitter validates the provided schedule and shifts its minute field by a stable offset in the
+// range [0, jitterMinutes). The offset is derived from seed, such that the same seed always results in the same
+// schedule. The shifted minute wraps around within the same hour, so the hours of the original schedule are kept.
+// Jitter can only be applied if the minute field is a fixed value, a list of fixed values or a "*/step" expression.
+func CronScheduleWithJitter(schedule string, jitterMinutes int32, seed string) (string, error) {
+	fields, err := parseCronSchedule(schedule)
+	if err != nil {
+		return "", err
+	}
+
+	if jitterMinutes <= 0 {
+		return schedule, nil
+	}
+	if jitterMinutes > v1alpha2.MaxScheduleJitterMinutes {
+		return "", fmt.Errorf("schedule jitter must not exceed %d minutes, got %d", v1alpha2.MaxScheduleJitterMinutes, jitterMinutes)
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(seed))
+	offset := int(h.Sum32() % uint32(jitterMinutes))
+
+	minute, err := jitterMinuteField(fields[0], offset)
+	if err != nil {
+		return "", err
+	}
+	fields[0] = minute
+	return strings.Join(fields, " "), nil
+}
+
+// parseCronSchedule validates the schedule and returns its 5 fields. Macros are expanded.
+func parseCronSchedule(schedule string) ([]string, error) {
+	schedule = strings.TrimSpace(schedule)
+	if expanded, ok := cronMacros[strings.ToLower(schedule)]; ok {
+		schedule = expanded
+	}
+
+	fields := strings.Fields(schedule)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("invalid cron schedule %q: expected %d fields, got %d", schedule, len(cronFields), len(fields))
+	}
+
+	for i, f := range fields {
+		if err := cronFields[i].validate(f); err != nil {
+			return nil, fmt.Errorf("invalid cron schedule %q: %w", schedule, err)
+		}
+	}
+	return fields, nil
+}
+
+func (c cronField) validate(field string) error {
+	for _, item := range strings.Split(field, ",") {
+		expr, step, hasStep := strings.Cut(item, "/")
+		if hasStep {
+			s, err := strconv.Atoi(step)
+			if err != nil || s <= 0 {
+				return fmt.Errorf("invalid step %q in %s field", step, c.name)
+			}
+		}
+
+		// "?" is accepted as an alias for "*" in the day fields
+		if expr == "*" || (expr == "?" && (c.name == "day of month" || c.name == "day of week")) {
+			continue
+		}
+
+		start, end, isRange := strings.Cut(expr, "-")
+		from, err := c.value(start)
+		if err != nil {
+			return err
+		}
+		if !isRange {
+			continue
+		}
+
+		to, err := c.value(end)
+		if err != nil {
+			return err
+		}
+		if from > to {
+			return fmt.Errorf("invalid range %q in %s field", expr, c.name)
+		}
+	}
+	return nil
+}
+
+func (c cronField) value(s string) (int, error) {
+	if v, ok := c.names[strings.ToUpper(s)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q in %s field", s, c.name)
+	}
+	if v < c.min || v > c.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d] in %s field", v, c.min, c.max, c.name)
+	}
+	return v, nil
+}
+
+func jitterMinuteField(field string, offset int) (string, error) {
+	if strings.HasPrefix(field, "*/") {
+		s, _ := strconv.Atoi(strings.TrimPrefix(field, "*/")) // the field has already been validated
+		if offset%s == 0 {
+			return field, nil
+		}
+		return fmt.Sprintf("%d-59/%d", offset%s, s), nil
+	}
+
+	items := strings.Split(field, ",")
+	for i, item := range items {
+		m, err := strconv.Atoi(item)
+		if err != nil {
+			return "", fmt.Errorf("schedule jitter requires a minute field with fixed values or a \"*/step\" expression, got %q", field)
+		}
+		items[i] = strconv.Itoa((m + offset) % 60)
+	}
+	return strings.Join(items, ","), nil
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2022 Mondoo, Inc.
+
+This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at https://mozilla.org/MPL/2.0/.
+*/
+
+package k8s
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mondoo.com/mondoo-operator/api/v1alpha2"
+	"sigs.k8s.io/yaml"
+)
+
+func TestValidateCronSchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule string
+		valid    bool
+	}{
+		{name: "fixed minute", schedule: "5 * * * *", valid: true},
+		{name: "ranges, lists and steps", schedule: "0,30 1-5/2 * * MON-FRI", valid: true},
+		{name: "month names", schedule: "0 0 1 jan,jul *", valid: true},
+		{name: "question mark in day fields", schedule: "0 0 ? * ?", valid: true},
+		{name: "macro", schedule: "@daily", valid: true},
+		{name: "empty", schedule: "", valid: false},
+		{name: "too few fields", schedule: "* * * *", valid: false},
+		{name: "too many fields", schedule: "* * * * * *", valid: false},
+		{name: "minute out of range", schedule: "60 * * * *", valid: false},
+		{name: "hour out of range", schedule: "0 24 * * *", valid: false},
+		{name: "day of month out of range", schedule: "0 0 0 * *", valid: false},
+		{name: "inverted range", schedule: "0 5-1 * * *", valid: false},
+		{name: "invalid step", schedule: "*/0 * * * *", valid: false},
+		{name: "question mark in minute field", schedule: "? * * * *", valid: false},
+		{name: "unknown macro", schedule: "@sometimes", valid: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateCronSchedule(test.schedule)
+			if test.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestCronScheduleWithJitter(t *testing.T) {
+	schedule, err := CronScheduleWithJitter("0 2 * * *", 0, "seed")
+	require.NoError(t, err)
+	assert.Equal(t, "0 2 * * *", schedule)
+
+	// The same seed must always result in the same schedule
+	first, err := CronScheduleWithJitter("0 2 * * *", 30, "seed")
+	require.NoError(t, err)
+	second, err := CronScheduleWithJitter("0 2 * * *", 30, "seed")
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	// The hour must be kept even when the minute wraps around
+	schedule, err = CronScheduleWithJitter("50,55 2 * * *", 59, "seed")
+	require.NoError(t, err)
+	assert.Regexp(t, `^\d+,\d+ 2 \* \* \*$`, schedule)
+
+	schedule, err = CronScheduleWithJitter("@hourly", 59, "seed")
+	require.NoError(t, err)
+	assert.Regexp(t, `^\d+ \* \* \* \*$`, schedule)
+
+	schedule, err = CronScheduleWithJitter("*/15 * * * *", 59, "seed")
+	require.NoError(t, err)
+	assert.Regexp(t, `^(\*|\d+-59)/15 \* \* \* \*$`, schedule)
+
+	_, err = CronScheduleWithJitter("* * * * *", 10, "seed")
+	assert.Error(t, err)
+
+	_, err = CronScheduleWithJitter("0-10 * * * *", 10, "seed")
+	assert.Error(t, err)
+
+	_, err = CronScheduleWithJitter("invalid", 10, "seed")
+	assert.Error(t, err)
+
+	_, err = CronScheduleWithJitter("0 2 * * *", v1alpha2.MaxScheduleJitterMinutes+1, "seed")
+	assert.Error(t, err)
+}
+
+func TestMaxScheduleJitterMinutes_MatchesCRD(t *testing.T) {
+	data, err := os.ReadFile("../../../config/crd/bases/k8s.mondoo.com_mondooauditconfigs.yaml")
+	require.NoError(t, err)
+
+	var crd map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(data, &crd))
+
+	maximums := scheduleJitterMaximums(crd)
+	require.NotEmpty(t, maximums)
+	for _, m := range maximums {
+		assert.Equal(t, float64(v1alpha2.MaxScheduleJitterMinutes), m)
+	}
+}
+
+// scheduleJitterMaximums returns the maximum of every scheduleJitterMinutes property in the CRD schema.
+func scheduleJitterMaximums(v interface{}) []interface{} {
+	var maximums []interface{}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if prop, ok := child.(map[string]interface{}); ok && k == "scheduleJitterMinutes" {
+				maximums = append(maximums, prop["maximum"])
+				continue
+			}
+			maximums = append(maximums, scheduleJitterMaximums(child)...)
+		}
+	case []interface{}:
+		for _, child := range v {
+			maximums = append(maximums, scheduleJitterMaximums(child)...)
+		}
+	}
+	return maximums
+}
@@ -16,6 +16,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
 )
 
 // AreDeploymentsEqual returns a value indicating whether 2 deployments are equal. Note that it does not perform a full
@@ -72,7 +73,10 @@ func AreServicesEqual(a, b corev1.Service) bool {
 func AreCronJobsEqual(a, b batchv1.CronJob) bool {
 	aPodSpec := a.Spec.JobTemplate.Spec.Template.Spec
 	bPodSpec := b.Spec.JobTemplate.Spec.Template.Spec
-	return len(aPodSpec.Containers) == len(bPodSpec.Containers) &&
+	return a.Spec.Schedule == b.Spec.Schedule &&
+		HasCustomSchedule(a) == HasCustomSchedule(b) &&
+		pointer.BoolDeref(a.Spec.Suspend, false) == pointer.BoolDeref(b.Spec.Suspend, false) &&
+		len(aPodSpec.Containers) == len(bPodSpec.Containers) &&
 		aPodSpec.ServiceAccountName == bPodSpec.ServiceAccountName &&
 		reflect.DeepEqual(aPodSpec.Tolerations, bPodSpec.Tolerations) &&
 		reflect.DeepEqual(aPodSpec.NodeName, bPodSpec.NodeName) &&
@@ -89,6 +93,26 @@ func AreCronJobsEqual(a, b batchv1.CronJob) bool {
 		reflect.DeepEqual(a.GetOwnerReferences(), b.GetOwnerReferences())
 }
 
+// AreDaemonSetsEqual returns a value indicating whether 2 daemon sets are equal. Note that it does not perform a full
+// comparison but checks just some of the properties of a daemon set (only the ones we are currently interested at).
+func AreDaemonSetsEqual(a, b appsv1.DaemonSet) bool {
+	aPodSpec := a.Spec.Template.Spec
+	bPodSpec := b.Spec.Template.Spec
+	return len(aPodSpec.Containers) == len(bPodSpec.Containers) &&
+		reflect.DeepEqual(a.Spec.Selector, b.Spec.Selector) &&
+		aPodSpec.ServiceAccountName == bPodSpec.ServiceAccountName &&
+		reflect.DeepEqual(aPodSpec.Tolerations, bPodSpec.Tolerations) &&
+		reflect.DeepEqual(aPodSpec.Containers[0].Image, bPodSpec.Containers[0].Image) &&
+		reflect.DeepEqual(aPodSpec.Containers[0].Command, bPodSpec.Containers[0].Command) &&
+		reflect.DeepEqual(aPodSpec.Containers[0].Args, bPodSpec.Containers[0].Args) &&
+		reflect.DeepEqual(aPodSpec.Containers[0].VolumeMounts, bPodSpec.Containers[0].VolumeMounts) &&
+		AreEnvVarsEqual(aPodSpec.Containers[0].Env, bPodSpec.Containers[0].Env) &&
+		AreResouceRequirementsEqual(aPodSpec.Containers[0].Resources, bPodSpec.Containers[0].Resources) &&
+		AreSecurityContextsEqual(aPodSpec.Containers[0].SecurityContext, bPodSpec.Containers[0].SecurityContext) &&
+		reflect.DeepEqual(aPodSpec.Volumes, bPodSpec.Volumes) &&
+		reflect.DeepEqual(a.GetOwnerReferences(), b.GetOwnerReferences())
+}
+
 // AreResouceRequirementsEqual returns a value indicating whether 2 resource requirements are equal.
 func AreResouceRequirementsEqual(x corev1.ResourceRequirements, y corev1.ResourceRequirements) bool {
 	if x.Limits.Cpu().Equal(*y.Limits.Cpu()) &&
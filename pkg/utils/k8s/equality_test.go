@@ -456,6 +456,15 @@ func TestAreCronJobsEqual(t *testing.T) {
 			},
 			shouldBeEqual: true,
 		},
+		{
+			name: "should not be equal when schedules differ",
+			createB: func(a batchv1.CronJob) batchv1.CronJob {
+				b := *a.DeepCopy()
+				b.Spec.Schedule = "30 * * * *"
+				return b
+			},
+			shouldBeEqual: false,
+		},
 		{
 			name: "should not be equal when container count differ",
 			createB: func(a batchv1.CronJob) batchv1.CronJob {
@@ -596,6 +605,140 @@ func TestAreCronJobsEqual(t *testing.T) {
 	}
 }
 
+func TestAreDaemonSetsEqual(t *testing.T) {
+	labels := map[string]string{"label": "value"}
+	a := appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "daemonset",
+			Namespace: "ns",
+			Labels:    labels,
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Tolerations: []corev1.Toleration{{
+						Key:    "key",
+						Effect: corev1.TaintEffectNoExecute,
+						Value:  "value",
+					}},
+					AutomountServiceAccountToken: pointer.Bool(false),
+					Containers: []corev1.Container{
+						{
+							Image:     "test-image:latest",
+							Name:      "cnspec",
+							Command:   []string{"/bin/sh", "-c", "cnspec scan local"},
+							Resources: DefaultCnspecResources,
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "root",
+									ReadOnly:  true,
+									MountPath: "/mnt/host/",
+								},
+							},
+							Env: []corev1.EnvVar{
+								{
+									Name: "NODE_NAME",
+									ValueFrom: &corev1.EnvVarSource{
+										FieldRef: &corev1.ObjectFieldSelector{FieldPath: "spec.nodeName"},
+									},
+								},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "root",
+							VolumeSource: corev1.VolumeSource{
+								HostPath: &corev1.HostPathVolumeSource{Path: "/"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		createB       func(appsv1.DaemonSet) appsv1.DaemonSet
+		shouldBeEqual bool
+	}{
+		{
+			name: "should be equal when identical",
+			createB: func(a appsv1.DaemonSet) appsv1.DaemonSet {
+				return *a.DeepCopy()
+			},
+			shouldBeEqual: true,
+		},
+		{
+			name: "should be equal when statuses differ",
+			createB: func(a appsv1.DaemonSet) appsv1.DaemonSet {
+				b := *a.DeepCopy()
+				b.Status.NumberUnavailable = 1
+				return b
+			},
+			shouldBeEqual: true,
+		},
+		{
+			name: "should not be equal when tolerations differ",
+			createB: func(a appsv1.DaemonSet) appsv1.DaemonSet {
+				b := *a.DeepCopy()
+				b.Spec.Template.Spec.Tolerations = nil
+				return b
+			},
+			shouldBeEqual: false,
+		},
+		{
+			name: "should not be equal when container commands differ",
+			createB: func(a appsv1.DaemonSet) appsv1.DaemonSet {
+				b := *a.DeepCopy()
+				b.Spec.Template.Spec.Containers[0].Command = []string{"test"}
+				return b
+			},
+			shouldBeEqual: false,
+		},
+		{
+			name: "should not be equal when env vars differ",
+			createB: func(a appsv1.DaemonSet) appsv1.DaemonSet {
+				b := *a.DeepCopy()
+				b.Spec.Template.Spec.Containers[0].Env = make([]corev1.EnvVar, 0)
+				return b
+			},
+			shouldBeEqual: false,
+		},
+		{
+			name: "should not be equal when Pod volume definition(s) differ",
+			createB: func(a appsv1.DaemonSet) appsv1.DaemonSet {
+				b := *a.DeepCopy()
+				b.Spec.Template.Spec.Volumes[0].VolumeSource.HostPath.Path = "/var"
+				return b
+			},
+			shouldBeEqual: false,
+		},
+		{
+			name: "should not be equal when owner references differ",
+			createB: func(a appsv1.DaemonSet) appsv1.DaemonSet {
+				b := *a.DeepCopy()
+				assert.NoError(t, ctrl.SetControllerReference(&a, &b, scheme.Scheme))
+				return b
+			},
+			shouldBeEqual: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.shouldBeEqual {
+				assert.True(t, AreDaemonSetsEqual(a, test.createB(a)))
+			} else {
+				assert.False(t, AreDaemonSetsEqual(a, test.createB(a)))
+			}
+		})
+	}
+}
+
 func TestAreResouceRequirementsEqual(t *testing.T) {
 	r := corev1.ResourceRequirements{
 		Limits: corev1.ResourceList{
@@ -11,14 +11,28 @@ package k8s
 import (
 	"context"
 	"os"
+	"sort"
 
 	"github.com/go-logr/logr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"go.mondoo.com/mondoo-operator/api/v1alpha2"
+	"go.mondoo.com/mondoo-operator/pkg/utils"
 )
 
+// NamespaceFiltering holds the namespaces which are passed to the scanner.
+type NamespaceFiltering struct {
+	Include []string
+	Exclude []string
+	// NoMatch is true if a namespace selector is configured, but no namespace matches it. Scanning has to be
+	// skipped in that case, because empty Include and Exclude lists mean that all namespaces are scanned.
+	NoMatch bool
+}
+
 // GetRunningNamespace will return the namespace the Pod is running under
 // Can fake the returned value (useful for local testing) by setting MONDOO_NAMESPACE_OVERRIDE
 func GetRunningNamespace() (string, error) {
@@ -51,3 +65,60 @@ func GetClusterUID(ctx context.Context, kubeClient client.Client, log logr.Logge
 	clusterID := string(namespace.UID)
 	return clusterID, nil
 }
+
+// NamespaceSelector returns the namespace selector of the filtering. A nil selector is returned if no namespace
+// selector is configured.
+func NamespaceSelector(filtering v1alpha2.Filtering) (labels.Selector, error) {
+	if filtering.NamespaceSelector == nil {
+		return nil, nil
+	}
+	return metav1.LabelSelectorAsSelector(filtering.NamespaceSelector)
+}
+
+// IsNamespaceAllowed checks whether the namespace matches the include and exclude patterns and the selector. The
+// namespace is only retrieved if a selector is provided.
+func IsNamespaceAllowed(
+	ctx context.Context, kubeClient client.Reader, namespace string, include, exclude []string, selector labels.Selector,
+) (bool, error) {
+	allow, err := utils.AllowNamespace(namespace, include, exclude)
+	if err != nil || !allow || selector == nil {
+		return allow, err
+	}
+
+	ns := &corev1.Namespace{}
+	if err := kubeClient.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		return false, err
+	}
+	return selector.Matches(labels.Set(ns.Labels)), nil
+}
+
+// ResolveNamespaceFiltering returns the namespaces to scan for the filtering. The scanner only supports lists of
+// namespaces, so if a namespace selector is configured, all namespaces that match the selector as well as the
+// include and exclude patterns are listed explicitly.
+func ResolveNamespaceFiltering(ctx context.Context, kubeClient client.Reader, filtering v1alpha2.Filtering) (NamespaceFiltering, error) {
+	selector, err := NamespaceSelector(filtering)
+	if err != nil {
+		return NamespaceFiltering{}, err
+	}
+	if selector == nil {
+		return NamespaceFiltering{Include: filtering.Namespaces.Include, Exclude: filtering.Namespaces.Exclude}, nil
+	}
+
+	namespaces := &corev1.NamespaceList{}
+	if err := kubeClient.List(ctx, namespaces, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return NamespaceFiltering{}, err
+	}
+
+	var include []string
+	for _, ns := range namespaces.Items {
+		allow, err := utils.AllowNamespace(ns.Name, filtering.Namespaces.Include, filtering.Namespaces.Exclude)
+		if err != nil {
+			return NamespaceFiltering{}, err
+		}
+		if allow {
+			include = append(include, ns.Name)
+		}
+	}
+	sort.Strings(include)
+	return NamespaceFiltering{Include: include, NoMatch: len(include) == 0}, nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2022 Mondoo, Inc.
+
+This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at https://mozilla.org/MPL/2.0/.
+*/
+
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"go.mondoo.com/mondoo-operator/api/v1alpha2"
+)
+
+func testNamespaceClient() client.Client {
+	return fake.NewClientBuilder().WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "payments-prod", Labels: map[string]string{"team": "payments"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "payments-dev", Labels: map[string]string{"team": "payments"}}},
+	).Build()
+}
+
+func TestIsNamespaceAllowed(t *testing.T) {
+	ctx := context.Background()
+	kubeClient := testNamespaceClient()
+	selector, err := NamespaceSelector(v1alpha2.Filtering{
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}},
+	})
+	require.NoError(t, err)
+
+	allow, err := IsNamespaceAllowed(ctx, kubeClient, "default", nil, nil, nil)
+	require.NoError(t, err)
+	assert.True(t, allow)
+
+	allow, err = IsNamespaceAllowed(ctx, kubeClient, "default", nil, nil, selector)
+	require.NoError(t, err)
+	assert.False(t, allow)
+
+	allow, err = IsNamespaceAllowed(ctx, kubeClient, "payments-prod", nil, nil, selector)
+	require.NoError(t, err)
+	assert.True(t, allow)
+
+	allow, err = IsNamespaceAllowed(ctx, kubeClient, "payments-dev", nil, []string{"*-dev"}, selector)
+	require.NoError(t, err)
+	assert.False(t, allow)
+}
+
+func TestResolveNamespaceFiltering(t *testing.T) {
+	ctx := context.Background()
+	kubeClient := testNamespaceClient()
+
+	filtering := v1alpha2.Filtering{Namespaces: v1alpha2.FilteringSpec{Exclude: []string{"kube-system"}}}
+	namespaces, err := ResolveNamespaceFiltering(ctx, kubeClient, filtering)
+	require.NoError(t, err)
+	assert.Equal(t, NamespaceFiltering{Exclude: []string{"kube-system"}}, namespaces)
+
+	filtering.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}}
+	namespaces, err = ResolveNamespaceFiltering(ctx, kubeClient, filtering)
+	require.NoError(t, err)
+	assert.Equal(t, NamespaceFiltering{Include: []string{"payments-dev", "payments-prod"}}, namespaces)
+
+	filtering.Namespaces.Exclude = []string{"payments-*"}
+	namespaces, err = ResolveNamespaceFiltering(ctx, kubeClient, filtering)
+	require.NoError(t, err)
+	assert.True(t, namespaces.NoMatch)
+	assert.Empty(t, namespaces.Include)
+}
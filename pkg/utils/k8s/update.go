@@ -28,3 +28,10 @@ func UpdateDeployment(current *appsv1.Deployment, desired appsv1.Deployment) {
 	current.Spec = desired.Spec
 	current.SetOwnerReferences(desired.GetOwnerReferences())
 }
+
+// UpdateDaemonSet updates a daemon set such that it matches a desired state. The function does
+// not replace all fields but only a set of fields that we are interested at.
+func UpdateDaemonSet(current *appsv1.DaemonSet, desired appsv1.DaemonSet) {
+	current.Spec = desired.Spec
+	current.SetOwnerReferences(desired.GetOwnerReferences())
+}
@@ -124,6 +124,9 @@ func SetMondooOperatorConfigCondition(
 }
 
 func UpdateMondooOperatorConfigStatus(ctx context.Context, client client.Client, origMOC, newMOC *mondoov1alpha2.MondooOperatorConfig, log logr.Logger) error {
+	for i := range newMOC.Status.Conditions {
+		newMOC.Status.Conditions[i].ObservedGeneration = newMOC.Generation
+	}
 	if !reflect.DeepEqual(origMOC.Status, newMOC.Status) {
 		log.Info("status has changed, updating")
 		err := client.Status().Update(ctx, newMOC)
@@ -185,6 +188,9 @@ func SetMondooAuditCondition(
 }
 
 func UpdateMondooAuditStatus(ctx context.Context, client client.Client, origMOC, newMOC *mondoov1alpha2.MondooAuditConfig, log logr.Logger) error {
+	for i := range newMOC.Status.Conditions {
+		newMOC.Status.Conditions[i].ObservedGeneration = newMOC.Generation
+	}
 	if !reflect.DeepEqual(origMOC.Status, newMOC.Status) {
 		log.Info("status has changed, updating")
 		err := client.Status().Update(ctx, newMOC)
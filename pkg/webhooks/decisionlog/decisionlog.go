@@ -0,0 +1,88 @@
+package decisionlog
+
+import (
+	"time"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var log = logf.Log.WithName("decision-log")
+
+// Record is a single admission decision of the webhook.
+type Record struct {
+	Time time.Time `json:"time"`
+	// RequestUID is the UID of the admission request.
+	RequestUID string `json:"requestUID"`
+
+	Group     string `json:"group,omitempty"`
+	Version   string `json:"version"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	UID       string `json:"uid,omitempty"`
+	Operation string `json:"operation"`
+
+	User   string   `json:"user"`
+	Groups []string `json:"groups,omitempty"`
+
+	Mode string `json:"mode"`
+	// ModeSource is "MondooAuditConfig" or, if the namespace overrides the mode with a label, "namespace label".
+	ModeSource string `json:"modeSource"`
+	// Score is the worst score of the scan. It is not set if the resource wasn't scanned.
+	Score *uint32 `json:"score,omitempty"`
+	// Decision is "allowed", "denied" or, in audit mode, "would_deny".
+	Decision string `json:"decision"`
+	Reason   string `json:"reason,omitempty"`
+	// SkipReason is set if the resource was admitted without a scan.
+	SkipReason string `json:"skipReason,omitempty"`
+	Exemption  string `json:"exemption,omitempty"`
+	// Degraded is true if the scan API was unavailable and the decision wasn't based on a fresh scan.
+	Degraded  bool    `json:"degraded,omitempty"`
+	LatencyMs float64 `json:"latencyMs"`
+}
+
+// Sink receives the decision records. Sinks must be safe for concurrent use.
+type Sink interface {
+	Write(record Record) error
+	// Close flushes buffered records and releases the resources of the sink.
+	Close() error
+}
+
+// Logger writes every decision to all of its sinks. A nil Logger discards all decisions.
+type Logger struct {
+	sinks []Sink
+}
+
+// NewLogger returns a Logger for the sinks. If there are no sinks, nil is returned.
+func NewLogger(sinks ...Sink) *Logger {
+	if len(sinks) == 0 {
+		return nil
+	}
+	return &Logger{sinks: sinks}
+}
+
+// Log writes the record to all sinks. Errors are logged, so a failing sink never affects the admission decision.
+func (l *Logger) Log(record Record) {
+	if l == nil {
+		return
+	}
+	for _, s := range l.sinks {
+		if err := s.Write(record); err != nil {
+			log.Error(err, "failed to write admission decision", "namespace", record.Namespace, "name", record.Name)
+		}
+	}
+}
+
+// Close closes all sinks and returns the first error.
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+	var firstErr error
+	for _, s := range l.sinks {
+		if err := s.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,45 @@
+package decisionlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingSink struct{}
+
+func (failingSink) Write(Record) error { return fmt.Errorf("write failed") }
+func (failingSink) Close() error       { return fmt.Errorf("close failed") }
+
+func TestLogger(t *testing.T) {
+	assert.Nil(t, NewLogger())
+	var nilLogger *Logger
+	nilLogger.Log(Record{Name: "ignored"})
+	assert.NoError(t, nilLogger.Close())
+
+	buf := &bytes.Buffer{}
+	score := uint32(80)
+	logger := NewLogger(failingSink{}, NewWriterSink(buf))
+	logger.Log(Record{Kind: "Pod", Name: "a", Score: &score, Decision: "allowed"})
+	logger.Log(Record{Kind: "Pod", Name: "b", Decision: "denied", SkipReason: "has_parent"})
+	assert.EqualError(t, logger.Close(), "close failed")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var record Record
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "a", record.Name)
+	require.NotNil(t, record.Score)
+	assert.Equal(t, score, *record.Score)
+
+	// Fields which are not set are omitted.
+	assert.NotContains(t, lines[0], "skipReason")
+	assert.Contains(t, lines[1], `"skipReason":"has_parent"`)
+	assert.NotContains(t, lines[1], "score")
+}
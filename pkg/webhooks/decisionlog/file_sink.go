@@ -0,0 +1,105 @@
+package decisionlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+const (
+	// DefaultFileMaxSizeMB is the default size in megabytes after which the decision log file is rotated
+	DefaultFileMaxSizeMB = 100
+	// DefaultFileMaxBackups is the default number of rotated decision log files which are kept
+	DefaultFileMaxBackups = 5
+)
+
+// fileSink writes the records as JSON lines to a file. When the file exceeds the maximum size, it is rotated to
+// <path>.1 and older files are shifted up to <path>.<maxBackups>.
+type fileSink struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// NewFileSink returns a Sink which appends JSON lines to the file at path. The file is rotated when it grows beyond
+// maxSize bytes and at most maxBackups rotated files are kept.
+func NewFileSink(path string, maxSize int64, maxBackups int) (Sink, error) {
+	if maxSize <= 0 {
+		return nil, fmt.Errorf("the maximum size of the decision log file must be positive, got %d", maxSize)
+	}
+	s := &fileSink{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *fileSink) Write(record Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.size > 0 && s.size+int64(len(data)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.file.Write(data)
+	s.size += int64(n)
+	return err
+}
+
+func (s *fileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+func (s *fileSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.file = f
+	s.size = info.Size()
+	return nil
+}
+
+func (s *fileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	if s.maxBackups <= 0 {
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return s.open()
+	}
+
+	for i := s.maxBackups - 1; i > 0; i-- {
+		if err := os.Rename(backupName(s.path, i), backupName(s.path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(s.path, backupName(s.path, 1)); err != nil {
+		return err
+	}
+	return s.open()
+}
+
+func backupName(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
@@ -0,0 +1,49 @@
+package decisionlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSink_Rotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "decisions.jsonl")
+	// Every record is larger than half of the maximum size, so each write rotates the file.
+	sink, err := NewFileSink(path, 150, 2)
+	require.NoError(t, err)
+
+	for _, name := range []string{"first", "second", "third", "fourth"} {
+		require.NoError(t, sink.Write(Record{Kind: "Pod", Name: name}))
+	}
+	require.NoError(t, sink.Close())
+
+	for file, name := range map[string]string{path: "fourth", path + ".1": "third", path + ".2": "second"} {
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, 1, strings.Count(string(data), "\n"), file)
+		assert.Contains(t, string(data), `"name":"`+name+`"`, file)
+	}
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFileSink_Append(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "decisions.jsonl")
+	for _, name := range []string{"first", "second"} {
+		sink, err := NewFileSink(path, 1024*1024, 1)
+		require.NoError(t, err)
+		require.NoError(t, sink.Write(Record{Kind: "Pod", Name: name}))
+		require.NoError(t, sink.Close())
+	}
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(data), "\n"))
+
+	_, err = NewFileSink(path, 0, 1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,182 @@
+package decisionlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultHTTPBatchSize is the default number of records sent in one request
+	DefaultHTTPBatchSize = 100
+	// DefaultHTTPFlushInterval is the default time after which an incomplete batch is sent
+	DefaultHTTPFlushInterval = 5 * time.Second
+	// DefaultHTTPMaxRetries is the default number of retries for a failed request
+	DefaultHTTPMaxRetries = 3
+
+	httpRequestTimeout = 10 * time.Second
+	httpInitialBackoff = 500 * time.Millisecond
+)
+
+// HTTPSinkOptions configures the HTTP sink.
+type HTTPSinkOptions struct {
+	// Endpoint is the URL the batches are posted to.
+	Endpoint string
+	// BatchSize is the maximum number of records in one request.
+	BatchSize int
+	// FlushInterval is the maximum time a record is buffered before it is sent.
+	FlushInterval time.Duration
+	// MaxRetries is the number of retries for requests which failed with a network error or a 429 or 5xx status.
+	MaxRetries int
+	// Client is used for the requests. http.DefaultClient is used if it is nil.
+	Client *http.Client
+}
+
+// httpSink posts the records in batches as a JSON array. Records are buffered in memory, so records which are
+// still buffered when the webhook is killed are lost.
+type httpSink struct {
+	opts    HTTPSinkOptions
+	backoff time.Duration
+	records chan Record
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewHTTPSink returns a Sink which posts the records in batches to the endpoint.
+func NewHTTPSink(opts HTTPSinkOptions) (Sink, error) {
+	if opts.Endpoint == "" {
+		return nil, fmt.Errorf("the decision log endpoint must be set")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultHTTPBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = DefaultHTTPFlushInterval
+	}
+	if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+
+	s := &httpSink{
+		opts:    opts,
+		backoff: httpInitialBackoff,
+		// Buffer a few batches, so a slow endpoint doesn't drop records right away.
+		records: make(chan Record, opts.BatchSize*10),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+// Write buffers the record. It never blocks the admission request, so records are dropped if the buffer is full.
+func (s *httpSink) Write(record Record) error {
+	select {
+	case s.records <- record:
+		return nil
+	default:
+		return fmt.Errorf("decision log buffer is full, dropping the record")
+	}
+}
+
+// Close sends the buffered records and stops the sink.
+func (s *httpSink) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	<-s.done
+	return nil
+}
+
+func (s *httpSink) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Record, 0, s.opts.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.send(batch); err != nil {
+			log.Error(err, "failed to send admission decisions", "endpoint", s.opts.Endpoint, "records", len(batch))
+		}
+		batch = make([]Record, 0, s.opts.BatchSize)
+	}
+
+	for {
+		select {
+		case r := <-s.records:
+			batch = append(batch, r)
+			if len(batch) >= s.opts.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-s.stop:
+			for {
+				select {
+				case r := <-s.records:
+					batch = append(batch, r)
+					if len(batch) >= s.opts.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *httpSink) send(batch []Record) error {
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	backoff := s.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := s.post(data)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= s.opts.MaxRetries {
+			return err
+		}
+		log.V(1).Info("retrying to send admission decisions", "error", err.Error(), "attempt", attempt+1)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// post sends the data and returns whether a failed request should be retried.
+func (s *httpSink) post(data []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpRequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.opts.Endpoint, bytes.NewReader(data))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("decision log endpoint returned status %d", resp.StatusCode)
+}
@@ -0,0 +1,89 @@
+package decisionlog
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testEndpoint struct {
+	mu       sync.Mutex
+	failures int
+	requests int
+	batches  [][]Record
+}
+
+func (e *testEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.requests++
+	if e.failures > 0 {
+		e.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	var batch []Record
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	e.batches = append(e.batches, batch)
+}
+
+func newTestHTTPSink(t *testing.T, endpoint *testEndpoint, maxRetries int) *httpSink {
+	server := httptest.NewServer(endpoint)
+	t.Cleanup(server.Close)
+
+	sink, err := NewHTTPSink(HTTPSinkOptions{
+		Endpoint:      server.URL,
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+		MaxRetries:    maxRetries,
+	})
+	require.NoError(t, err)
+	s := sink.(*httpSink)
+	s.backoff = time.Millisecond
+	return s
+}
+
+func TestHTTPSink_Batches(t *testing.T) {
+	endpoint := &testEndpoint{}
+	sink := newTestHTTPSink(t, endpoint, 0)
+
+	for _, name := range []string{"a", "b", "c"} {
+		require.NoError(t, sink.Write(Record{Kind: "Pod", Name: name}))
+	}
+	// The last incomplete batch is sent when the sink is closed.
+	require.NoError(t, sink.Close())
+
+	require.Len(t, endpoint.batches, 2)
+	assert.Len(t, endpoint.batches[0], 2)
+	assert.Equal(t, "c", endpoint.batches[1][0].Name)
+}
+
+func TestHTTPSink_Retry(t *testing.T) {
+	endpoint := &testEndpoint{failures: 2}
+	sink := newTestHTTPSink(t, endpoint, 2)
+	require.NoError(t, sink.Write(Record{Kind: "Pod", Name: "a"}))
+	require.NoError(t, sink.Close())
+	assert.Equal(t, 3, endpoint.requests)
+	assert.Len(t, endpoint.batches, 1)
+
+	endpoint = &testEndpoint{failures: 5}
+	sink = newTestHTTPSink(t, endpoint, 1)
+	require.NoError(t, sink.Write(Record{Kind: "Pod", Name: "a"}))
+	require.NoError(t, sink.Close())
+	assert.Equal(t, 2, endpoint.requests)
+	assert.Empty(t, endpoint.batches)
+}
+
+func TestNewHTTPSink_NoEndpoint(t *testing.T) {
+	_, err := NewHTTPSink(HTTPSinkOptions{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,33 @@
+package decisionlog
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// writerSink writes the records as JSON lines to a writer.
+type writerSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterSink returns a Sink which writes one JSON object per line to w, e.g. os.Stdout.
+func NewWriterSink(w io.Writer) Sink {
+	return &writerSink{w: w}
+}
+
+func (s *writerSink) Write(record Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(data, '\n'))
+	return err
+}
+
+func (s *writerSink) Close() error {
+	return nil
+}
@@ -0,0 +1,217 @@
+package webhookhandler
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	mondoov1alpha2 "go.mondoo.com/mondoo-operator/api/v1alpha2"
+)
+
+const (
+	// DefaultAuditStatusInterval is the default time between two updates of the audit counts in the
+	// MondooAuditConfig status
+	DefaultAuditStatusInterval = 30 * time.Second
+
+	// maxAuditWorkloads is the number of workloads kept in the status, so it doesn't grow without bounds.
+	maxAuditWorkloads = 50
+)
+
+type auditWorkload struct {
+	kind      string
+	namespace string
+	name      string
+}
+
+// AuditStatusRecorder counts the would-be denials of the "audit" mode and adds them to the status of the
+// MondooAuditConfig. Every webhook replica only adds its own counts, so the counts of all replicas add up.
+// A nil AuditStatusRecorder discards the counts.
+type AuditStatusRecorder struct {
+	client   client.Client
+	reader   client.Reader
+	key      types.NamespacedName
+	interval time.Duration
+
+	mu         sync.Mutex
+	namespaces map[string]int64
+	workloads  map[auditWorkload]int64
+	lastDenial map[auditWorkload]time.Time
+}
+
+// NewAuditStatusRecorder returns a recorder for the status of the MondooAuditConfig with the key. The reader
+// should not be cached, so conflicting updates are resolved with the latest status. If the name of the key
+// is empty, nil is returned.
+func NewAuditStatusRecorder(c client.Client, reader client.Reader, key types.NamespacedName, interval time.Duration) *AuditStatusRecorder {
+	if key.Name == "" {
+		return nil
+	}
+	if interval <= 0 {
+		interval = DefaultAuditStatusInterval
+	}
+	r := &AuditStatusRecorder{client: c, reader: reader, key: key, interval: interval}
+	r.reset()
+	return r
+}
+
+// record counts a would-be denial of the workload.
+func (r *AuditStatusRecorder) record(kind, namespace, name string) {
+	if r == nil {
+		return
+	}
+	w := auditWorkload{kind: kind, namespace: namespace, name: name}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.namespaces[namespace]++
+	r.workloads[w]++
+	r.lastDenial[w] = time.Now()
+}
+
+// Start adds the counts to the status until the context is done. It implements manager.Runnable.
+func (r *AuditStatusRecorder) Start(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.flush(ctx)
+		case <-ctx.Done():
+			// The manager's context is already cancelled, so the last counts get a few seconds on their own.
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			r.flush(flushCtx)
+			cancel()
+			return nil
+		}
+	}
+}
+
+// flush adds the counts since the last flush to the status. If the update fails, the counts are kept for
+// the next flush.
+func (r *AuditStatusRecorder) flush(ctx context.Context) {
+	r.mu.Lock()
+	namespaces, workloads, lastDenial := r.namespaces, r.workloads, r.lastDenial
+	r.reset()
+	r.mu.Unlock()
+	if len(namespaces) == 0 {
+		return
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		mac := &mondoov1alpha2.MondooAuditConfig{}
+		if err := r.reader.Get(ctx, r.key, mac); err != nil {
+			return err
+		}
+		mac.Status.AdmissionAudit = mergeAuditStatus(mac.Status.AdmissionAudit, namespaces, workloads, lastDenial)
+		return r.client.Status().Update(ctx, mac)
+	})
+	if err != nil {
+		handlerlog.Error(err, "failed to update the audit counts of the MondooAuditConfig", "mondooauditconfig", r.key)
+		r.mu.Lock()
+		for ns, count := range namespaces {
+			r.namespaces[ns] += count
+		}
+		for w, count := range workloads {
+			r.workloads[w] += count
+			if lastDenial[w].After(r.lastDenial[w]) {
+				r.lastDenial[w] = lastDenial[w]
+			}
+		}
+		r.mu.Unlock()
+	}
+}
+
+func (r *AuditStatusRecorder) reset() {
+	r.namespaces = make(map[string]int64)
+	r.workloads = make(map[auditWorkload]int64)
+	r.lastDenial = make(map[auditWorkload]time.Time)
+}
+
+// mergeAuditStatus adds the counts to the status. Namespaces are sorted by name, workloads by their count, and
+// only the maxAuditWorkloads workloads with the most would-be denials are kept.
+func mergeAuditStatus(
+	status *mondoov1alpha2.AdmissionAuditStatus,
+	namespaces map[string]int64,
+	workloads map[auditWorkload]int64,
+	lastDenial map[auditWorkload]time.Time,
+) *mondoov1alpha2.AdmissionAuditStatus {
+	merged := &mondoov1alpha2.AdmissionAuditStatus{}
+	if status != nil {
+		merged = status.DeepCopy()
+	}
+	merged.LastUpdateTime = metav1.Now()
+
+	namespaceIndex := make(map[string]int, len(merged.Namespaces))
+	for i, n := range merged.Namespaces {
+		namespaceIndex[n.Namespace] = i
+	}
+	for ns, count := range namespaces {
+		merged.WouldBeDenied += count
+		if i, ok := namespaceIndex[ns]; ok {
+			merged.Namespaces[i].WouldBeDenied += count
+			continue
+		}
+		merged.Namespaces = append(merged.Namespaces, mondoov1alpha2.AdmissionAuditNamespace{Namespace: ns, WouldBeDenied: count})
+	}
+	sort.Slice(merged.Namespaces, func(i, j int) bool {
+		return merged.Namespaces[i].Namespace < merged.Namespaces[j].Namespace
+	})
+
+	workloadIndex := make(map[auditWorkload]int, len(merged.Workloads))
+	for i, w := range merged.Workloads {
+		workloadIndex[auditWorkload{kind: w.Kind, namespace: w.Namespace, name: w.Name}] = i
+	}
+	for w, count := range workloads {
+		last := metav1.NewTime(lastDenial[w])
+		if i, ok := workloadIndex[w]; ok {
+			merged.Workloads[i].WouldBeDenied += count
+			merged.Workloads[i].LastDenialTime = last
+			continue
+		}
+		merged.Workloads = append(merged.Workloads, mondoov1alpha2.AdmissionAuditWorkload{
+			Kind:           w.kind,
+			Namespace:      w.namespace,
+			Name:           w.name,
+			WouldBeDenied:  count,
+			LastDenialTime: last,
+		})
+	}
+	sort.SliceStable(merged.Workloads, func(i, j int) bool {
+		a, b := merged.Workloads[i], merged.Workloads[j]
+		if a.WouldBeDenied != b.WouldBeDenied {
+			return a.WouldBeDenied > b.WouldBeDenied
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+	if len(merged.Workloads) > maxAuditWorkloads {
+		merged.Workloads = merged.Workloads[:maxAuditWorkloads]
+	}
+	return merged
+}
+
+// workloadName returns the name of the object. Objects created with a generated name have no name yet, so their
+// generateName prefix is used instead.
+func workloadName(req admission.Request, obj runtime.Object) string {
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return req.Name
+	}
+	if name := objMeta.GetName(); name != "" {
+		return name
+	}
+	return objMeta.GetGenerateName()
+}
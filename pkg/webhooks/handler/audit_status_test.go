@@ -0,0 +1,136 @@
+package webhookhandler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	mondoov1alpha2 "go.mondoo.com/mondoo-operator/api/v1alpha2"
+	"go.mondoo.com/mondoo-operator/pkg/mondooclient"
+	"go.mondoo.com/mondoo-operator/pkg/mondooclient/mock"
+)
+
+func TestMergeAuditStatus(t *testing.T) {
+	now := time.Now()
+	web := auditWorkload{kind: "Deployment", namespace: "app", name: "web"}
+	db := auditWorkload{kind: "StatefulSet", namespace: "data", name: "db"}
+
+	status := mergeAuditStatus(nil,
+		map[string]int64{"app": 2, "data": 1},
+		map[auditWorkload]int64{web: 2, db: 1},
+		map[auditWorkload]time.Time{web: now, db: now})
+	assert.Equal(t, int64(3), status.WouldBeDenied)
+	assert.Equal(t, []mondoov1alpha2.AdmissionAuditNamespace{
+		{Namespace: "app", WouldBeDenied: 2},
+		{Namespace: "data", WouldBeDenied: 1},
+	}, status.Namespaces)
+	require.Len(t, status.Workloads, 2)
+	assert.Equal(t, "web", status.Workloads[0].Name)
+
+	// Counts of another replica are added to the existing ones.
+	status = mergeAuditStatus(status,
+		map[string]int64{"data": 3},
+		map[auditWorkload]int64{db: 3},
+		map[auditWorkload]time.Time{db: now})
+	assert.Equal(t, int64(6), status.WouldBeDenied)
+	assert.Equal(t, int64(4), status.Namespaces[1].WouldBeDenied)
+	assert.Equal(t, "db", status.Workloads[0].Name)
+	assert.Equal(t, int64(4), status.Workloads[0].WouldBeDenied)
+
+	workloads := make(map[auditWorkload]int64)
+	for i := 0; i < maxAuditWorkloads+10; i++ {
+		workloads[auditWorkload{kind: "Pod", namespace: "app", name: fmt.Sprintf("pod-%d", i)}] = 1
+	}
+	status = mergeAuditStatus(status, map[string]int64{"app": int64(len(workloads))}, workloads, nil)
+	assert.Len(t, status.Workloads, maxAuditWorkloads)
+	assert.Equal(t, "db", status.Workloads[0].Name)
+}
+
+func TestAuditStatusRecorder_Flush(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, mondoov1alpha2.AddToScheme(scheme))
+	mac := &mondoov1alpha2.MondooAuditConfig{ObjectMeta: metav1.ObjectMeta{Name: "mondoo-client", Namespace: "mondoo-operator"}}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(mac).Build()
+
+	assert.Nil(t, NewAuditStatusRecorder(fakeClient, fakeClient, types.NamespacedName{}, 0))
+	recorder := NewAuditStatusRecorder(fakeClient, fakeClient, client.ObjectKeyFromObject(mac), 0)
+	recorder.record("Deployment", "app", "web")
+	recorder.record("Deployment", "app", "web")
+	recorder.flush(context.TODO())
+	// Nothing was recorded since the last flush, so the status is not updated again.
+	recorder.flush(context.TODO())
+
+	require.NoError(t, fakeClient.Get(context.TODO(), client.ObjectKeyFromObject(mac), mac))
+	require.NotNil(t, mac.Status.AdmissionAudit)
+	assert.Equal(t, int64(2), mac.Status.AdmissionAudit.WouldBeDenied)
+	require.Len(t, mac.Status.AdmissionAudit.Workloads, 1)
+	assert.Equal(t, mondoov1alpha2.AdmissionAuditWorkload{
+		Kind:           "Deployment",
+		Namespace:      "app",
+		Name:           "web",
+		WouldBeDenied:  2,
+		LastDenialTime: mac.Status.AdmissionAudit.Workloads[0].LastDenialTime,
+	}, mac.Status.AdmissionAudit.Workloads[0])
+
+	// Counts are kept if the MondooAuditConfig cannot be updated.
+	require.NoError(t, fakeClient.Delete(context.TODO(), mac))
+	recorder.record("Deployment", "app", "web")
+	recorder.flush(context.TODO())
+	assert.Equal(t, int64(1), recorder.namespaces["app"])
+}
+
+func TestWebhookAuditMode(t *testing.T) {
+	decoder := setupDecoder(t)
+	mockCtrl := gomock.NewController(t)
+	scanner := mock.NewMockClient(mockCtrl)
+	gomock.InOrder(
+		scanner.EXPECT().RunAdmissionReview(gomock.Any(), gomock.Any()).Return(&mondooclient.ScanResult{
+			WorstScore: &mondooclient.Score{Type: mondooclient.ValidScanResult, Value: 20},
+		}, nil),
+		scanner.EXPECT().RunAdmissionReview(gomock.Any(), gomock.Any()).Return(&mondooclient.ScanResult{
+			WorstScore: &mondooclient.Score{Type: mondooclient.ValidScanResult, Value: 100},
+		}, nil),
+	)
+
+	recorder := &AuditStatusRecorder{}
+	recorder.reset()
+	validator := &webhookValidator{
+		decoder:        decoder,
+		mode:           mondoov1alpha2.Audit,
+		scanner:        scanner,
+		uniDecoder:     serializer.NewCodecFactory(clientgoscheme.Scheme).UniversalDeserializer(),
+		scoreThreshold: DefaultScoreThreshold,
+		auditStatus:    recorder,
+	}
+	request := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Kind:   metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		Object: testExamplePod(),
+	}}
+
+	response := validator.Handle(context.TODO(), request)
+	assert.True(t, response.Allowed)
+	assert.Equal(t, auditWouldDeny, string(response.Result.Reason))
+	require.NotEmpty(t, response.Warnings)
+	assert.Contains(t, response.Warnings[0], "would be denied in enforcing mode")
+	assert.Equal(t, map[auditWorkload]int64{{kind: "Pod", name: "testPod-abcd"}: 1}, recorder.workloads)
+
+	response = validator.Handle(context.TODO(), request)
+	assert.True(t, response.Allowed)
+	assert.Equal(t, passedScan, string(response.Result.Reason))
+	assert.Len(t, recorder.workloads, 1)
+}
@@ -0,0 +1,64 @@
+package webhookhandler
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// DefaultMaxInFlightScans is the default number of concurrent requests to the scan API
+	DefaultMaxInFlightScans = 20
+	// DefaultWebhookTimeout is the timeout the API server applies to the webhook if timeoutSeconds isn't set
+	DefaultWebhookTimeout = 10 * time.Second
+)
+
+// errScanShed is returned if a scan is not started because too many scans are in flight.
+var errScanShed = errors.New("too many scans in flight")
+
+// scanLimiter bounds the number of concurrent requests to the scan API. Requests over the limit are not queued,
+// because they would only time out in the API server. A nil scanLimiter doesn't limit anything.
+type scanLimiter struct {
+	slots chan struct{}
+}
+
+// newScanLimiter returns a limiter for max concurrent scans. If max is not positive, nil is returned and the scans
+// are not limited. The limit is exported next to the scans in flight, which shows how close the webhook is to
+// shedding requests.
+func newScanLimiter(max int) *scanLimiter {
+	if max <= 0 {
+		metricsMaxInFlightScans.Set(0)
+		return nil
+	}
+	metricsMaxInFlightScans.Set(float64(max))
+	return &scanLimiter{slots: make(chan struct{}, max)}
+}
+
+// tryAcquire returns whether a scan may start. Every successful call must be followed by a call to release.
+func (l *scanLimiter) tryAcquire() bool {
+	if l == nil {
+		return true
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (l *scanLimiter) release() {
+	if l == nil {
+		return
+	}
+	<-l.slots
+}
+
+// requestDeadline returns how long a request may take, so the webhook still answers before the API server gives up
+// after webhookTimeout. Returns 0 if webhookTimeout is not positive.
+func requestDeadline(webhookTimeout time.Duration) time.Duration {
+	if webhookTimeout <= 0 {
+		return 0
+	}
+	// Leave a fifth of the timeout for the fallback response and the round trip to the API server.
+	return webhookTimeout * 4 / 5
+}
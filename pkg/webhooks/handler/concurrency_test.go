@@ -0,0 +1,117 @@
+package webhookhandler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	mondoov1alpha2 "go.mondoo.com/mondoo-operator/api/v1alpha2"
+	"go.mondoo.com/mondoo-operator/pkg/mondooclient"
+	"go.mondoo.com/mondoo-operator/pkg/mondooclient/mock"
+)
+
+func TestScanLimiter(t *testing.T) {
+	var unlimited *scanLimiter
+	assert.Nil(t, newScanLimiter(0))
+	assert.Equal(t, float64(0), testutil.ToFloat64(metricsMaxInFlightScans))
+	assert.True(t, unlimited.tryAcquire())
+	unlimited.release()
+
+	limiter := newScanLimiter(2)
+	assert.Equal(t, float64(2), testutil.ToFloat64(metricsMaxInFlightScans))
+	assert.True(t, limiter.tryAcquire())
+	assert.True(t, limiter.tryAcquire())
+	assert.False(t, limiter.tryAcquire())
+	limiter.release()
+	assert.True(t, limiter.tryAcquire())
+}
+
+func TestRequestDeadline(t *testing.T) {
+	assert.Equal(t, 8*time.Second, requestDeadline(DefaultWebhookTimeout))
+	assert.Equal(t, time.Duration(0), requestDeadline(0))
+}
+
+func TestWebhookLoadShedding(t *testing.T) {
+	decoder := setupDecoder(t)
+	mockCtrl := gomock.NewController(t)
+	scanner := mock.NewMockClient(mockCtrl)
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	scanner.EXPECT().RunAdmissionReview(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(context.Context, *mondooclient.AdmissionReviewJob) (*mondooclient.ScanResult, error) {
+			close(started)
+			<-finish
+			return &mondooclient.ScanResult{WorstScore: &mondooclient.Score{Type: mondooclient.ValidScanResult, Value: 100}}, nil
+		})
+
+	validator := &webhookValidator{
+		decoder:        decoder,
+		mode:           mondoov1alpha2.Enforcing,
+		scanner:        scanner,
+		uniDecoder:     serializer.NewCodecFactory(clientgoscheme.Scheme).UniversalDeserializer(),
+		scoreThreshold: DefaultScoreThreshold,
+		lastVerdicts:   newLastVerdicts(time.Minute),
+		scanLimiter:    newScanLimiter(1),
+	}
+	withUID := func(p *corev1.Pod) { p.UID = "pod-uid" }
+	request := func(modifiers ...func(*corev1.Pod)) admission.Request {
+		return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Object: testExamplePod(modifiers...)}}
+	}
+	shedBefore := testutil.ToFloat64(metricsShedScans)
+
+	first := make(chan admission.Response)
+	go func() {
+		first <- validator.Handle(context.TODO(), request(withUID))
+	}()
+	<-started
+	assert.Equal(t, float64(1), testutil.ToFloat64(metricsScansInFlight))
+
+	// The only slot is taken and there is no last known verdict yet, so the default response applies.
+	assert.False(t, validator.Handle(context.TODO(), request(withUID)).Allowed)
+	assert.Equal(t, shedBefore+1, testutil.ToFloat64(metricsShedScans))
+
+	close(finish)
+	assert.True(t, (<-first).Allowed)
+	assert.Equal(t, float64(0), testutil.ToFloat64(metricsScansInFlight))
+
+	// Take the slot, so the next request is shed and gets the last known verdict of the first scan.
+	require.True(t, validator.scanLimiter.tryAcquire())
+	assert.True(t, validator.Handle(context.TODO(), request(withUID)).Allowed)
+	assert.Equal(t, shedBefore+2, testutil.ToFloat64(metricsShedScans))
+}
+
+func TestWebhookRequestDeadline(t *testing.T) {
+	decoder := setupDecoder(t)
+	mockCtrl := gomock.NewController(t)
+	scanner := mock.NewMockClient(mockCtrl)
+	scanner.EXPECT().RunAdmissionReview(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, _ *mondooclient.AdmissionReviewJob) (*mondooclient.ScanResult, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+
+	validator := &webhookValidator{
+		decoder:         decoder,
+		mode:            mondoov1alpha2.Enforcing,
+		scanner:         scanner,
+		uniDecoder:      serializer.NewCodecFactory(clientgoscheme.Scheme).UniversalDeserializer(),
+		scoreThreshold:  DefaultScoreThreshold,
+		requestDeadline: 50 * time.Millisecond,
+	}
+
+	start := time.Now()
+	response := validator.Handle(context.TODO(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Object: testExamplePod()}})
+	assert.False(t, response.Allowed)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
@@ -0,0 +1,74 @@
+package webhookhandler
+
+import (
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	mondoov1alpha2 "go.mondoo.com/mondoo-operator/api/v1alpha2"
+	"go.mondoo.com/mondoo-operator/pkg/mondooclient"
+	"go.mondoo.com/mondoo-operator/pkg/webhooks/decisionlog"
+)
+
+// decision collects the details of an admission decision while the request is handled.
+type decision struct {
+	uid string
+	// modeSource tells whether the mode comes from the MondooAuditConfig or from the label of the namespace.
+	modeSource string
+	skipReason string
+	score      *mondooclient.Score
+	exemption  string
+	degraded   bool
+	// wouldDeny is set in audit mode if the resource would have been denied in enforcing mode.
+	wouldDeny bool
+}
+
+func (d *decision) skip(reason string) {
+	d.skipReason = reason
+	metricsSkippedScans.WithLabelValues(reason).Inc()
+}
+
+// recordDecision counts the decision in the metrics and writes it to the decision log.
+func (a *webhookValidator) recordDecision(
+	req admission.Request, mode mondoov1alpha2.AdmissionMode, response admission.Response, d *decision, latency time.Duration,
+) {
+	result := "allowed"
+	if !response.Allowed {
+		result = "denied"
+	} else if d.wouldDeny {
+		result = "would_deny"
+	}
+	metricsAdmissionDecisions.WithLabelValues(string(mode), result, req.Kind.Kind, req.Namespace).Inc()
+
+	if a.decisionLog == nil {
+		return
+	}
+	record := decisionlog.Record{
+		Time:       time.Now().UTC(),
+		RequestUID: string(req.UID),
+		Group:      req.Kind.Group,
+		Version:    req.Kind.Version,
+		Kind:       req.Kind.Kind,
+		Namespace:  req.Namespace,
+		Name:       req.Name,
+		UID:        d.uid,
+		Operation:  string(req.Operation),
+		User:       req.UserInfo.Username,
+		Groups:     req.UserInfo.Groups,
+		Mode:       string(mode),
+		ModeSource: d.modeSource,
+		Decision:   result,
+		SkipReason: d.skipReason,
+		Exemption:  d.exemption,
+		Degraded:   d.degraded,
+		LatencyMs:  float64(latency.Microseconds()) / 1000,
+	}
+	if response.Result != nil {
+		record.Reason = string(response.Result.Reason)
+	}
+	if d.score != nil && d.score.Type == mondooclient.ValidScanResult {
+		score := d.score.Value
+		record.Score = &score
+	}
+	a.decisionLog.Log(record)
+}
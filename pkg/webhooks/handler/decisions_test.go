@@ -0,0 +1,120 @@
+package webhookhandler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	mondoov1alpha2 "go.mondoo.com/mondoo-operator/api/v1alpha2"
+	"go.mondoo.com/mondoo-operator/pkg/constants"
+	"go.mondoo.com/mondoo-operator/pkg/mondooclient"
+	"go.mondoo.com/mondoo-operator/pkg/mondooclient/mock"
+	"go.mondoo.com/mondoo-operator/pkg/webhooks/decisionlog"
+)
+
+type recordingSink struct {
+	mu      sync.Mutex
+	records []decisionlog.Record
+}
+
+func (s *recordingSink) Write(record decisionlog.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+	return nil
+}
+
+func (s *recordingSink) Close() error {
+	return nil
+}
+
+func TestWebhookDecisionLog(t *testing.T) {
+	decoder := setupDecoder(t)
+	mockCtrl := gomock.NewController(t)
+	scanner := mock.NewMockClient(mockCtrl)
+	gomock.InOrder(
+		scanner.EXPECT().RunAdmissionReview(gomock.Any(), gomock.Any()).Return(&mondooclient.ScanResult{
+			WorstScore: &mondooclient.Score{Type: mondooclient.ValidScanResult, Value: 40},
+		}, nil),
+		scanner.EXPECT().RunAdmissionReview(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("scan API unavailable")),
+	)
+
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "unchecked",
+		Labels: map[string]string{constants.AdmissionModeNamespaceLabel: constants.AdmissionModeDisabled},
+	}}
+	sink := &recordingSink{}
+	validator := &webhookValidator{
+		client:         fake.NewClientBuilder().WithObjects(namespace).Build(),
+		decoder:        decoder,
+		mode:           mondoov1alpha2.Enforcing,
+		scanner:        scanner,
+		uniDecoder:     serializer.NewCodecFactory(clientgoscheme.Scheme).UniversalDeserializer(),
+		scoreThreshold: DefaultScoreThreshold,
+		decisionLog:    decisionlog.NewLogger(sink),
+	}
+	request := func(modifiers ...func(*corev1.Pod)) admission.Request {
+		return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			UID:       "request-uid",
+			Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			Name:      "testPod-abcd",
+			Operation: admissionv1.Create,
+			UserInfo:  authenticationv1.UserInfo{Username: "alice", Groups: []string{"developers"}},
+			Object:    testExamplePod(modifiers...),
+		}}
+	}
+
+	assert.False(t, validator.Handle(context.TODO(), request()).Allowed)
+	assert.False(t, validator.Handle(context.TODO(), request()).Allowed)
+	assert.False(t, validator.Handle(context.TODO(), request(func(p *corev1.Pod) {
+		p.OwnerReferences = []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "rs", UID: "abcd", Controller: pointer.Bool(true)}}
+	})).Allowed)
+	disabledRequest := request()
+	disabledRequest.Namespace = namespace.Name
+	assert.True(t, validator.Handle(context.TODO(), disabledRequest).Allowed)
+
+	require.Len(t, sink.records, 4)
+	scanned := sink.records[0]
+	assert.Equal(t, "request-uid", scanned.RequestUID)
+	assert.Equal(t, "Pod", scanned.Kind)
+	assert.Equal(t, "testPod-abcd", scanned.Name)
+	assert.Equal(t, "CREATE", scanned.Operation)
+	assert.Equal(t, "alice", scanned.User)
+	assert.Equal(t, []string{"developers"}, scanned.Groups)
+	assert.Equal(t, string(mondoov1alpha2.Enforcing), scanned.Mode)
+	assert.Equal(t, modeSourceAuditConfig, scanned.ModeSource)
+	assert.Equal(t, "denied", scanned.Decision)
+	require.NotNil(t, scanned.Score)
+	assert.Equal(t, uint32(40), *scanned.Score)
+	assert.NotEmpty(t, scanned.Reason)
+	assert.Empty(t, scanned.SkipReason)
+	assert.False(t, scanned.Degraded)
+	assert.GreaterOrEqual(t, scanned.LatencyMs, float64(0))
+
+	failed := sink.records[1]
+	assert.True(t, failed.Degraded)
+	assert.Nil(t, failed.Score)
+
+	skipped := sink.records[2]
+	assert.Equal(t, skipReasonHasParent, skipped.SkipReason)
+	assert.Nil(t, skipped.Score)
+
+	disabled := sink.records[3]
+	assert.Equal(t, constants.AdmissionModeDisabled, disabled.Mode)
+	assert.Equal(t, modeSourceNamespaceLabel, disabled.ModeSource)
+	assert.Equal(t, "allowed", disabled.Decision)
+}
@@ -0,0 +1,60 @@
+package webhookhandler
+
+import (
+	"fmt"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// eventReasonDenied is the reason of the Event for a resource that was denied
+	eventReasonDenied = "MondooAdmissionDenied"
+	// eventReasonFailedScanPermitted is the reason of the Event for a resource that failed the scan, but was admitted
+	eventReasonFailedScanPermitted = "MondooFailedScanPermitted"
+	// eventReasonExempted is the reason of the Event for an exempted resource that failed the scan
+	eventReasonExempted = "MondooAdmissionExempted"
+	// eventReasonAuditWouldDeny is the reason of the Event for a resource that was admitted in audit mode, but
+	// would have been denied in enforcing mode
+	eventReasonAuditWouldDeny = "MondooAdmissionWouldDeny"
+)
+
+// recordEvent records a Warning Event for the admission decision. A resource whose creation was denied never
+// exists, so the Event is recorded on its namespace instead. Cluster-scoped resources don't have a namespace, so
+// their Event is recorded on the resource itself. The event recorder aggregates and rate-limits repeated Events.
+func (a *webhookValidator) recordEvent(req admission.Request, obj runtime.Object, reason, message string) {
+	if a.recorder == nil {
+		return
+	}
+
+	name := req.Name
+	var uid types.UID
+	if objMeta, err := meta.Accessor(obj); err == nil {
+		// Resources created with a generateName don't have a name yet when they are admitted.
+		name = objMeta.GetName()
+		if name == "" {
+			name = objMeta.GetGenerateName()
+		}
+		uid = objMeta.GetUID()
+	}
+
+	ref := &corev1.ObjectReference{
+		APIVersion: schema.GroupVersion{Group: req.Kind.Group, Version: req.Kind.Version}.String(),
+		Kind:       req.Kind.Kind,
+		Namespace:  req.Namespace,
+		Name:       name,
+		UID:        uid,
+	}
+	if reason == eventReasonDenied && req.Operation == admissionv1.Create && req.Namespace != "" {
+		message = fmt.Sprintf("%s %s: %s", req.Kind.Kind, name, message)
+		// Namespaces are cluster-scoped. Setting the namespace anyway makes sure the Event is created in it.
+		ref = &corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Namespace: req.Namespace, Name: req.Namespace}
+	}
+
+	a.recorder.Event(ref, corev1.EventTypeWarning, reason, truncate(message, maxDenialMessageLength))
+}
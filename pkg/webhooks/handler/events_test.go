@@ -0,0 +1,158 @@
+package webhookhandler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	mondoov1alpha2 "go.mondoo.com/mondoo-operator/api/v1alpha2"
+	"go.mondoo.com/mondoo-operator/pkg/mondooclient"
+	"go.mondoo.com/mondoo-operator/pkg/mondooclient/mock"
+)
+
+func TestWebhookEvents(t *testing.T) {
+	decoder := setupDecoder(t)
+	tests := []struct {
+		name        string
+		mode        mondoov1alpha2.AdmissionMode
+		operation   admissionv1.Operation
+		score       uint32
+		expectEvent string
+	}{
+		{
+			name:        "denied create",
+			mode:        mondoov1alpha2.Enforcing,
+			operation:   admissionv1.Create,
+			score:       20,
+			expectEvent: `Warning MondooAdmissionDenied Pod testPod-abcd: FAILED MONDOO SCAN: score 20 of "//policy/a" is below the threshold of 100 involvedObject{kind=Namespace,apiVersion=v1}`,
+		},
+		{
+			name:        "denied update",
+			mode:        mondoov1alpha2.Enforcing,
+			operation:   admissionv1.Update,
+			score:       20,
+			expectEvent: `Warning MondooAdmissionDenied FAILED MONDOO SCAN: score 20 of "//policy/a" is below the threshold of 100 involvedObject{kind=Pod,apiVersion=v1}`,
+		},
+		{
+			name:        "permitted failed scan",
+			mode:        mondoov1alpha2.Permissive,
+			operation:   admissionv1.Create,
+			score:       20,
+			expectEvent: `Warning MondooFailedScanPermitted Admitted in permissive mode, but the Mondoo scan failed: score 20 of "//policy/a" is below the threshold of 100 involvedObject{kind=Pod,apiVersion=v1}`,
+		},
+		{
+			name:      "passed scan",
+			mode:      mondoov1alpha2.Enforcing,
+			operation: admissionv1.Create,
+			score:     100,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			scanner := mock.NewMockClient(mockCtrl)
+			scanner.EXPECT().RunAdmissionReview(gomock.Any(), gomock.Any()).Return(&mondooclient.ScanResult{
+				WorstScore: &mondooclient.Score{QrId: "//policy/a", Type: mondooclient.ValidScanResult, Value: test.score},
+			}, nil)
+			recorder := record.NewFakeRecorder(10)
+			recorder.IncludeObject = true
+
+			validator := &webhookValidator{
+				client:         fake.NewClientBuilder().Build(),
+				decoder:        decoder,
+				mode:           test.mode,
+				scanner:        scanner,
+				uniDecoder:     serializer.NewCodecFactory(clientgoscheme.Scheme).UniversalDeserializer(),
+				scoreThreshold: DefaultScoreThreshold,
+				recorder:       recorder,
+			}
+
+			validator.Handle(context.TODO(), admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+					Namespace: testNamespace,
+					Operation: test.operation,
+					Object:    testExamplePod(),
+				},
+			})
+
+			if test.expectEvent == "" {
+				assert.Empty(t, recorder.Events)
+				return
+			}
+			require.Len(t, recorder.Events, 1)
+			assert.Equal(t, test.expectEvent, <-recorder.Events)
+		})
+	}
+}
+
+// refRecorder keeps the object references of the recorded Events.
+type refRecorder struct {
+	record.FakeRecorder
+	refs []*corev1.ObjectReference
+}
+
+func (r *refRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	r.refs = append(r.refs, object.(*corev1.ObjectReference))
+	r.FakeRecorder.Event(object, eventtype, reason, message)
+}
+
+func TestRecordEvent_DeniedCreate(t *testing.T) {
+	tests := []struct {
+		name          string
+		req           admission.Request
+		obj           runtime.Object
+		expectRef     corev1.ObjectReference
+		expectMessage string
+	}{
+		{
+			name: "cluster-scoped",
+			req: admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Kind:      metav1.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"},
+				Name:      "admin-all",
+				Operation: admissionv1.Create,
+			}},
+			obj:           &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "admin-all"}},
+			expectRef:     corev1.ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "admin-all"},
+			expectMessage: "Warning MondooAdmissionDenied FAILED MONDOO SCAN",
+		},
+		{
+			name: "generateName",
+			req: admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+				Namespace: testNamespace,
+				Operation: admissionv1.Create,
+			}},
+			obj:           &corev1.Pod{ObjectMeta: metav1.ObjectMeta{GenerateName: "web-", Namespace: testNamespace}},
+			expectRef:     corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Namespace: testNamespace, Name: testNamespace},
+			expectMessage: "Warning MondooAdmissionDenied Pod web-: FAILED MONDOO SCAN",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := &refRecorder{FakeRecorder: *record.NewFakeRecorder(10)}
+			validator := &webhookValidator{recorder: recorder}
+
+			validator.recordEvent(test.req, test.obj, eventReasonDenied, failedScan)
+
+			require.Len(t, recorder.refs, 1)
+			assert.Equal(t, test.expectRef, *recorder.refs[0])
+			assert.Equal(t, test.expectMessage, <-recorder.Events)
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package webhookhandler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.mondoo.com/mondoo-operator/pkg/mondooclient"
+)
+
+const (
+	// maxWarnings is the maximum number of failed checks returned as warnings. The API server limits the
+	// total size of the warnings in a response, so only the worst checks are listed.
+	maxWarnings = 10
+	// maxWarningLength is the maximum length of a single warning.
+	maxWarningLength = 200
+	// maxDenialMessageLength is the maximum length of the message of a denied request.
+	maxDenialMessageLength = 1024
+)
+
+type failedCheck struct {
+	title string
+	score uint32
+}
+
+func (c failedCheck) String() string {
+	return fmt.Sprintf("%s (score %d)", c.title, c.score)
+}
+
+// failedChecks returns the checks with a score below 100, the worst first. If the report contains the policy
+// bundle, the titles of the checks are used and the scores of the policies are left out.
+func failedChecks(result *mondooclient.ScanResult) []failedCheck {
+	if result == nil || result.Full == nil {
+		return nil
+	}
+
+	titles := map[string]string{}
+	if result.Full.Bundle != nil {
+		for _, q := range result.Full.Bundle.Queries {
+			if q == nil {
+				continue
+			}
+			title := q.Title
+			if title == "" {
+				title = q.Mrn
+			}
+			if q.CodeId != "" {
+				titles[q.CodeId] = title
+			}
+			if q.Mrn != "" {
+				titles[q.Mrn] = title
+			}
+		}
+	}
+
+	// The same check can fail for multiple assets, keep the worst score.
+	worst := map[string]uint32{}
+	for _, report := range result.Full.Reports {
+		if report == nil {
+			continue
+		}
+		for qrId, score := range report.Scores {
+			if score == nil || score.Type != mondooclient.ValidScanResult || score.Value >= 100 {
+				continue
+			}
+			title := qrId
+			if len(titles) > 0 {
+				t, ok := titles[qrId]
+				if !ok {
+					continue
+				}
+				title = t
+			}
+			if v, ok := worst[title]; !ok || score.Value < v {
+				worst[title] = score.Value
+			}
+		}
+	}
+
+	checks := make([]failedCheck, 0, len(worst))
+	for title, score := range worst {
+		checks = append(checks, failedCheck{title: title, score: score})
+	}
+	sort.Slice(checks, func(i, j int) bool {
+		if checks[i].score != checks[j].score {
+			return checks[i].score < checks[j].score
+		}
+		return checks[i].title < checks[j].title
+	})
+	return checks
+}
+
+// checkWarnings returns one admission warning per failed check. At most maxWarnings warnings are returned.
+func checkWarnings(checks []failedCheck) []string {
+	var warnings []string
+	for i, c := range checks {
+		if i == maxWarnings-1 && len(checks) > maxWarnings {
+			warnings = append(warnings, fmt.Sprintf("... and %d more failed Mondoo checks", len(checks)-i))
+			break
+		}
+		warnings = append(warnings, truncate("failed Mondoo check: "+c.String(), maxWarningLength))
+	}
+	return warnings
+}
+
+// denialMessage appends as many failed checks to the reason as fit into maxDenialMessageLength.
+func denialMessage(reason string, checks []failedCheck) string {
+	if len(checks) == 0 {
+		return reason
+	}
+
+	// leave room for the number of checks which didn't fit
+	reserved := len(", and 9999 more")
+	msg := strings.Builder{}
+	msg.WriteString(reason)
+	msg.WriteString("; failed checks: ")
+	for i, c := range checks {
+		entry := c.String()
+		if i > 0 {
+			entry = ", " + entry
+			if msg.Len()+len(entry)+reserved > maxDenialMessageLength {
+				msg.WriteString(fmt.Sprintf(", and %d more", len(checks)-i))
+				break
+			}
+		}
+		msg.WriteString(entry)
+	}
+	return truncate(msg.String(), maxDenialMessageLength)
+}
+
+func truncate(s string, length int) string {
+	if len(s) <= length {
+		return s
+	}
+	return s[:length-3] + "..."
+}
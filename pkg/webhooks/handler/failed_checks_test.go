@@ -0,0 +1,137 @@
+package webhookhandler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	mondoov1alpha2 "go.mondoo.com/mondoo-operator/api/v1alpha2"
+	"go.mondoo.com/mondoo-operator/pkg/mondooclient"
+	"go.mondoo.com/mondoo-operator/pkg/mondooclient/mock"
+)
+
+func testScanResult() *mondooclient.ScanResult {
+	return &mondooclient.ScanResult{
+		WorstScore: &mondooclient.Score{QrId: "//policy/k8s", Type: mondooclient.ValidScanResult, Value: 20},
+		Full: &mondooclient.ReportCollection{
+			Bundle: &mondooclient.Bundle{
+				Queries: []*mondooclient.Query{
+					{CodeId: "privileged", Title: "Container should not run as privileged"},
+					{CodeId: "limits", Title: "Container should have resource limits"},
+					{Mrn: "//checks/root", Title: "Container should not run as root"},
+					{CodeId: "probes", Title: "Container should have a liveness probe"},
+				},
+			},
+			Reports: map[string]*mondooclient.Report{
+				"//assets/pod": {Scores: map[string]*mondooclient.Score{
+					"//policy/k8s":  {Type: mondooclient.ValidScanResult, Value: 20},
+					"privileged":    {Type: mondooclient.ValidScanResult, Value: 0},
+					"limits":        {Type: mondooclient.ValidScanResult, Value: 60},
+					"probes":        {Type: mondooclient.ValidScanResult, Value: 100},
+					"//checks/root": {Type: 1},
+				}},
+				"//assets/deployment": {Scores: map[string]*mondooclient.Score{
+					"limits":        {Type: mondooclient.ValidScanResult, Value: 40},
+					"//checks/root": {Type: mondooclient.ValidScanResult, Value: 0},
+				}},
+			},
+		},
+	}
+}
+
+func TestFailedChecks(t *testing.T) {
+	assert.Equal(t, []failedCheck{
+		{title: "Container should not run as privileged", score: 0},
+		{title: "Container should not run as root", score: 0},
+		{title: "Container should have resource limits", score: 40},
+	}, failedChecks(testScanResult()))
+
+	// Without the bundle, the QrIds are used and the policy scores cannot be told apart.
+	result := testScanResult()
+	result.Full.Bundle = nil
+	assert.Equal(t, []failedCheck{
+		{title: "//checks/root", score: 0},
+		{title: "privileged", score: 0},
+		{title: "//policy/k8s", score: 20},
+		{title: "limits", score: 40},
+	}, failedChecks(result))
+
+	assert.Empty(t, failedChecks(&mondooclient.ScanResult{}))
+}
+
+func TestCheckWarnings(t *testing.T) {
+	var checks []failedCheck
+	for i := 0; i < 15; i++ {
+		checks = append(checks, failedCheck{title: fmt.Sprintf("check %d", i)})
+	}
+
+	warnings := checkWarnings(checks)
+	assert.Len(t, warnings, maxWarnings)
+	assert.Equal(t, "failed Mondoo check: check 0 (score 0)", warnings[0])
+	assert.Equal(t, "... and 6 more failed Mondoo checks", warnings[maxWarnings-1])
+
+	warnings = checkWarnings([]failedCheck{{title: strings.Repeat("x", 300)}})
+	assert.Len(t, warnings[0], maxWarningLength)
+}
+
+func TestDenialMessage(t *testing.T) {
+	assert.Equal(t, "denied", denialMessage("denied", nil))
+	assert.Equal(t, "denied; failed checks: a (score 0), b (score 10)",
+		denialMessage("denied", []failedCheck{{title: "a"}, {title: "b", score: 10}}))
+
+	var checks []failedCheck
+	for i := 0; i < 100; i++ {
+		checks = append(checks, failedCheck{title: fmt.Sprintf("Container should not do thing number %d", i)})
+	}
+	msg := denialMessage("denied", checks)
+	assert.LessOrEqual(t, len(msg), maxDenialMessageLength)
+	assert.Regexp(t, `, and \d+ more$`, msg)
+}
+
+func TestWebhookFailedCheckDetails(t *testing.T) {
+	decoder := setupDecoder(t)
+	for _, mode := range []mondoov1alpha2.AdmissionMode{mondoov1alpha2.Permissive, mondoov1alpha2.Enforcing} {
+		t.Run(string(mode), func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			scanner := mock.NewMockClient(mockCtrl)
+			scanner.EXPECT().RunAdmissionReview(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, job *mondooclient.AdmissionReviewJob) (*mondooclient.ScanResult, error) {
+					assert.Equal(t, mondooclient.ReportType_FULL, job.ReportType)
+					return testScanResult(), nil
+				})
+
+			validator := &webhookValidator{
+				decoder:        decoder,
+				mode:           mode,
+				scanner:        scanner,
+				uniDecoder:     serializer.NewCodecFactory(clientgoscheme.Scheme).UniversalDeserializer(),
+				scoreThreshold: DefaultScoreThreshold,
+			}
+
+			response := validator.Handle(context.TODO(), admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{Object: testExamplePod()},
+			})
+
+			if mode == mondoov1alpha2.Permissive {
+				assert.True(t, response.Allowed)
+				assert.Equal(t, []string{
+					"failed Mondoo check: Container should not run as privileged (score 0)",
+					"failed Mondoo check: Container should not run as root (score 0)",
+					"failed Mondoo check: Container should have resource limits (score 40)",
+				}, response.Warnings)
+			} else {
+				assert.False(t, response.Allowed)
+				assert.Contains(t, string(response.Result.Reason),
+					"failed checks: Container should not run as privileged (score 0), Container should not run as root (score 0)")
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package webhookhandler
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/cache"
+
+	"go.mondoo.com/mondoo-operator/pkg/mondooclient"
+)
+
+const (
+	// DefaultLastVerdictWindow is the default time the last known scan result of a workload is used while the
+	// scan API is unavailable
+	DefaultLastVerdictWindow = time.Hour
+
+	lastVerdictsSize = 5000
+)
+
+// lastVerdicts keeps the last known scan result of every workload. It is only used while the scan API is
+// unavailable, so a workload which was admitted before can still be rolled out. A nil lastVerdicts
+// keeps nothing.
+type lastVerdicts struct {
+	cache  *cache.LRUExpireCache
+	window time.Duration
+}
+
+// newLastVerdicts returns a store for the last known scan results, which are used for at most window after the
+// scan. If window is not positive, nil is returned and the degraded mode is disabled.
+func newLastVerdicts(window time.Duration) *lastVerdicts {
+	if window <= 0 {
+		return nil
+	}
+	return &lastVerdicts{cache: cache.NewLRUExpireCache(lastVerdictsSize), window: window}
+}
+
+func (l *lastVerdicts) get(key string) (*mondooclient.ScanResult, bool) {
+	if l == nil || key == "" {
+		return nil, false
+	}
+	result, ok := l.cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+	return result.(*mondooclient.ScanResult), true
+}
+
+func (l *lastVerdicts) add(key string, result *mondooclient.ScanResult) {
+	if l == nil || key == "" {
+		return
+	}
+	l.cache.Add(key, result, l.window)
+}
+
+// workloadKey identifies a workload by the UID of its owner and the hash of its spec. Resources without a
+// controller are their own owner. If the resource has no UID yet, its kind, namespace and name are used instead.
+func workloadKey(obj runtime.Object) (string, error) {
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return "", err
+	}
+	owner := string(objMeta.GetUID())
+	if ref := metav1.GetControllerOfNoCopy(objMeta); ref != nil {
+		owner = string(ref.UID)
+	}
+	if owner == "" {
+		owner = fmt.Sprintf("%s/%s/%s", obj.GetObjectKind().GroupVersionKind().Kind, objMeta.GetNamespace(), objMeta.GetName())
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	content := make(map[string]interface{})
+	if err := json.Unmarshal(data, &content); err != nil {
+		return "", err
+	}
+	spec, _ := content["spec"].(map[string]interface{})
+	// A rolling restart only sets an annotation on the pod template.
+	if template, ok := spec["template"].(map[string]interface{}); ok {
+		if metadata, ok := template["metadata"].(map[string]interface{}); ok {
+			removeNoisyAnnotations(metadata)
+		}
+	}
+
+	specHash, err := hashJSON(spec)
+	if err != nil {
+		return "", err
+	}
+	return owner + "/" + specHash, nil
+}
@@ -0,0 +1,151 @@
+package webhookhandler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	mondoov1alpha2 "go.mondoo.com/mondoo-operator/api/v1alpha2"
+	"go.mondoo.com/mondoo-operator/pkg/mondooclient"
+	"go.mondoo.com/mondoo-operator/pkg/mondooclient/mock"
+)
+
+func TestWorkloadKey(t *testing.T) {
+	deployment := func(modify func(*appsv1.Deployment)) *appsv1.Deployment {
+		d := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: testNamespace, UID: "deployment-uid"},
+			Spec:       appsv1.DeploymentSpec{Replicas: pointer.Int32(1)},
+		}
+		if modify != nil {
+			modify(d)
+		}
+		return d
+	}
+	key := func(obj runtime.Object) string {
+		k, err := workloadKey(obj)
+		require.NoError(t, err)
+		return k
+	}
+
+	base := key(deployment(nil))
+	assert.Equal(t, base, key(deployment(func(d *appsv1.Deployment) {
+		d.ResourceVersion = "42"
+		d.Labels = map[string]string{"team": "payments"}
+		d.Spec.Template.Annotations = map[string]string{"kubectl.kubernetes.io/restartedAt": "2023-01-01T00:00:00Z"}
+	})), "only the spec is part of the key")
+	assert.NotEqual(t, base, key(deployment(func(d *appsv1.Deployment) {
+		d.Spec.Replicas = pointer.Int32(2)
+	})), "spec changes must change the key")
+	assert.NotEqual(t, base, key(deployment(func(d *appsv1.Deployment) {
+		d.UID = "other-uid"
+	})), "other workloads must have another key")
+
+	owned := key(deployment(func(d *appsv1.Deployment) {
+		d.UID = "other-uid"
+		d.OwnerReferences = []metav1.OwnerReference{{UID: "deployment-uid", Controller: pointer.Bool(true)}}
+	}))
+	assert.True(t, strings.HasPrefix(owned, "deployment-uid/"), "the owner UID identifies the workload")
+}
+
+func TestWebhookLastVerdict(t *testing.T) {
+	tests := []struct {
+		name          string
+		mode          mondoov1alpha2.AdmissionMode
+		window        time.Duration
+		pod           func(*corev1.Pod)
+		expectAllowed bool
+		expectVerdict string
+	}{
+		{
+			name:          "enforcing uses the last known verdict",
+			mode:          mondoov1alpha2.Enforcing,
+			window:        time.Minute,
+			expectAllowed: true,
+			expectVerdict: "last_known",
+		},
+		{
+			name:   "enforcing denies never-seen workloads",
+			mode:   mondoov1alpha2.Enforcing,
+			window: time.Minute,
+			pod: func(p *corev1.Pod) {
+				p.UID = "other-uid"
+			},
+			expectVerdict: "default",
+		},
+		{
+			name:   "enforcing denies changed workloads",
+			mode:   mondoov1alpha2.Enforcing,
+			window: time.Minute,
+			pod: func(p *corev1.Pod) {
+				p.Spec.HostNetwork = true
+			},
+			expectVerdict: "default",
+		},
+		{
+			name:          "enforcing without degraded mode",
+			mode:          mondoov1alpha2.Enforcing,
+			expectVerdict: "default",
+		},
+		{
+			name:          "permissive without degraded mode",
+			mode:          mondoov1alpha2.Permissive,
+			expectAllowed: true,
+			expectVerdict: "default",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decoder := setupDecoder(t)
+			mockCtrl := gomock.NewController(t)
+			scanner := mock.NewMockClient(mockCtrl)
+			gomock.InOrder(
+				scanner.EXPECT().RunAdmissionReview(gomock.Any(), gomock.Any()).Return(&mondooclient.ScanResult{
+					WorstScore: &mondooclient.Score{Type: mondooclient.ValidScanResult, Value: 100},
+				}, nil),
+				scanner.EXPECT().RunAdmissionReview(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("scan API unavailable")),
+			)
+
+			validator := &webhookValidator{
+				decoder:        decoder,
+				mode:           test.mode,
+				scanner:        scanner,
+				uniDecoder:     serializer.NewCodecFactory(clientgoscheme.Scheme).UniversalDeserializer(),
+				scoreThreshold: DefaultScoreThreshold,
+				lastVerdicts:   newLastVerdicts(test.window),
+			}
+			withUID := func(p *corev1.Pod) { p.UID = "pod-uid" }
+			request := func(modifiers ...func(*corev1.Pod)) admission.Request {
+				return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Object: testExamplePod(modifiers...)}}
+			}
+
+			assert.True(t, validator.Handle(context.TODO(), request(withUID)).Allowed)
+
+			modifiers := []func(*corev1.Pod){withUID}
+			if test.pod != nil {
+				modifiers = append(modifiers, test.pod)
+			}
+			degraded := testutil.ToFloat64(metricsDegradedDecisions.WithLabelValues(test.expectVerdict))
+			response := validator.Handle(context.TODO(), request(modifiers...))
+
+			assert.Equal(t, test.expectAllowed, response.Allowed)
+			assert.Equal(t, degraded+1, testutil.ToFloat64(metricsDegradedDecisions.WithLabelValues(test.expectVerdict)))
+		})
+	}
+}
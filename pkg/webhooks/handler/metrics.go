@@ -0,0 +1,97 @@
+package webhookhandler
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Skip reasons for metricsSkippedScans
+const (
+	skipReasonAdmissionDisabled = "admission_disabled"
+	skipReasonHasParent         = "has_parent"
+	skipReasonNamespaceFilter   = "namespace_filter"
+	skipReasonSSAUpdate         = "ssa_update"
+)
+
+var (
+	metricsAdmissionDecisions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mondoo_webhook_admission_decisions_total",
+			Help: "Number of admission decisions by admission mode, result, kind and namespace",
+		},
+		[]string{"mode", "result", "kind", "namespace"},
+	)
+	metricsScanDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "mondoo_webhook_scan_duration_seconds",
+			Help:    "Duration of the admission review requests to the scan API",
+			Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 3, 5, 8, 10, 15, 20, 30},
+		},
+	)
+	metricsScanErrors = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "mondoo_webhook_scan_errors_total",
+			Help: "Number of admission review requests to the scan API which failed",
+		},
+	)
+	metricsSkippedScans = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mondoo_webhook_skipped_scans_total",
+			Help: "Number of admission requests which were not scanned, by the reason for skipping them",
+		},
+		[]string{"reason"},
+	)
+	metricsScansInFlight = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "mondoo_webhook_scans_in_flight",
+			Help: "Number of admission review requests to the scan API which are in flight. Requests over the limit are shed instead of queued",
+		},
+	)
+	metricsMaxInFlightScans = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "mondoo_webhook_max_in_flight_scans",
+			Help: "Maximum number of admission review requests to the scan API which may be in flight, 0 if not limited",
+		},
+	)
+	metricsShedScans = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "mondoo_webhook_shed_scans_total",
+			Help: "Number of admission requests which were not scanned, because too many scans were in flight",
+		},
+	)
+	metricsVerdictCacheHits = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "mondoo_webhook_verdict_cache_hits_total",
+			Help: "Number of admission requests answered from the verdict cache",
+		},
+	)
+	metricsVerdictCacheMisses = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "mondoo_webhook_verdict_cache_misses_total",
+			Help: "Number of admission requests which had to be scanned, because no verdict was cached",
+		},
+	)
+	metricsDegradedDecisions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mondoo_webhook_degraded_decisions_total",
+			Help: "Number of admission decisions made while the scan API was unavailable, by the verdict that was applied",
+		},
+		[]string{"verdict"},
+	)
+)
+
+func init() {
+	// Register custom metrics with the global prometheus registry
+	metrics.Registry.MustRegister(
+		metricsAdmissionDecisions,
+		metricsScanDuration,
+		metricsScanErrors,
+		metricsSkippedScans,
+		metricsScansInFlight,
+		metricsMaxInFlightScans,
+		metricsShedScans,
+		metricsVerdictCacheHits,
+		metricsVerdictCacheMisses,
+		metricsDegradedDecisions,
+	)
+}
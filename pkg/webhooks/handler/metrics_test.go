@@ -0,0 +1,75 @@
+package webhookhandler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	mondoov1alpha2 "go.mondoo.com/mondoo-operator/api/v1alpha2"
+	"go.mondoo.com/mondoo-operator/pkg/mondooclient"
+	"go.mondoo.com/mondoo-operator/pkg/mondooclient/mock"
+)
+
+func TestWebhookMetrics(t *testing.T) {
+	decoder := setupDecoder(t)
+	mockCtrl := gomock.NewController(t)
+	scanner := mock.NewMockClient(mockCtrl)
+	gomock.InOrder(
+		scanner.EXPECT().RunAdmissionReview(gomock.Any(), gomock.Any()).Return(&mondooclient.ScanResult{
+			WorstScore: &mondooclient.Score{Type: mondooclient.ValidScanResult, Value: 10},
+		}, nil),
+		scanner.EXPECT().RunAdmissionReview(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("scan API unavailable")),
+	)
+
+	validator := &webhookValidator{
+		decoder:        decoder,
+		mode:           mondoov1alpha2.Enforcing,
+		scanner:        scanner,
+		uniDecoder:     serializer.NewCodecFactory(clientgoscheme.Scheme).UniversalDeserializer(),
+		scoreThreshold: DefaultScoreThreshold,
+	}
+	request := func(modifiers ...func(*corev1.Pod)) admission.Request {
+		return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Kind:   metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			Object: testExamplePod(modifiers...),
+		}}
+	}
+	denied := metricsAdmissionDecisions.WithLabelValues(string(mondoov1alpha2.Enforcing), "denied", "Pod", "")
+	skipped := metricsSkippedScans.WithLabelValues(skipReasonHasParent)
+
+	deniedBefore := testutil.ToFloat64(denied)
+	skippedBefore := testutil.ToFloat64(skipped)
+	errorsBefore := testutil.ToFloat64(metricsScanErrors)
+	scansBefore := scanCount(t)
+
+	assert.False(t, validator.Handle(context.TODO(), request()).Allowed)
+	assert.False(t, validator.Handle(context.TODO(), request()).Allowed)
+	// Skipped resources get the default response, which denies them in enforcing mode.
+	assert.False(t, validator.Handle(context.TODO(), request(func(p *corev1.Pod) {
+		p.OwnerReferences = []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "rs", UID: "abcd", Controller: pointer.Bool(true)}}
+	})).Allowed)
+
+	assert.Equal(t, deniedBefore+3, testutil.ToFloat64(denied))
+	assert.Equal(t, skippedBefore+1, testutil.ToFloat64(skipped))
+	assert.Equal(t, errorsBefore+1, testutil.ToFloat64(metricsScanErrors))
+	assert.Equal(t, scansBefore+2, scanCount(t))
+}
+
+func scanCount(t *testing.T) uint64 {
+	m := &dto.Metric{}
+	require.NoError(t, metricsScanDuration.Write(m))
+	return m.GetHistogram().GetSampleCount()
+}
@@ -0,0 +1,118 @@
+package webhookhandler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/cache"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"go.mondoo.com/mondoo-operator/pkg/mondooclient"
+)
+
+const (
+	// DefaultVerdictCacheSize is the default number of scan results kept in the verdict cache
+	DefaultVerdictCacheSize = 1000
+	// DefaultVerdictCacheTTL is the default time a scan result is kept in the verdict cache
+	DefaultVerdictCacheTTL = 5 * time.Minute
+)
+
+// noisyAnnotations are annotations which change without changing the resource in a way that matters for a scan.
+var noisyAnnotations = []string{
+	"kubectl.kubernetes.io/last-applied-configuration",
+	"kubectl.kubernetes.io/restartedAt",
+	"deployment.kubernetes.io/revision",
+}
+
+// verdictCache keeps the scan results of recently admitted resources, so resources which are submitted again
+// without meaningful changes are not scanned again. A nil verdictCache caches nothing.
+type verdictCache struct {
+	cache *cache.LRUExpireCache
+	ttl   time.Duration
+}
+
+// newVerdictCache returns a cache for at most size scan results, which expire after ttl. If size or ttl is
+// not positive, nil is returned and caching is disabled.
+func newVerdictCache(size int, ttl time.Duration) *verdictCache {
+	if size <= 0 || ttl <= 0 {
+		return nil
+	}
+	return &verdictCache{cache: cache.NewLRUExpireCache(size), ttl: ttl}
+}
+
+func (c *verdictCache) get(key string) (*mondooclient.ScanResult, bool) {
+	if c == nil || key == "" {
+		return nil, false
+	}
+	result, ok := c.cache.Get(key)
+	if !ok {
+		metricsVerdictCacheMisses.Inc()
+		return nil, false
+	}
+	metricsVerdictCacheHits.Inc()
+	return result.(*mondooclient.ScanResult), true
+}
+
+func (c *verdictCache) add(key string, result *mondooclient.ScanResult) {
+	if c == nil || key == "" {
+		return
+	}
+	c.cache.Add(key, result, c.ttl)
+}
+
+// verdictCacheKey returns a hash of the object in the admission request. Fields which change without changing
+// the resource, like the resourceVersion or the managedFields, are left out. The same fields are ignored by
+// objectsOnlyDifferInSSAFields.
+func verdictCacheKey(req admission.Request) (string, error) {
+	obj := make(map[string]interface{})
+	if err := json.Unmarshal(req.Object.Raw, &obj); err != nil {
+		return "", err
+	}
+	delete(obj, "status")
+	if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+		for _, field := range []string{"resourceVersion", "managedFields", "uid", "generation", "creationTimestamp"} {
+			delete(metadata, field)
+		}
+		removeNoisyAnnotations(metadata)
+	}
+	// A rolling restart only sets an annotation on the pod template.
+	if spec, ok := obj["spec"].(map[string]interface{}); ok {
+		if template, ok := spec["template"].(map[string]interface{}); ok {
+			if metadata, ok := template["metadata"].(map[string]interface{}); ok {
+				removeNoisyAnnotations(metadata)
+			}
+		}
+	}
+
+	return hashJSON(map[string]interface{}{
+		"kind":      req.Kind,
+		"namespace": req.Namespace,
+		"object":    obj,
+	})
+}
+
+// hashJSON returns the sha256 hash of the JSON representation of v. The keys of maps are sorted when they are
+// marshaled, so the same value always results in the same hash.
+func hashJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+func removeNoisyAnnotations(metadata map[string]interface{}) {
+	annotations, ok := metadata["annotations"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	for _, a := range noisyAnnotations {
+		delete(annotations, a)
+	}
+	if len(annotations) == 0 {
+		delete(metadata, "annotations")
+	}
+}
@@ -0,0 +1,115 @@
+package webhookhandler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	mondoov1alpha2 "go.mondoo.com/mondoo-operator/api/v1alpha2"
+	"go.mondoo.com/mondoo-operator/pkg/mondooclient"
+	"go.mondoo.com/mondoo-operator/pkg/mondooclient/mock"
+)
+
+func TestVerdictCacheKey(t *testing.T) {
+	key := func(object runtime.RawExtension) string {
+		k, err := verdictCacheKey(admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Object: object}})
+		require.NoError(t, err)
+		return k
+	}
+
+	base := key(testExamplePod())
+	assert.Equal(t, base, key(testExamplePod(func(p *corev1.Pod) {
+		p.ResourceVersion = "42"
+		p.UID = "other-uid"
+		p.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: "kubectl"}}
+		p.Annotations = map[string]string{"kubectl.kubernetes.io/last-applied-configuration": "{}"}
+		p.Status.Phase = corev1.PodRunning
+	})), "noise must not change the key")
+
+	assert.NotEqual(t, base, key(testExamplePod(func(p *corev1.Pod) {
+		p.Spec.HostNetwork = true
+	})), "spec changes must change the key")
+	assert.NotEqual(t, base, key(testExamplePod(func(p *corev1.Pod) {
+		p.Annotations = map[string]string{"container.apparmor.security.beta.kubernetes.io/app": "unconfined"}
+	})), "other annotations must change the key")
+	assert.NotEqual(t, base, key(testExamplePod(func(p *corev1.Pod) {
+		p.Name = "other"
+	})))
+}
+
+func TestVerdictCacheKey_RollingRestart(t *testing.T) {
+	key := func(restartedAt string) string {
+		dep := map[string]interface{}{
+			"kind":     "Deployment",
+			"metadata": map[string]interface{}{"name": "app", "namespace": testNamespace},
+			"spec": map[string]interface{}{"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"annotations": map[string]interface{}{"kubectl.kubernetes.io/restartedAt": restartedAt}},
+			}},
+		}
+		data, err := json.Marshal(dep)
+		require.NoError(t, err)
+		k, err := verdictCacheKey(admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Object: runtime.RawExtension{Raw: data}}})
+		require.NoError(t, err)
+		return k
+	}
+
+	assert.Equal(t, key("2023-01-01T00:00:00Z"), key("2023-01-02T00:00:00Z"))
+}
+
+func TestWebhookVerdictCache(t *testing.T) {
+	decoder := setupDecoder(t)
+	mockCtrl := gomock.NewController(t)
+	scanner := mock.NewMockClient(mockCtrl)
+	scanner.EXPECT().RunAdmissionReview(gomock.Any(), gomock.Any()).Return(&mondooclient.ScanResult{
+		WorstScore: &mondooclient.Score{Type: mondooclient.ValidScanResult, Value: 100},
+	}, nil).Times(2)
+
+	validator := &webhookValidator{
+		decoder:        decoder,
+		mode:           mondoov1alpha2.Enforcing,
+		scanner:        scanner,
+		uniDecoder:     serializer.NewCodecFactory(clientgoscheme.Scheme).UniversalDeserializer(),
+		scoreThreshold: DefaultScoreThreshold,
+		verdictCache:   newVerdictCache(10, time.Minute),
+	}
+	request := func(object runtime.RawExtension) admission.Request {
+		return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Object: object}}
+	}
+
+	hits := testutil.ToFloat64(metricsVerdictCacheHits)
+	misses := testutil.ToFloat64(metricsVerdictCacheMisses)
+
+	assert.True(t, validator.Handle(context.TODO(), request(testExamplePod())).Allowed)
+	assert.True(t, validator.Handle(context.TODO(), request(testExamplePod(func(p *corev1.Pod) {
+		p.ResourceVersion = "2"
+	}))).Allowed)
+	assert.True(t, validator.Handle(context.TODO(), request(testExamplePod(func(p *corev1.Pod) {
+		p.Spec.HostPID = true
+	}))).Allowed)
+
+	assert.Equal(t, hits+1, testutil.ToFloat64(metricsVerdictCacheHits))
+	assert.Equal(t, misses+2, testutil.ToFloat64(metricsVerdictCacheMisses))
+}
+
+func TestNewVerdictCache_Disabled(t *testing.T) {
+	assert.Nil(t, newVerdictCache(0, time.Minute))
+	assert.Nil(t, newVerdictCache(10, 0))
+
+	var c *verdictCache
+	c.add("key", &mondooclient.ScanResult{})
+	_, ok := c.get("key")
+	assert.False(t, ok)
+}
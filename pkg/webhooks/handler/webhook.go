@@ -2,16 +2,22 @@ package webhookhandler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"time"
 
 	"google.golang.org/protobuf/types/known/structpb"
 	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
@@ -24,11 +30,13 @@ import (
 	"go.mondoo.com/mondoo-operator/pkg/constants"
 	"go.mondoo.com/mondoo-operator/pkg/feature_flags"
 	"go.mondoo.com/mondoo-operator/pkg/mondooclient"
-	"go.mondoo.com/mondoo-operator/pkg/utils"
+	"go.mondoo.com/mondoo-operator/pkg/utils/k8s"
+	"go.mondoo.com/mondoo-operator/pkg/webhooks/decisionlog"
 	wutils "go.mondoo.com/mondoo-operator/pkg/webhooks/utils"
 )
 
 // Have kubebuilder generate a ValidatingWebhookConfiguration under the path /validate-k8s-mondoo-com that watches Pod/Deployment creation/updates
+// The operator replaces the rules with the resources configured in the MondooAuditConfig.
 //+kubebuilder:webhook:path=/validate-k8s-mondoo-com,mutating=false,failurePolicy=ignore,sideEffects=None,groups="";apps;batch,resources=pods;deployments;daemonsets;statefulsets;jobs;cronjobs,verbs=create;update,versions=v1,name=policy.k8s.mondoo.com,admissionReviewVersions=v1
 
 var handlerlog = logf.Log.WithName("webhook-validator")
@@ -44,6 +52,12 @@ const (
 	failedScanPermitted = "PERMITTING FAILED SCAN"
 	// failedScan is the Denied result when in Enforcing mode and the scan result was a failing result
 	failedScan = "FAILED MONDOO SCAN"
+	// admissionDisabled is the Allowed result when the admission checks are disabled for the namespace
+	admissionDisabled = "MONDOO ADMISSION DISABLED FOR NAMESPACE"
+	// exemptedScan is the Allowed result when the resource is exempted from the admission checks
+	exemptedScan = "EXEMPTED FROM MONDOO SCAN"
+	// auditWouldDeny is the Allowed result when in Audit mode and the scan result would have been denied
+	auditWouldDeny = "AUDIT: WOULD DENY IN ENFORCING MODE"
 
 	mondooLabelPrefix          = "k8s.mondoo.com/"
 	mondooNamespaceLabel       = mondooLabelPrefix + "namespace"
@@ -57,6 +71,14 @@ const (
 	mondooAuthorLabel          = mondooLabelPrefix + "author"
 	mondooOperationLabel       = mondooLabelPrefix + "operation"
 	mondooClusterIDLabel       = mondooLabelPrefix + "cluster-id"
+	mondooExemptionLabel       = mondooLabelPrefix + "exemption"
+
+	// DefaultScoreThreshold only admits resources with a perfect score.
+	DefaultScoreThreshold = 100
+
+	// modeSourceAuditConfig and modeSourceNamespaceLabel tell where the admission mode of a request comes from.
+	modeSourceAuditConfig    = "MondooAuditConfig"
+	modeSourceNamespaceLabel = "namespace label"
 )
 
 type webhookValidator struct {
@@ -69,6 +91,18 @@ type webhookValidator struct {
 	uniDecoder        runtime.Decoder
 	includeNamespaces []string
 	excludeNamespaces []string
+	namespaceSelector labels.Selector
+	scoreThreshold    uint32
+	exemptionUsers    []string
+	exemptionGroups   []string
+	recorder          record.EventRecorder
+	verdictCache      *verdictCache
+	lastVerdicts      *lastVerdicts
+	discoveryTargets  []string
+	decisionLog       *decisionlog.Logger
+	scanLimiter       *scanLimiter
+	requestDeadline   time.Duration
+	auditStatus       *AuditStatusRecorder
 }
 
 type NewWebhookValidatorOpts struct {
@@ -80,16 +114,63 @@ type NewWebhookValidatorOpts struct {
 	ClusterId         string
 	IncludeNamespaces []string
 	ExcludeNamespaces []string
+	// NamespaceSelector is a label selector in the string representation (e.g. "team=payments,env!=dev").
+	NamespaceSelector string
+	// ScoreThreshold is the minimum score (0-100) a resource needs to pass the scan.
+	ScoreThreshold uint32
+	// ExemptionUsers and ExemptionGroups restrict who may exempt resources from the admission checks. If both
+	// are empty, every user may exempt resources.
+	ExemptionUsers  []string
+	ExemptionGroups []string
+	// Recorder records Events for denied resources and failed scans. No Events are recorded if it is nil.
+	Recorder record.EventRecorder
+	// VerdictCacheSize and VerdictCacheTTL configure the cache for scan results. Caching is disabled if
+	// either of them is 0.
+	VerdictCacheSize int
+	VerdictCacheTTL  time.Duration
+	// LastVerdictWindow is how long the last known scan result of a workload is used while the scan API is
+	// unavailable. The degraded mode is disabled if it is 0.
+	LastVerdictWindow time.Duration
+	// Resources are the resources the webhook is registered for. If empty, the default resources are used.
+	Resources []string
+	// DecisionLog receives a record of every admission decision. Nothing is recorded if it is nil.
+	DecisionLog *decisionlog.Logger
+	// MaxInFlightScans is the number of concurrent requests to the scan API. Requests over the limit get the last
+	// known verdict or the default response right away. The scans are not limited if it is 0.
+	MaxInFlightScans int
+	// WebhookTimeout is the timeoutSeconds of the ValidatingWebhookConfiguration. Requests are answered before it
+	// passes, even if the scan hasn't finished yet.
+	WebhookTimeout time.Duration
+	// AuditStatus counts the would-be denials of the "audit" mode in the MondooAuditConfig status. The counts are
+	// only logged if it is nil.
+	AuditStatus *AuditStatusRecorder
 }
 
 // NewWebhookValidator will initialize a CoreValidator with the provided k8s Client and
-// set it to the provided mode. Returns error if mode is invalid.
+// set it to the provided mode. Returns error if mode, resources, namespace selector or score threshold are invalid.
 func NewWebhookValidator(opts *NewWebhookValidatorOpts) (admission.Handler, error) {
 	webhookMode, err := wutils.ModeStringToAdmissionMode(opts.Mode)
 	if err != nil {
 		return nil, err
 	}
 
+	if opts.ScoreThreshold > 100 {
+		return nil, fmt.Errorf("score threshold must be between 0 and 100, got %d", opts.ScoreThreshold)
+	}
+
+	resources, err := wutils.AdmissionResources(opts.Resources)
+	if err != nil {
+		return nil, err
+	}
+
+	var namespaceSelector labels.Selector
+	if opts.NamespaceSelector != "" {
+		namespaceSelector, err = labels.Parse(opts.NamespaceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid namespace selector: %w", err)
+		}
+	}
+
 	return &webhookValidator{
 		client: opts.Client,
 		mode:   webhookMode,
@@ -102,6 +183,18 @@ func NewWebhookValidator(opts *NewWebhookValidatorOpts) (admission.Handler, erro
 		uniDecoder:        serializer.NewCodecFactory(opts.Client.Scheme()).UniversalDeserializer(),
 		includeNamespaces: opts.IncludeNamespaces,
 		excludeNamespaces: opts.ExcludeNamespaces,
+		namespaceSelector: namespaceSelector,
+		scoreThreshold:    opts.ScoreThreshold,
+		exemptionUsers:    opts.ExemptionUsers,
+		exemptionGroups:   opts.ExemptionGroups,
+		recorder:          opts.Recorder,
+		verdictCache:      newVerdictCache(opts.VerdictCacheSize, opts.VerdictCacheTTL),
+		lastVerdicts:      newLastVerdicts(opts.LastVerdictWindow),
+		discoveryTargets:  discoveryTargets(resources),
+		decisionLog:       opts.DecisionLog,
+		scanLimiter:       newScanLimiter(opts.MaxInFlightScans),
+		requestDeadline:   requestDeadline(opts.WebhookTimeout),
+		auditStatus:       opts.AuditStatus,
 	}, nil
 }
 
@@ -111,27 +204,50 @@ func (a *webhookValidator) Handle(ctx context.Context, req admission.Request) (r
 	resource := fmt.Sprintf("%s/%s", req.Namespace, req.Name)
 	handlerlog.Info("Webhook triggered", "kind", req.Kind.Kind, "resource", resource)
 
+	start := time.Now()
+	d := &decision{}
+	if a.requestDeadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.requestDeadline)
+		defer cancel()
+	}
+	mode, modeSource := a.admissionMode(ctx, req.Namespace)
+	d.modeSource = modeSource
+	defer func() {
+		a.recordDecision(req, mode, response, d, time.Since(start))
+	}()
+	if mode == mondoov1alpha2.AdmissionMode(constants.AdmissionModeDisabled) {
+		handlerlog.Info("skipping because admission is disabled for the namespace", "resource", resource, "modeSource", modeSource)
+		d.skip(skipReasonAdmissionDisabled)
+		return admission.Allowed(admissionDisabled)
+	}
+
 	// the default/safe response
 	response = admission.Allowed(defaultScanPass)
-	if a.mode == mondoov1alpha2.Enforcing {
+	if mode == mondoov1alpha2.Enforcing {
 		response = admission.Denied(defaultScanFail)
 	}
 
 	obj, err := a.objFromRaw(req.Object)
 	if err == nil {
+		if objMeta, err := meta.Accessor(obj); err == nil {
+			d.uid = string(objMeta.GetUID())
+		}
 		if !shouldScanObject(obj) {
 			handlerlog.Info("skipping because the resource has a parent", "resource", resource)
+			d.skip(skipReasonHasParent)
 			return
 		}
 	}
 
-	skip, err := a.skipNamespace(obj)
+	skip, err := a.skipNamespace(ctx, obj)
 	if err != nil {
 		handlerlog.Error(err, "error while checking whether to skip resource based on namespace")
 		return
 	}
 	if skip {
 		handlerlog.Info("skipping based on namespace filtering", "resource", resource)
+		d.skip(skipReasonNamespaceFilter)
 		return
 	}
 
@@ -148,11 +264,21 @@ func (a *webhookValidator) Handle(ctx context.Context, req admission.Request) (r
 		}
 		if skip {
 			handlerlog.V(9).Info("skipping because the old and new object only differ in resourceVersion; happens with server-side apply")
+			d.skip(skipReasonSSAUpdate)
 			return
 		}
 	}
 
-	k8sLabels, err := a.generateLabels(req, obj)
+	// Exempted resources are still scanned, so the exemption shows up on the asset, but they are always admitted.
+	exemption := a.exemption(req, obj)
+	d.exemption = exemption
+	if exemption != "" {
+		handlerlog.Info("resource is exempted from admission checks", "resource", resource, "reason", exemption,
+			"user", req.UserInfo.Username, "groups", req.UserInfo.Groups)
+		response = admission.Allowed(exemptedScan)
+	}
+
+	k8sLabels, err := a.generateLabels(req, obj, exemption)
 	if err != nil {
 		handlerlog.Error(err, "failed to set labels for incoming request")
 		return
@@ -179,49 +305,96 @@ func (a *webhookValidator) Handle(ctx context.Context, req admission.Request) (r
 		handlerlog.Error(err, "failed to create proto struct from admission request")
 		return
 	}
+	// The full report contains the failed checks, which are returned to the user.
 	scanJob := &mondooclient.AdmissionReviewJob{
 		Data:       data,
 		Labels:     k8sLabels,
-		ReportType: mondooclient.ReportType_ERROR,
+		ReportType: mondooclient.ReportType_FULL,
 	}
 
 	scanJob.Discovery = &providers.Discovery{}
 	scanJob.Options = map[string]string{"all-namespaces": "true"}
 	// do not use auto discovery here, because we do not want to scan the cluster
-	scanJob.Discovery.Targets = []string{"pods", "deployments", "daemonsets", "statefulsets", "replicasets", "jobs", "cronjobs"}
+	scanJob.Discovery.Targets = append([]string{}, a.discoveryTargets...)
 
 	if feature_flags.GetAdmissionReviewDiscovery() {
 		scanJob.Discovery.Targets = append(scanJob.Discovery.Targets, "admissionreviews")
 	}
 
-	result, err := a.scanner.RunAdmissionReview(ctx, scanJob)
+	workload, err := workloadKey(obj)
 	if err != nil {
-		handlerlog.Error(err, "error returned from scan request")
-		return
+		handlerlog.Error(err, "failed to compute workload key, the last known verdict cannot be used")
 	}
 
-	passed := false
-	if result.WorstScore != nil && result.WorstScore.Type == mondooclient.ValidScanResult && result.WorstScore.Value == 100 {
-		passed = true
+	result, err := a.scan(ctx, req, workload, scanJob)
+	if err != nil {
+		cause := "scan API unavailable"
+		if errors.Is(err, errScanShed) {
+			cause = "too many scans in flight"
+		} else {
+			handlerlog.Error(err, "error returned from scan request")
+		}
+		d.degraded = true
+		var ok bool
+		result, ok = a.lastVerdicts.get(workload)
+		if !ok {
+			metricsDegradedDecisions.WithLabelValues("default").Inc()
+			handlerlog.Info("DEGRADED: "+cause+" and no last known verdict, applying the default response",
+				"kind", req.Kind.Kind, "resource", resource, "allowed", response.Allowed)
+			return
+		}
+		metricsDegradedDecisions.WithLabelValues("last_known").Inc()
+		handlerlog.Info("DEGRADED: "+cause+", using the last known verdict", "kind", req.Kind.Kind, "resource", resource)
 	}
+	d.score = result.WorstScore
 
-	handlerlog.Info("Scan result", "shouldAdmit", passed, "kind", req.Kind.Kind, "resource", resource, "worstscore", result.WorstScore)
+	passed, failure := a.evaluateScore(result.WorstScore)
+	checks := failedChecks(result)
+
+	handlerlog.Info("Scan result", "shouldAdmit", passed, "kind", req.Kind.Kind, "resource", resource, "worstscore", result.WorstScore,
+		"mode", mode, "modeSource", modeSource, "exemption", exemption, "degraded", d.degraded)
+
+	if exemption != "" {
+		if !passed {
+			a.recordEvent(req, obj, eventReasonExempted,
+				fmt.Sprintf("Exempted from Mondoo admission checks (%s), but the scan failed: %s", exemption, failure))
+		}
+		return admission.Allowed(exemptedScan).WithWarnings(checkWarnings(checks)...)
+	}
 
 	// Depending on the mode, we either just allow the resource through no matter the scan result
 	// or allow/deny based on the scan result
-	switch a.mode {
+	switch mode {
 	case mondoov1alpha2.Permissive:
 		if passed {
 			response = admission.Allowed(passedScan)
 		} else {
 			response = admission.Allowed(failedScanPermitted)
+			a.recordEvent(req, obj, eventReasonFailedScanPermitted, fmt.Sprintf("Admitted in permissive mode, but the Mondoo scan failed: %s", failure))
 		}
+		response = response.WithWarnings(checkWarnings(checks)...)
 	case mondoov1alpha2.Enforcing:
+		if passed {
+			// A resource can pass the score threshold and still have failed checks.
+			response = admission.Allowed(passedScan).WithWarnings(checkWarnings(checks)...)
+		} else {
+			message := denialMessage(fmt.Sprintf("%s: %s", failedScan, failure), checks)
+			response = admission.Denied(message)
+			a.recordEvent(req, obj, eventReasonDenied, message)
+		}
+	case mondoov1alpha2.Audit:
+		// Evaluate like enforcing mode, but always admit the resource.
+		warnings := checkWarnings(checks)
 		if passed {
 			response = admission.Allowed(passedScan)
 		} else {
-			response = admission.Denied(failedScan)
+			d.wouldDeny = true
+			response = admission.Allowed(auditWouldDeny)
+			warnings = append([]string{"Mondoo audit mode: would be denied in enforcing mode: " + failure}, warnings...)
+			a.recordEvent(req, obj, eventReasonAuditWouldDeny, fmt.Sprintf("Would be denied in enforcing mode: %s", failure))
+			a.auditStatus.record(req.Kind.Kind, req.Namespace, workloadName(req, obj))
 		}
+		response = response.WithWarnings(warnings...)
 	default:
 		err := fmt.Errorf("neither permissive nor enforcing modes defined")
 		handlerlog.Error(err, "unexpected runtime environment, allowing the resource through")
@@ -229,6 +402,116 @@ func (a *webhookValidator) Handle(ctx context.Context, req admission.Request) (r
 	return
 }
 
+// scan returns the scan result for the admission request. Results for the same object are served from the
+// verdict cache until they expire. Fresh results are also kept as the last known verdict of the workload.
+// If too many scans are in flight, errScanShed is returned without contacting the scan API.
+func (a *webhookValidator) scan(
+	ctx context.Context, req admission.Request, workload string, scanJob *mondooclient.AdmissionReviewJob,
+) (*mondooclient.ScanResult, error) {
+	key, err := verdictCacheKey(req)
+	if err != nil {
+		handlerlog.Error(err, "failed to compute verdict cache key, scanning without cache")
+	}
+	if result, ok := a.verdictCache.get(key); ok {
+		handlerlog.V(1).Info("using cached scan result", "resource", fmt.Sprintf("%s/%s", req.Namespace, req.Name))
+		return result, nil
+	}
+
+	if !a.scanLimiter.tryAcquire() {
+		metricsShedScans.Inc()
+		return nil, errScanShed
+	}
+	defer a.scanLimiter.release()
+	metricsScansInFlight.Inc()
+	defer metricsScansInFlight.Dec()
+
+	start := time.Now()
+	result, err := a.scanner.RunAdmissionReview(ctx, scanJob)
+	metricsScanDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		metricsScanErrors.Inc()
+		return nil, err
+	}
+	a.verdictCache.add(key, result)
+	a.lastVerdicts.add(workload, result)
+	return result, nil
+}
+
+// admissionMode returns the admission mode for the namespace and where it comes from. A valid
+// AdmissionModeNamespaceLabel on the namespace overrides the mode of the webhook. The namespace is
+// read from the client's cache, so label changes are picked up right away.
+func (a *webhookValidator) admissionMode(ctx context.Context, namespace string) (mondoov1alpha2.AdmissionMode, string) {
+	if namespace == "" {
+		return a.mode, modeSourceAuditConfig
+	}
+
+	ns := &corev1.Namespace{}
+	if err := a.client.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		if !apierrors.IsNotFound(err) {
+			handlerlog.Error(err, "failed to get namespace to look up the admission mode", "namespace", namespace)
+		}
+		return a.mode, modeSourceAuditConfig
+	}
+
+	value, ok := ns.Labels[constants.AdmissionModeNamespaceLabel]
+	if !ok {
+		return a.mode, modeSourceAuditConfig
+	}
+	if value == constants.AdmissionModeDisabled {
+		return mondoov1alpha2.AdmissionMode(value), modeSourceNamespaceLabel
+	}
+	mode, err := wutils.ModeStringToAdmissionMode(value)
+	if err != nil {
+		handlerlog.Info("ignoring invalid admission mode label on namespace", "namespace", namespace, "value", value)
+		return a.mode, modeSourceAuditConfig
+	}
+	return mode, modeSourceNamespaceLabel
+}
+
+// exemption returns the reason of the AdmissionSkipAnnotation on the object. An empty string is returned if
+// the object isn't exempted or the requesting user isn't allowed to exempt resources.
+func (a *webhookValidator) exemption(req admission.Request, obj runtime.Object) string {
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	reason := objMeta.GetAnnotations()[constants.AdmissionSkipAnnotation]
+	if reason == "" {
+		return ""
+	}
+
+	if len(a.exemptionUsers) == 0 && len(a.exemptionGroups) == 0 {
+		return reason
+	}
+	for _, u := range a.exemptionUsers {
+		if u == req.UserInfo.Username {
+			return reason
+		}
+	}
+	for _, g := range a.exemptionGroups {
+		for _, userGroup := range req.UserInfo.Groups {
+			if g == userGroup {
+				return reason
+			}
+		}
+	}
+	handlerlog.Info("ignoring exemption, because the user is not allowed to exempt resources",
+		"namespace", objMeta.GetNamespace(), "name", objMeta.GetName(), "user", req.UserInfo.Username, "groups", req.UserInfo.Groups)
+	return ""
+}
+
+// evaluateScore checks the worst score of a scan against the score threshold. If the score doesn't pass, the
+// reason is returned as well.
+func (a *webhookValidator) evaluateScore(score *mondooclient.Score) (bool, string) {
+	if score == nil || score.Type != mondooclient.ValidScanResult {
+		return false, "the scan did not return a valid score"
+	}
+	if score.Value < a.scoreThreshold {
+		return false, fmt.Sprintf("score %d of %q is below the threshold of %d", score.Value, score.QrId, a.scoreThreshold)
+	}
+	return true, ""
+}
+
 var _ admission.DecoderInjector = &webhookValidator{}
 
 func (a *webhookValidator) InjectDecoder(d *admission.Decoder) error {
@@ -236,7 +519,9 @@ func (a *webhookValidator) InjectDecoder(d *admission.Decoder) error {
 	return nil
 }
 
-func (a *webhookValidator) generateLabels(req admission.Request, obj runtime.Object) (map[string]string, error) {
+// generateLabels returns the labels for the scanned asset. The exemption reason is only added if the resource
+// was exempted from the admission checks.
+func (a *webhookValidator) generateLabels(req admission.Request, obj runtime.Object, exemption string) (map[string]string, error) {
 	labels, err := generateLabelsFromAdmissionRequest(req, obj)
 	if err != nil {
 		return nil, err
@@ -246,6 +531,9 @@ func (a *webhookValidator) generateLabels(req admission.Request, obj runtime.Obj
 	if a.integrationMRN != "" {
 		labels[constants.MondooAssetsIntegrationLabel] = a.integrationMRN
 	}
+	if exemption != "" {
+		labels[mondooExemptionLabel] = exemption
+	}
 
 	return labels, nil
 }
@@ -261,17 +549,38 @@ func (a *webhookValidator) objFromRaw(rawObj runtime.RawExtension) (runtime.Obje
 	return obj, err
 }
 
-func (a *webhookValidator) skipNamespace(obj runtime.Object) (bool, error) {
+func (a *webhookValidator) skipNamespace(ctx context.Context, obj runtime.Object) (bool, error) {
 	objmeta, err := meta.Accessor(obj)
 	if err != nil {
 		handlerlog.Error(err, "error getting metadata from object")
 		return false, nil
 	}
 
-	allow, err := utils.AllowNamespace(objmeta.GetNamespace(), a.includeNamespaces, a.excludeNamespaces)
+	namespace := objmeta.GetNamespace()
+	if namespace == "" {
+		// Namespaces are filtered like the resources in them. Other cluster-scoped resources are never filtered.
+		if obj.GetObjectKind().GroupVersionKind().Kind != "Namespace" {
+			return false, nil
+		}
+		namespace = objmeta.GetName()
+	}
+
+	// The namespace labels are read from the client's cache, so label changes are picked up right away.
+	allow, err := k8s.IsNamespaceAllowed(
+		ctx, a.client, namespace, a.includeNamespaces, a.excludeNamespaces, a.namespaceSelector)
 	return !allow, err
 }
 
+// discoveryTargets returns the discovery targets for the scan of the admitted resources. ReplicaSets are created
+// by Deployments and are not admitted themselves, but they stay part of the discovery.
+func discoveryTargets(resources []wutils.AdmissionResource) []string {
+	targets := make([]string, 0, len(resources)+1)
+	for _, r := range resources {
+		targets = append(targets, r.Resource)
+	}
+	return append(targets, "replicasets")
+}
+
 func generateLabelsFromAdmissionRequest(req admission.Request, obj runtime.Object) (map[string]string, error) {
 	objMeta, err := meta.Accessor(obj)
 	if err != nil {
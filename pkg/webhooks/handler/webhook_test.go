@@ -6,6 +6,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -13,13 +14,16 @@ import (
 
 	admissionv1 "k8s.io/api/admission/v1"
 	appsv1 "k8s.io/api/apps/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 	"sigs.k8s.io/yaml"
 
@@ -27,6 +31,8 @@ import (
 	"go.mondoo.com/mondoo-operator/pkg/constants"
 	"go.mondoo.com/mondoo-operator/pkg/mondooclient"
 	"go.mondoo.com/mondoo-operator/pkg/mondooclient/fakeserver"
+	"go.mondoo.com/mondoo-operator/pkg/mondooclient/mock"
+	wutils "go.mondoo.com/mondoo-operator/pkg/webhooks/utils"
 )
 
 const (
@@ -208,7 +214,7 @@ func TestLabels(t *testing.T) {
 	obj, err := webhook.objFromRaw(req.Object)
 	require.NoError(t, err, "unexpected error while converting request object")
 
-	labels, err := webhook.generateLabels(req, obj)
+	labels, err := webhook.generateLabels(req, obj, "")
 
 	require.NoError(t, err, "unexpected error while testing label creation")
 	assert.Contains(t, labels, mondooClusterIDLabel, "cluster ID label missing")
@@ -323,6 +329,319 @@ func TestWebhookNamespaceFiltering(t *testing.T) {
 	}
 }
 
+func TestWebhookResources(t *testing.T) {
+	clusterRole := &rbacv1.ClusterRole{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"},
+		ObjectMeta: metav1.ObjectMeta{Name: "admin-all"},
+		Rules:      []rbacv1.PolicyRule{{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}},
+	}
+	namespace := &corev1.Namespace{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+		ObjectMeta: metav1.ObjectMeta{Name: "kube-system"},
+	}
+	rawObject := func(obj runtime.Object) runtime.RawExtension {
+		data, err := json.Marshal(obj)
+		require.NoError(t, err)
+		return runtime.RawExtension{Raw: data}
+	}
+
+	resources, err := wutils.AdmissionResources([]string{"clusterroles", "namespaces"})
+	require.NoError(t, err)
+
+	mockCtrl := gomock.NewController(t)
+	scanner := mock.NewMockClient(mockCtrl)
+	scanner.EXPECT().RunAdmissionReview(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, job *mondooclient.AdmissionReviewJob) (*mondooclient.ScanResult, error) {
+			assert.Equal(t, []string{"clusterroles", "namespaces", "replicasets"}, job.Discovery.Targets)
+			return &mondooclient.ScanResult{WorstScore: &mondooclient.Score{Type: mondooclient.ValidScanResult, Value: 10}}, nil
+		})
+
+	validator := &webhookValidator{
+		decoder:           setupDecoder(t),
+		mode:              mondoov1alpha2.Enforcing,
+		scanner:           scanner,
+		uniDecoder:        serializer.NewCodecFactory(clientgoscheme.Scheme).UniversalDeserializer(),
+		scoreThreshold:    DefaultScoreThreshold,
+		includeNamespaces: []string{"app-*"},
+		discoveryTargets:  discoveryTargets(resources),
+	}
+
+	// Cluster-scoped resources are not filtered by namespace.
+	response := validator.Handle(context.TODO(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Kind:   metav1.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"},
+		Object: rawObject(clusterRole),
+	}})
+	assert.False(t, response.Allowed)
+	assert.Contains(t, string(response.Result.Reason), failedScan)
+
+	// Namespaces are filtered by their name.
+	response = validator.Handle(context.TODO(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Kind:   metav1.GroupVersionKind{Version: "v1", Kind: "Namespace"},
+		Object: rawObject(namespace),
+	}})
+	assert.Equal(t, defaultScanFail, string(response.Result.Reason))
+}
+
+func TestWebhookScoreThreshold(t *testing.T) {
+	decoder := setupDecoder(t)
+	tests := []struct {
+		name          string
+		threshold     uint32
+		score         *mondooclient.Score
+		expectAllowed bool
+		expectReason  string
+	}{
+		{
+			name:          "perfect score",
+			threshold:     DefaultScoreThreshold,
+			score:         &mondooclient.Score{QrId: "//policy/a", Type: mondooclient.ValidScanResult, Value: 100},
+			expectAllowed: true,
+			expectReason:  passedScan,
+		},
+		{
+			name:         "score below default threshold",
+			threshold:    DefaultScoreThreshold,
+			score:        &mondooclient.Score{QrId: "//policy/a", Type: mondooclient.ValidScanResult, Value: 80},
+			expectReason: failedScan + `: score 80 of "//policy/a" is below the threshold of 100`,
+		},
+		{
+			name:          "score above threshold",
+			threshold:     70,
+			score:         &mondooclient.Score{QrId: "//policy/a", Type: mondooclient.ValidScanResult, Value: 80},
+			expectAllowed: true,
+			expectReason:  passedScan,
+		},
+		{
+			name:          "score equal to threshold",
+			threshold:     80,
+			score:         &mondooclient.Score{QrId: "//policy/a", Type: mondooclient.ValidScanResult, Value: 80},
+			expectAllowed: true,
+			expectReason:  passedScan,
+		},
+		{
+			name:         "invalid score",
+			threshold:    0,
+			score:        &mondooclient.Score{QrId: "//policy/a", Type: 1},
+			expectReason: failedScan + ": the scan did not return a valid score",
+		},
+		{
+			name:         "no score",
+			threshold:    0,
+			expectReason: failedScan + ": the scan did not return a valid score",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			scanner := mock.NewMockClient(mockCtrl)
+			scanner.EXPECT().RunAdmissionReview(gomock.Any(), gomock.Any()).Return(&mondooclient.ScanResult{WorstScore: test.score}, nil)
+
+			validator := &webhookValidator{
+				decoder:        decoder,
+				mode:           mondoov1alpha2.Enforcing,
+				scanner:        scanner,
+				uniDecoder:     serializer.NewCodecFactory(clientgoscheme.Scheme).UniversalDeserializer(),
+				scoreThreshold: test.threshold,
+			}
+
+			request := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Object: testExamplePod(),
+				},
+			}
+
+			response := validator.Handle(context.TODO(), request)
+
+			assert.Equal(t, test.expectAllowed, response.AdmissionResponse.Allowed)
+			assert.Equal(t, test.expectReason, string(response.AdmissionResponse.Result.Reason))
+		})
+	}
+}
+
+func TestNewWebhookValidator_InvalidScoreThreshold(t *testing.T) {
+	_, err := NewWebhookValidator(&NewWebhookValidatorOpts{Mode: string(mondoov1alpha2.Enforcing), ScoreThreshold: 101})
+	assert.Error(t, err)
+}
+
+func TestWebhookNamespaceAdmissionMode(t *testing.T) {
+	decoder := setupDecoder(t)
+	tests := []struct {
+		name          string
+		mode          mondoov1alpha2.AdmissionMode
+		namespaceMode string
+		scanned       bool
+		expectAllowed bool
+		expectReason  string
+	}{
+		{
+			name:          "configured mode",
+			mode:          mondoov1alpha2.Enforcing,
+			scanned:       true,
+			expectReason:  failedScan + `: score 50 of "//policy/a" is below the threshold of 100`,
+			expectAllowed: false,
+		},
+		{
+			name:          "namespace in permissive mode",
+			mode:          mondoov1alpha2.Enforcing,
+			namespaceMode: "permissive",
+			scanned:       true,
+			expectAllowed: true,
+			expectReason:  failedScanPermitted,
+		},
+		{
+			name:          "namespace in enforcing mode",
+			mode:          mondoov1alpha2.Permissive,
+			namespaceMode: "enforcing",
+			scanned:       true,
+			expectReason:  failedScan + `: score 50 of "//policy/a" is below the threshold of 100`,
+		},
+		{
+			name:          "namespace with admission disabled",
+			mode:          mondoov1alpha2.Enforcing,
+			namespaceMode: "disabled",
+			expectAllowed: true,
+			expectReason:  admissionDisabled,
+		},
+		{
+			name:          "invalid namespace mode",
+			mode:          mondoov1alpha2.Permissive,
+			namespaceMode: "strict",
+			scanned:       true,
+			expectAllowed: true,
+			expectReason:  failedScanPermitted,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}}
+			if test.namespaceMode != "" {
+				namespace.Labels = map[string]string{constants.AdmissionModeNamespaceLabel: test.namespaceMode}
+			}
+
+			mockCtrl := gomock.NewController(t)
+			scanner := mock.NewMockClient(mockCtrl)
+			if test.scanned {
+				scanner.EXPECT().RunAdmissionReview(gomock.Any(), gomock.Any()).Return(&mondooclient.ScanResult{
+					WorstScore: &mondooclient.Score{QrId: "//policy/a", Type: mondooclient.ValidScanResult, Value: 50},
+				}, nil)
+			}
+
+			validator := &webhookValidator{
+				client:         fake.NewClientBuilder().WithObjects(namespace).Build(),
+				decoder:        decoder,
+				mode:           test.mode,
+				scanner:        scanner,
+				uniDecoder:     serializer.NewCodecFactory(clientgoscheme.Scheme).UniversalDeserializer(),
+				scoreThreshold: DefaultScoreThreshold,
+			}
+
+			request := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Namespace: testNamespace,
+					Object:    testExamplePod(),
+				},
+			}
+
+			response := validator.Handle(context.TODO(), request)
+
+			assert.Equal(t, test.expectAllowed, response.AdmissionResponse.Allowed)
+			assert.Equal(t, test.expectReason, string(response.AdmissionResponse.Result.Reason))
+		})
+	}
+}
+
+func TestWebhookExemption(t *testing.T) {
+	decoder := setupDecoder(t)
+	exempted := func(p *corev1.Pod) {
+		p.Annotations = map[string]string{constants.AdmissionSkipAnnotation: "INC-1234"}
+	}
+	tests := []struct {
+		name            string
+		object          runtime.RawExtension
+		users           []string
+		groups          []string
+		expectAllowed   bool
+		expectReason    string
+		expectExemption string
+	}{
+		{
+			name:          "no exemption",
+			object:        testExamplePod(),
+			expectReason:  failedScan + `: score 50 of "//policy/a" is below the threshold of 100`,
+			expectAllowed: false,
+		},
+		{
+			name:            "exemption without restrictions",
+			object:          testExamplePod(exempted),
+			expectAllowed:   true,
+			expectReason:    exemptedScan,
+			expectExemption: "INC-1234",
+		},
+		{
+			name:            "exemption by allowed user",
+			object:          testExamplePod(exempted),
+			users:           []string{"alice"},
+			expectAllowed:   true,
+			expectReason:    exemptedScan,
+			expectExemption: "INC-1234",
+		},
+		{
+			name:            "exemption by allowed group",
+			object:          testExamplePod(exempted),
+			groups:          []string{"sre"},
+			expectAllowed:   true,
+			expectReason:    exemptedScan,
+			expectExemption: "INC-1234",
+		},
+		{
+			name:          "exemption by user who is not allowed",
+			object:        testExamplePod(exempted),
+			users:         []string{"bob"},
+			groups:        []string{"admins"},
+			expectReason:  failedScan + `: score 50 of "//policy/a" is below the threshold of 100`,
+			expectAllowed: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			scanner := mock.NewMockClient(mockCtrl)
+			scanner.EXPECT().RunAdmissionReview(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, job *mondooclient.AdmissionReviewJob) (*mondooclient.ScanResult, error) {
+					assert.Equal(t, test.expectExemption, job.Labels[mondooExemptionLabel])
+					return &mondooclient.ScanResult{
+						WorstScore: &mondooclient.Score{QrId: "//policy/a", Type: mondooclient.ValidScanResult, Value: 50},
+					}, nil
+				})
+
+			validator := &webhookValidator{
+				decoder:         decoder,
+				mode:            mondoov1alpha2.Enforcing,
+				scanner:         scanner,
+				uniDecoder:      serializer.NewCodecFactory(clientgoscheme.Scheme).UniversalDeserializer(),
+				scoreThreshold:  DefaultScoreThreshold,
+				exemptionUsers:  test.users,
+				exemptionGroups: test.groups,
+			}
+
+			request := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Object:   test.object,
+					UserInfo: authenticationv1.UserInfo{Username: "alice", Groups: []string{"sre", "system:authenticated"}},
+				},
+			}
+
+			response := validator.Handle(context.TODO(), request)
+
+			assert.Equal(t, test.expectAllowed, response.AdmissionResponse.Allowed)
+			assert.Equal(t, test.expectReason, string(response.AdmissionResponse.Result.Reason))
+		})
+	}
+}
+
 func testExamplePod(modifiers ...func(*corev1.Pod)) runtime.RawExtension {
 	pod := &corev1.Pod{
 		TypeMeta: metav1.TypeMeta{
@@ -14,6 +14,8 @@ func ModeStringToAdmissionMode(mode string) (mondoov1alpha2.AdmissionMode, error
 		return mondoov1alpha2.Enforcing, nil
 	case string(mondoov1alpha2.Permissive):
 		return mondoov1alpha2.Permissive, nil
+	case string(mondoov1alpha2.Audit):
+		return mondoov1alpha2.Audit, nil
 	default:
 		return mondoov1alpha2.Permissive, fmt.Errorf("mode %s is not valid", mode)
 	}
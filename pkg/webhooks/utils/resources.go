@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// AdmissionResource describes a kind of resource which can be checked by the admission webhook.
+type AdmissionResource struct {
+	// Resource is the lowercase plural name of the resource, as it is used in RBAC and webhook rules.
+	Resource string
+	schema.GroupVersion
+	// ClusterScoped is true for resources which don't belong to a namespace.
+	ClusterScoped bool
+}
+
+// DefaultAdmissionResources are checked by the admission webhook if no resources are configured.
+var DefaultAdmissionResources = []string{"pods", "deployments", "daemonsets", "statefulsets", "jobs", "cronjobs"}
+
+var admissionResources = map[string]AdmissionResource{
+	"pods":                {Resource: "pods", GroupVersion: schema.GroupVersion{Version: "v1"}},
+	"services":            {Resource: "services", GroupVersion: schema.GroupVersion{Version: "v1"}},
+	"namespaces":          {Resource: "namespaces", GroupVersion: schema.GroupVersion{Version: "v1"}, ClusterScoped: true},
+	"deployments":         {Resource: "deployments", GroupVersion: schema.GroupVersion{Group: "apps", Version: "v1"}},
+	"daemonsets":          {Resource: "daemonsets", GroupVersion: schema.GroupVersion{Group: "apps", Version: "v1"}},
+	"statefulsets":        {Resource: "statefulsets", GroupVersion: schema.GroupVersion{Group: "apps", Version: "v1"}},
+	"jobs":                {Resource: "jobs", GroupVersion: schema.GroupVersion{Group: "batch", Version: "v1"}},
+	"cronjobs":            {Resource: "cronjobs", GroupVersion: schema.GroupVersion{Group: "batch", Version: "v1"}},
+	"ingresses":           {Resource: "ingresses", GroupVersion: schema.GroupVersion{Group: "networking.k8s.io", Version: "v1"}},
+	"networkpolicies":     {Resource: "networkpolicies", GroupVersion: schema.GroupVersion{Group: "networking.k8s.io", Version: "v1"}},
+	"roles":               {Resource: "roles", GroupVersion: schema.GroupVersion{Group: "rbac.authorization.k8s.io", Version: "v1"}},
+	"rolebindings":        {Resource: "rolebindings", GroupVersion: schema.GroupVersion{Group: "rbac.authorization.k8s.io", Version: "v1"}},
+	"clusterroles":        {Resource: "clusterroles", GroupVersion: schema.GroupVersion{Group: "rbac.authorization.k8s.io", Version: "v1"}, ClusterScoped: true},
+	"clusterrolebindings": {Resource: "clusterrolebindings", GroupVersion: schema.GroupVersion{Group: "rbac.authorization.k8s.io", Version: "v1"}, ClusterScoped: true},
+}
+
+// AdmissionResources returns the descriptions of the resources in the same order. If no resources are provided,
+// the DefaultAdmissionResources are returned. Returns an error if a resource is not supported.
+func AdmissionResources(resources []string) ([]AdmissionResource, error) {
+	if len(resources) == 0 {
+		resources = DefaultAdmissionResources
+	}
+
+	var result []AdmissionResource
+	seen := make(map[string]bool, len(resources))
+	for _, r := range resources {
+		if seen[r] {
+			continue
+		}
+		seen[r] = true
+		resource, ok := admissionResources[r]
+		if !ok {
+			return nil, fmt.Errorf("resource %q is not supported by the admission webhook", r)
+		}
+		result = append(result, resource)
+	}
+	return result, nil
+}